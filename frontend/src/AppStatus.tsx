@@ -10,9 +10,10 @@ export interface AppStatus {
     LastSave: string;
     Sorting: SortingOption;
     BuildVersion?: string;
+    CollapseDuplicates?: boolean;
 }
 
-export type SortingOption = 'entry' | 'title' | 'authorMusic' | 'authorLyric';
+export type SortingOption = 'entry' | 'title' | 'authorMusic' | 'authorLyric' | 'lastUsed' | 'chapter';
 
 export const isEqualAppStatus = (status1: AppStatus, status2: AppStatus): boolean => {
     return (
@@ -58,7 +58,9 @@ export function InfoBox(props: Props) {
     { value: 'entry' as SortingOption, label: t('infoBox.sortOptions.entry') },
     { value: 'title' as SortingOption, label: t('infoBox.sortOptions.title') },
     { value: 'authorMusic' as SortingOption, label: t('infoBox.sortOptions.authorMusic') },
-    { value: 'authorLyric' as SortingOption, label: t('infoBox.sortOptions.authorLyric') }
+    { value: 'authorLyric' as SortingOption, label: t('infoBox.sortOptions.authorLyric') },
+    { value: 'lastUsed' as SortingOption, label: t('infoBox.sortOptions.lastUsed') },
+    { value: 'chapter' as SortingOption, label: t('infoBox.sortOptions.chapter') }
   ];
 
   const sourceOptions = [
@@ -1,6 +1,6 @@
 import { useContext, useEffect, useMemo, useRef, useState } from "react";
 import { useTranslation } from "react-i18next";
-import { GetCombinedPdfWithOptions, GetSongProjection, GetSongVerses } from "../../../wailsjs/go/app/App";
+import { GetCombinedPdfWithOptions, GetSongProjection, GetSongVerses, RecordSongProjection } from "../../../wailsjs/go/app/App";
 import logoImage from "../../assets/images/logo-universal.png";
 import { useScreenDetection } from "../../hooks/useScreenDetection";
 import { SelectionContext } from "../../selectionContext";
@@ -26,6 +26,8 @@ export const SelectedSongsPanel = () => {
     const [showScreenSelector, setShowScreenSelector] = useState(false);
     const [shouldCropPdf, setShouldCropPdf] = useState(false);
     const projectionMessageHandlerRef = useRef<((event: globalThis.MessageEvent) => void) | null>(null);
+    // Songs already recorded in the usage history during this projection
+    const recordedSongIdsRef = useRef<Set<number>>(new Set());
 
     // Use custom hook for screen detection
     const availableScreens = useScreenDetection();
@@ -157,6 +159,7 @@ export const SelectedSongsPanel = () => {
             setError("");
             setCurrentSongIdx(0);
             setCurrentVerseIdx(0);
+            recordedSongIdsRef.current = new Set();
 
             const getProj = typeof GetSongProjection === "function" ? GetSongProjection : undefined;
             const getVerses = typeof GetSongVerses === "function" ? GetSongVerses : undefined;
@@ -194,10 +197,17 @@ export const SelectedSongsPanel = () => {
                 window.removeEventListener("message", projectionMessageHandlerRef.current);
             }
 
+            const projectedSongs = [...selectedSongs];
             projectionMessageHandlerRef.current = (event: globalThis.MessageEvent) => {
                 if (event.data && event.data.type === "projection-state") {
                     setCurrentSongIdx(event.data.songIdx || 0);
                     setCurrentVerseIdx(event.data.verseIdx || 0);
+                    // A song counts as sung once it is shown on the screen
+                    const shown = projectedSongs[event.data.songIdx || 0];
+                    if (shown && !recordedSongIdsRef.current.has(shown.id) && typeof RecordSongProjection === "function") {
+                        recordedSongIdsRef.current.add(shown.id);
+                        RecordSongProjection(shown.id, "").catch(() => undefined);
+                    }
                 }
             };
             window.addEventListener("message", projectionMessageHandlerRef.current);
@@ -9,6 +9,7 @@ vi.mock('../../../../wailsjs/go/app/App', () => ({
   GetCombinedPdfWithOptions: vi.fn(),
   GetSongProjection: vi.fn(),
   GetSongVerses: vi.fn(),
+  RecordSongProjection: vi.fn(() => Promise.resolve()),
 }));
 
 vi.mock('../../PdfModal', () => ({
@@ -2,6 +2,22 @@
 // This file is automatically generated. DO NOT EDIT
 import {app} from '../models';
 
+export function AddSongScriptureRef(arg1:number,arg2:string):Promise<void>;
+
+export function AddSongTag(arg1:number,arg2:string):Promise<void>;
+
+export function CreateSongbook(arg1:string,arg2:string):Promise<void>;
+
+export function DeleteLiturgicalRule(arg1:number):Promise<void>;
+
+export function DeleteService(arg1:number):Promise<void>;
+
+export function DeleteSong(arg1:number):Promise<void>;
+
+export function DeleteSongbook(arg1:string):Promise<void>;
+
+export function DiffSongRevisions(arg1:number,arg2:number):Promise<Array<app.dtoRevisionChange>>;
+
 export function DownloadEz():Promise<void>;
 
 export function DownloadInternal():Promise<void>;
@@ -10,28 +26,130 @@ export function DownloadKK():Promise<void>;
 
 export function DownloadSongBase():Promise<void>;
 
+export function DuplicateService(arg1:number,arg2:string):Promise<number>;
+
+export function ExportChordPro(arg1:number):Promise<string>;
+
+export function ExportOfflineBundle(arg1:string):Promise<app.dtoOfflineBundle>;
+
+export function ExportOpenLyrics(arg1:number):Promise<string>;
+
+export function ExportScriptureIndex():Promise<string>;
+
+export function ExportServicePresentation(arg1:number,arg2:app.dtoPresentationOptions):Promise<string>;
+
+export function ExportSongbookOpenLyrics(arg1:string):Promise<string>;
+
+export function ExportSongsPresentation(arg1:Array<number>,arg2:app.dtoPresentationOptions):Promise<string>;
+
+export function ExportUsageReportCsv(arg1:string,arg2:string):Promise<string>;
+
+export function ExportUsageReportPdf(arg1:string,arg2:string):Promise<string>;
+
+export function ExportUserData():Promise<string>;
+
 export function FillDatabase():Promise<void>;
 
+export function FindSongsForScripture(arg1:string):Promise<Array<app.dtoScriptureMatch>>;
+
+export function GetAllTags():Promise<Array<app.dtoTag>>;
+
 export function GetCombinedPdf(arg1:Array<string>):Promise<string>;
 
 export function GetCombinedPdfWithOptions(arg1:Array<string>,arg2:boolean,arg3:number):Promise<string>;
 
+export function GetDatabaseSnapshots():Promise<Array<app.dtoDatabaseSnapshot>>;
+
+export function GetDefaultSettings():Promise<app.AppSettings>;
+
+export function GetDeletedSongs(arg1:string):Promise<Array<app.dtoDeletedSong>>;
+
+export function GetFavoriteSongs():Promise<Array<app.dtoSongHeader>>;
+
+export function GetLiturgicalDay(arg1:string):Promise<app.dtoLiturgicalDay>;
+
+export function GetLiturgicalRules():Promise<Array<app.dtoLiturgicalRule>>;
+
+export function GetNeverUsedSongs(arg1:string):Promise<Array<app.dtoSongHeader>>;
+
 export function GetPdfFile(arg1:string):Promise<string>;
 
+export function GetService(arg1:number):Promise<app.dtoService>;
+
+export function GetServiceItemProjection(arg1:number):Promise<string>;
+
+export function GetServiceOrderPdf(arg1:number):Promise<string>;
+
+export function GetServices():Promise<Array<app.dtoService>>;
+
+export function GetSettings():Promise<app.dtoSettings>;
+
+export function GetSimilarSongs(arg1:number,arg2:number):Promise<Array<app.dtoSimilarSong>>;
+
 export function GetSongAuthors(arg1:number):Promise<Array<app.Author>>;
 
+export function GetSongChapters(arg1:string):Promise<Array<app.dtoSongChapter>>;
+
+export function GetSongConcordance(arg1:number):Promise<Array<app.dtoConcordance>>;
+
+export function GetSongDetails(arg1:number):Promise<app.dtoSongDetails>;
+
+export function GetSongDraft(arg1:number):Promise<app.dtoSongDraft>;
+
+export function GetSongLastUsed(arg1:number):Promise<app.dtoSongUsage>;
+
+export function GetSongLocalEdits(arg1:number):Promise<Array<app.dtoSongOverlay>>;
+
 export function GetSongProjection(arg1:number):Promise<string>;
 
+export function GetSongProjectionWithOrder(arg1:number,arg2:string):Promise<string>;
+
+export function GetSongRevisions(arg1:number):Promise<Array<app.dtoSongRevision>>;
+
+export function GetSongScriptureRefs(arg1:number):Promise<Array<app.dtoScriptureRef>>;
+
+export function GetSongTags(arg1:number):Promise<Array<string>>;
+
+export function GetSongTune(arg1:number):Promise<app.dtoSongTune>;
+
+export function GetSongUsageStats(arg1:string,arg2:string,arg3:string):Promise<Array<app.dtoSongUsage>>;
+
+export function GetSongVerseOrder(arg1:number):Promise<app.dtoVerseOrder>;
+
 export function GetSongVerses(arg1:number):Promise<string>;
 
+export function GetSongbooks():Promise<Array<app.dtoSongbook>>;
+
 export function GetSongs(arg1:string,arg2:string,arg3:string):Promise<Array<app.dtoSong>>;
 
 export function GetSongs2(arg1:string,arg2:string,arg3:string):Promise<Array<app.dtoSongHeader>>;
 
+export function GetSongsSharingTune(arg1:number):Promise<Array<app.dtoTuneMatch>>;
+
+export function GetSongsWithSameMeter(arg1:number):Promise<Array<app.dtoTuneMatch>>;
+
 export function GetStatus():Promise<app.AppStatus>;
 
+export function GetSuggestedSongs(arg1:string,arg2:number):Promise<Array<app.dtoSongSuggestion>>;
+
+export function ImportChordPro(arg1:string,arg2:string,arg3:string):Promise<number>;
+
+export function ImportOfflineBundle(arg1:string):Promise<app.dtoOfflineBundle>;
+
+export function ImportOpenLP(arg1:string,arg2:string,arg3:string):Promise<app.dtoImportSummary>;
+
+export function ImportOpenSong(arg1:string,arg2:string,arg3:string):Promise<number>;
+
+export function ImportScriptureIndex():Promise<number>;
+
+export function ImportSongChapters():Promise<void>;
+
+export function ImportUserData(arg1:string,arg2:string):Promise<app.dtoBackupSummary>;
+
 export function InitializeDatabase():Promise<void>;
 
+export function PreviewOpenLPImport(arg1:string):Promise<app.dtoImportSummary>;
+
 export function ProcessKytaraPDF():Promise<void>;
 
 export function ProjectionNextSong():Promise<void>;
@@ -42,8 +160,42 @@ export function ProjectionPrevSong():Promise<void>;
 
 export function ProjectionPrevVerse():Promise<void>;
 
+export function RebuildConcordance():Promise<number>;
+
+export function RebuildSimilarSongs():Promise<number>;
+
+export function RecordSongProjection(arg1:number,arg2:string):Promise<void>;
+
+export function RemoveSongScriptureRef(arg1:number):Promise<void>;
+
+export function RemoveSongTag(arg1:number,arg2:string):Promise<void>;
+
 export function ResetData():Promise<void>;
 
+export function RestoreDatabaseSnapshot(arg1:string):Promise<void>;
+
+export function RestoreSongRevision(arg1:number,arg2:string):Promise<number>;
+
+export function RevertSongLocalEdits(arg1:number):Promise<void>;
+
+export function SaveCollapseDuplicates(arg1:boolean):Promise<void>;
+
+export function SaveLiturgicalRule(arg1:app.dtoLiturgicalRule):Promise<number>;
+
+export function SaveService(arg1:app.dtoService):Promise<number>;
+
+export function SaveSettings(arg1:app.AppSettings):Promise<app.dtoSettings>;
+
+export function SaveSong(arg1:app.dtoSongDraft):Promise<number>;
+
+export function SaveSongLocalEdits(arg1:app.dtoSongDraft):Promise<number>;
+
+export function SaveSongTune(arg1:number,arg2:string,arg3:string):Promise<void>;
+
+export function SaveSongVerseOrder(arg1:number,arg2:string):Promise<void>;
+
 export function SaveSorting(arg1:app.SortingOption):Promise<void>;
 
+export function SetSongFavorite(arg1:number,arg2:boolean):Promise<void>;
+
 export function Shutdown():Promise<void>;
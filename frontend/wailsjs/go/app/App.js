@@ -2,6 +2,38 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddSongScriptureRef(arg1, arg2) {
+  return window['go']['app']['App']['AddSongScriptureRef'](arg1, arg2);
+}
+
+export function AddSongTag(arg1, arg2) {
+  return window['go']['app']['App']['AddSongTag'](arg1, arg2);
+}
+
+export function CreateSongbook(arg1, arg2) {
+  return window['go']['app']['App']['CreateSongbook'](arg1, arg2);
+}
+
+export function DeleteLiturgicalRule(arg1) {
+  return window['go']['app']['App']['DeleteLiturgicalRule'](arg1);
+}
+
+export function DeleteService(arg1) {
+  return window['go']['app']['App']['DeleteService'](arg1);
+}
+
+export function DeleteSong(arg1) {
+  return window['go']['app']['App']['DeleteSong'](arg1);
+}
+
+export function DeleteSongbook(arg1) {
+  return window['go']['app']['App']['DeleteSongbook'](arg1);
+}
+
+export function DiffSongRevisions(arg1, arg2) {
+  return window['go']['app']['App']['DiffSongRevisions'](arg1, arg2);
+}
+
 export function DownloadEz() {
   return window['go']['app']['App']['DownloadEz']();
 }
@@ -18,10 +50,62 @@ export function DownloadSongBase() {
   return window['go']['app']['App']['DownloadSongBase']();
 }
 
+export function DuplicateService(arg1, arg2) {
+  return window['go']['app']['App']['DuplicateService'](arg1, arg2);
+}
+
+export function ExportChordPro(arg1) {
+  return window['go']['app']['App']['ExportChordPro'](arg1);
+}
+
+export function ExportOfflineBundle(arg1) {
+  return window['go']['app']['App']['ExportOfflineBundle'](arg1);
+}
+
+export function ExportOpenLyrics(arg1) {
+  return window['go']['app']['App']['ExportOpenLyrics'](arg1);
+}
+
+export function ExportScriptureIndex() {
+  return window['go']['app']['App']['ExportScriptureIndex']();
+}
+
+export function ExportServicePresentation(arg1, arg2) {
+  return window['go']['app']['App']['ExportServicePresentation'](arg1, arg2);
+}
+
+export function ExportSongbookOpenLyrics(arg1) {
+  return window['go']['app']['App']['ExportSongbookOpenLyrics'](arg1);
+}
+
+export function ExportSongsPresentation(arg1, arg2) {
+  return window['go']['app']['App']['ExportSongsPresentation'](arg1, arg2);
+}
+
+export function ExportUsageReportCsv(arg1, arg2) {
+  return window['go']['app']['App']['ExportUsageReportCsv'](arg1, arg2);
+}
+
+export function ExportUsageReportPdf(arg1, arg2) {
+  return window['go']['app']['App']['ExportUsageReportPdf'](arg1, arg2);
+}
+
+export function ExportUserData() {
+  return window['go']['app']['App']['ExportUserData']();
+}
+
 export function FillDatabase() {
   return window['go']['app']['App']['FillDatabase']();
 }
 
+export function FindSongsForScripture(arg1) {
+  return window['go']['app']['App']['FindSongsForScripture'](arg1);
+}
+
+export function GetAllTags() {
+  return window['go']['app']['App']['GetAllTags']();
+}
+
 export function GetCombinedPdf(arg1) {
   return window['go']['app']['App']['GetCombinedPdf'](arg1);
 }
@@ -30,22 +114,130 @@ export function GetCombinedPdfWithOptions(arg1, arg2, arg3) {
   return window['go']['app']['App']['GetCombinedPdfWithOptions'](arg1, arg2, arg3);
 }
 
+export function GetDatabaseSnapshots() {
+  return window['go']['app']['App']['GetDatabaseSnapshots']();
+}
+
+export function GetDefaultSettings() {
+  return window['go']['app']['App']['GetDefaultSettings']();
+}
+
+export function GetDeletedSongs(arg1) {
+  return window['go']['app']['App']['GetDeletedSongs'](arg1);
+}
+
+export function GetFavoriteSongs() {
+  return window['go']['app']['App']['GetFavoriteSongs']();
+}
+
+export function GetLiturgicalDay(arg1) {
+  return window['go']['app']['App']['GetLiturgicalDay'](arg1);
+}
+
+export function GetLiturgicalRules() {
+  return window['go']['app']['App']['GetLiturgicalRules']();
+}
+
+export function GetNeverUsedSongs(arg1) {
+  return window['go']['app']['App']['GetNeverUsedSongs'](arg1);
+}
+
 export function GetPdfFile(arg1) {
   return window['go']['app']['App']['GetPdfFile'](arg1);
 }
 
+export function GetService(arg1) {
+  return window['go']['app']['App']['GetService'](arg1);
+}
+
+export function GetServiceItemProjection(arg1) {
+  return window['go']['app']['App']['GetServiceItemProjection'](arg1);
+}
+
+export function GetServiceOrderPdf(arg1) {
+  return window['go']['app']['App']['GetServiceOrderPdf'](arg1);
+}
+
+export function GetServices() {
+  return window['go']['app']['App']['GetServices']();
+}
+
+export function GetSettings() {
+  return window['go']['app']['App']['GetSettings']();
+}
+
+export function GetSimilarSongs(arg1, arg2) {
+  return window['go']['app']['App']['GetSimilarSongs'](arg1, arg2);
+}
+
 export function GetSongAuthors(arg1) {
   return window['go']['app']['App']['GetSongAuthors'](arg1);
 }
 
+export function GetSongChapters(arg1) {
+  return window['go']['app']['App']['GetSongChapters'](arg1);
+}
+
+export function GetSongConcordance(arg1) {
+  return window['go']['app']['App']['GetSongConcordance'](arg1);
+}
+
+export function GetSongDetails(arg1) {
+  return window['go']['app']['App']['GetSongDetails'](arg1);
+}
+
+export function GetSongDraft(arg1) {
+  return window['go']['app']['App']['GetSongDraft'](arg1);
+}
+
+export function GetSongLastUsed(arg1) {
+  return window['go']['app']['App']['GetSongLastUsed'](arg1);
+}
+
+export function GetSongLocalEdits(arg1) {
+  return window['go']['app']['App']['GetSongLocalEdits'](arg1);
+}
+
 export function GetSongProjection(arg1) {
   return window['go']['app']['App']['GetSongProjection'](arg1);
 }
 
+export function GetSongProjectionWithOrder(arg1, arg2) {
+  return window['go']['app']['App']['GetSongProjectionWithOrder'](arg1, arg2);
+}
+
+export function GetSongRevisions(arg1) {
+  return window['go']['app']['App']['GetSongRevisions'](arg1);
+}
+
+export function GetSongScriptureRefs(arg1) {
+  return window['go']['app']['App']['GetSongScriptureRefs'](arg1);
+}
+
+export function GetSongTags(arg1) {
+  return window['go']['app']['App']['GetSongTags'](arg1);
+}
+
+export function GetSongTune(arg1) {
+  return window['go']['app']['App']['GetSongTune'](arg1);
+}
+
+export function GetSongUsageStats(arg1, arg2, arg3) {
+  return window['go']['app']['App']['GetSongUsageStats'](arg1, arg2, arg3);
+}
+
+export function GetSongVerseOrder(arg1) {
+  return window['go']['app']['App']['GetSongVerseOrder'](arg1);
+}
+
 export function GetSongVerses(arg1) {
   return window['go']['app']['App']['GetSongVerses'](arg1);
 }
 
+export function GetSongbooks() {
+  return window['go']['app']['App']['GetSongbooks']();
+}
+
 export function GetSongs(arg1, arg2, arg3) {
   return window['go']['app']['App']['GetSongs'](arg1, arg2, arg3);
 }
@@ -54,14 +246,58 @@ export function GetSongs2(arg1, arg2, arg3) {
   return window['go']['app']['App']['GetSongs2'](arg1, arg2, arg3);
 }
 
+export function GetSongsSharingTune(arg1) {
+  return window['go']['app']['App']['GetSongsSharingTune'](arg1);
+}
+
+export function GetSongsWithSameMeter(arg1) {
+  return window['go']['app']['App']['GetSongsWithSameMeter'](arg1);
+}
+
 export function GetStatus() {
   return window['go']['app']['App']['GetStatus']();
 }
 
+export function GetSuggestedSongs(arg1, arg2) {
+  return window['go']['app']['App']['GetSuggestedSongs'](arg1, arg2);
+}
+
+export function ImportChordPro(arg1, arg2, arg3) {
+  return window['go']['app']['App']['ImportChordPro'](arg1, arg2, arg3);
+}
+
+export function ImportOfflineBundle(arg1) {
+  return window['go']['app']['App']['ImportOfflineBundle'](arg1);
+}
+
+export function ImportOpenLP(arg1, arg2, arg3) {
+  return window['go']['app']['App']['ImportOpenLP'](arg1, arg2, arg3);
+}
+
+export function ImportOpenSong(arg1, arg2, arg3) {
+  return window['go']['app']['App']['ImportOpenSong'](arg1, arg2, arg3);
+}
+
+export function ImportScriptureIndex() {
+  return window['go']['app']['App']['ImportScriptureIndex']();
+}
+
+export function ImportSongChapters() {
+  return window['go']['app']['App']['ImportSongChapters']();
+}
+
+export function ImportUserData(arg1, arg2) {
+  return window['go']['app']['App']['ImportUserData'](arg1, arg2);
+}
+
 export function InitializeDatabase() {
   return window['go']['app']['App']['InitializeDatabase']();
 }
 
+export function PreviewOpenLPImport(arg1) {
+  return window['go']['app']['App']['PreviewOpenLPImport'](arg1);
+}
+
 export function ProcessKytaraPDF() {
   return window['go']['app']['App']['ProcessKytaraPDF']();
 }
@@ -82,14 +318,82 @@ export function ProjectionPrevVerse() {
   return window['go']['app']['App']['ProjectionPrevVerse']();
 }
 
+export function RebuildConcordance() {
+  return window['go']['app']['App']['RebuildConcordance']();
+}
+
+export function RebuildSimilarSongs() {
+  return window['go']['app']['App']['RebuildSimilarSongs']();
+}
+
+export function RecordSongProjection(arg1, arg2) {
+  return window['go']['app']['App']['RecordSongProjection'](arg1, arg2);
+}
+
+export function RemoveSongScriptureRef(arg1) {
+  return window['go']['app']['App']['RemoveSongScriptureRef'](arg1);
+}
+
+export function RemoveSongTag(arg1, arg2) {
+  return window['go']['app']['App']['RemoveSongTag'](arg1, arg2);
+}
+
 export function ResetData() {
   return window['go']['app']['App']['ResetData']();
 }
 
+export function RestoreDatabaseSnapshot(arg1) {
+  return window['go']['app']['App']['RestoreDatabaseSnapshot'](arg1);
+}
+
+export function RestoreSongRevision(arg1, arg2) {
+  return window['go']['app']['App']['RestoreSongRevision'](arg1, arg2);
+}
+
+export function RevertSongLocalEdits(arg1) {
+  return window['go']['app']['App']['RevertSongLocalEdits'](arg1);
+}
+
+export function SaveCollapseDuplicates(arg1) {
+  return window['go']['app']['App']['SaveCollapseDuplicates'](arg1);
+}
+
+export function SaveLiturgicalRule(arg1) {
+  return window['go']['app']['App']['SaveLiturgicalRule'](arg1);
+}
+
+export function SaveService(arg1) {
+  return window['go']['app']['App']['SaveService'](arg1);
+}
+
+export function SaveSettings(arg1) {
+  return window['go']['app']['App']['SaveSettings'](arg1);
+}
+
+export function SaveSong(arg1) {
+  return window['go']['app']['App']['SaveSong'](arg1);
+}
+
+export function SaveSongLocalEdits(arg1) {
+  return window['go']['app']['App']['SaveSongLocalEdits'](arg1);
+}
+
+export function SaveSongTune(arg1, arg2, arg3) {
+  return window['go']['app']['App']['SaveSongTune'](arg1, arg2, arg3);
+}
+
+export function SaveSongVerseOrder(arg1, arg2) {
+  return window['go']['app']['App']['SaveSongVerseOrder'](arg1, arg2);
+}
+
 export function SaveSorting(arg1) {
   return window['go']['app']['App']['SaveSorting'](arg1);
 }
 
+export function SetSongFavorite(arg1, arg2) {
+  return window['go']['app']['App']['SetSongFavorite'](arg1, arg2);
+}
+
 export function Shutdown() {
   return window['go']['app']['App']['Shutdown']();
 }
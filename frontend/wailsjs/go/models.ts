@@ -1,5 +1,67 @@
 export namespace app {
 	
+	export class SupplementalPDF {
+	    URL: string;
+	    FileName: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SupplementalPDF(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.URL = source["URL"];
+	        this.FileName = source["FileName"];
+	    }
+	}
+	export class AppSettings {
+	    XMLUrlEZ: string;
+	    XMLUrlKK: string;
+	    PDFUrl: string;
+	    SupplementalPDFs: SupplementalPDF[];
+	    Mirrors: string[];
+	    DataDir: string;
+	    LogLevel: string;
+	    Proxy: string;
+	    DownloadTimeoutSeconds: number;
+	    SnapshotKeep: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AppSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.XMLUrlEZ = source["XMLUrlEZ"];
+	        this.XMLUrlKK = source["XMLUrlKK"];
+	        this.PDFUrl = source["PDFUrl"];
+	        this.SupplementalPDFs = this.convertValues(source["SupplementalPDFs"], SupplementalPDF);
+	        this.Mirrors = source["Mirrors"];
+	        this.DataDir = source["DataDir"];
+	        this.LogLevel = source["LogLevel"];
+	        this.Proxy = source["Proxy"];
+	        this.DownloadTimeoutSeconds = source["DownloadTimeoutSeconds"];
+	        this.SnapshotKeep = source["SnapshotKeep"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class AppStatus {
 	    WebResourcesReady: boolean;
 	    SongsReady: boolean;
@@ -11,6 +73,8 @@ export namespace app {
 	    Sorting: string;
 	    SearchPattern: string;
 	    BuildVersion: string;
+	    CollapseDuplicates: boolean;
+	    SimilarSongsStale: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new AppStatus(source);
@@ -28,6 +92,8 @@ export namespace app {
 	        this.Sorting = source["Sorting"];
 	        this.SearchPattern = source["SearchPattern"];
 	        this.BuildVersion = source["BuildVersion"];
+	        this.CollapseDuplicates = source["CollapseDuplicates"];
+	        this.SimilarSongsStale = source["SimilarSongsStale"];
 	    }
 	}
 	export class Author {
@@ -44,6 +110,396 @@ export namespace app {
 	        this.Value = source["Value"];
 	    }
 	}
+	
+	export class dtoBackupSummary {
+	    CreatedAt: string;
+	    SchemaVersion: number;
+	    Songbooks: number;
+	    Songs: number;
+	    Services: number;
+	    Rows: number;
+	    Skipped: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoBackupSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.CreatedAt = source["CreatedAt"];
+	        this.SchemaVersion = source["SchemaVersion"];
+	        this.Songbooks = source["Songbooks"];
+	        this.Songs = source["Songs"];
+	        this.Services = source["Services"];
+	        this.Rows = source["Rows"];
+	        this.Skipped = source["Skipped"];
+	    }
+	}
+	export class dtoConcordance {
+	    Id: number;
+	    Entry: number;
+	    EntryText: string;
+	    Title: string;
+	    SongbookAcronym: string;
+	    Confidence: number;
+	    Reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoConcordance(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.Entry = source["Entry"];
+	        this.EntryText = source["EntryText"];
+	        this.Title = source["Title"];
+	        this.SongbookAcronym = source["SongbookAcronym"];
+	        this.Confidence = source["Confidence"];
+	        this.Reason = source["Reason"];
+	    }
+	}
+	export class dtoDatabaseSnapshot {
+	    Name: string;
+	    Reason: string;
+	    CreatedAt: string;
+	    Size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoDatabaseSnapshot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.Reason = source["Reason"];
+	        this.CreatedAt = source["CreatedAt"];
+	        this.Size = source["Size"];
+	    }
+	}
+	export class dtoDeletedSong {
+	    RevisionId: number;
+	    EntryText: string;
+	    Title: string;
+	    DeletedAfter: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoDeletedSong(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.RevisionId = source["RevisionId"];
+	        this.EntryText = source["EntryText"];
+	        this.Title = source["Title"];
+	        this.DeletedAfter = source["DeletedAfter"];
+	    }
+	}
+	export class dtoImportSummary {
+	    Songs: number;
+	    Verses: number;
+	    Authors: number;
+	    Songbooks: string[];
+	    Titles: string[];
+	    Skipped: string[];
+	    Imported: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoImportSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Songs = source["Songs"];
+	        this.Verses = source["Verses"];
+	        this.Authors = source["Authors"];
+	        this.Songbooks = source["Songbooks"];
+	        this.Titles = source["Titles"];
+	        this.Skipped = source["Skipped"];
+	        this.Imported = source["Imported"];
+	    }
+	}
+	export class dtoLiturgicalDay {
+	    Date: string;
+	    Name: string;
+	    Season: string;
+	    SeasonName: string;
+	    Feast: string;
+	    FeastName: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoLiturgicalDay(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Date = source["Date"];
+	        this.Name = source["Name"];
+	        this.Season = source["Season"];
+	        this.SeasonName = source["SeasonName"];
+	        this.Feast = source["Feast"];
+	        this.FeastName = source["FeastName"];
+	    }
+	}
+	export class dtoLiturgicalRule {
+	    Id: number;
+	    Occasion: string;
+	    Tag: string;
+	    SongbookAcronym: string;
+	    EntryFrom: number;
+	    EntryTo: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoLiturgicalRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.Occasion = source["Occasion"];
+	        this.Tag = source["Tag"];
+	        this.SongbookAcronym = source["SongbookAcronym"];
+	        this.EntryFrom = source["EntryFrom"];
+	        this.EntryTo = source["EntryTo"];
+	    }
+	}
+	export class dtoOfflineBundle {
+	    CreatedAt: string;
+	    BuildVersion: string;
+	    Songbooks: string[];
+	    Files: string[];
+	    Missing: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoOfflineBundle(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.CreatedAt = source["CreatedAt"];
+	        this.BuildVersion = source["BuildVersion"];
+	        this.Songbooks = source["Songbooks"];
+	        this.Files = source["Files"];
+	        this.Missing = source["Missing"];
+	    }
+	}
+	export class dtoPresentationOptions {
+	    Format: string;
+	    FontFamily: string;
+	    FontSize: number;
+	    TextColor: string;
+	    BackgroundColor: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoPresentationOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Format = source["Format"];
+	        this.FontFamily = source["FontFamily"];
+	        this.FontSize = source["FontSize"];
+	        this.TextColor = source["TextColor"];
+	        this.BackgroundColor = source["BackgroundColor"];
+	    }
+	}
+	export class dtoRevisionChange {
+	    Field: string;
+	    VerseName: string;
+	    Change: string;
+	    Before: string;
+	    After: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoRevisionChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Field = source["Field"];
+	        this.VerseName = source["VerseName"];
+	        this.Change = source["Change"];
+	        this.Before = source["Before"];
+	        this.After = source["After"];
+	    }
+	}
+	export class dtoScriptureMatch {
+	    Id: number;
+	    Entry: number;
+	    EntryText: string;
+	    Title: string;
+	    SongbookAcronym: string;
+	    Reference: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoScriptureMatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.Entry = source["Entry"];
+	        this.EntryText = source["EntryText"];
+	        this.Title = source["Title"];
+	        this.SongbookAcronym = source["SongbookAcronym"];
+	        this.Reference = source["Reference"];
+	    }
+	}
+	export class dtoScriptureRef {
+	    Id: number;
+	    Reference: string;
+	    Book: string;
+	    BookName: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoScriptureRef(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.Reference = source["Reference"];
+	        this.Book = source["Book"];
+	        this.BookName = source["BookName"];
+	    }
+	}
+	export class dtoServiceItem {
+	    Id: number;
+	    Position: number;
+	    ItemType: string;
+	    SongId: number;
+	    VerseOrder: string;
+	    Notes: string;
+	    Title: string;
+	    Content: string;
+	    ScriptureRef: string;
+	    Entry: number;
+	    SongbookAcronym: string;
+	    EntryText: string;
+	    Missing: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoServiceItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.Position = source["Position"];
+	        this.ItemType = source["ItemType"];
+	        this.SongId = source["SongId"];
+	        this.VerseOrder = source["VerseOrder"];
+	        this.Notes = source["Notes"];
+	        this.Title = source["Title"];
+	        this.Content = source["Content"];
+	        this.ScriptureRef = source["ScriptureRef"];
+	        this.Entry = source["Entry"];
+	        this.SongbookAcronym = source["SongbookAcronym"];
+	        this.EntryText = source["EntryText"];
+	        this.Missing = source["Missing"];
+	    }
+	}
+	export class dtoService {
+	    Id: number;
+	    ServiceDate: string;
+	    Title: string;
+	    Notes: string;
+	    ItemCount: number;
+	    Items: dtoServiceItem[];
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoService(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.ServiceDate = source["ServiceDate"];
+	        this.Title = source["Title"];
+	        this.Notes = source["Notes"];
+	        this.ItemCount = source["ItemCount"];
+	        this.Items = this.convertValues(source["Items"], dtoServiceItem);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class dtoSettings {
+	    Saved: AppSettings;
+	    Effective: AppSettings;
+	    Overrides: string[];
+	    RestartRequired: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Saved = this.convertValues(source["Saved"], AppSettings);
+	        this.Effective = this.convertValues(source["Effective"], AppSettings);
+	        this.Overrides = source["Overrides"];
+	        this.RestartRequired = source["RestartRequired"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class dtoSimilarSong {
+	    Id: number;
+	    Entry: number;
+	    EntryText: string;
+	    Title: string;
+	    SongbookAcronym: string;
+	    Score: number;
+	    Reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoSimilarSong(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.Entry = source["Entry"];
+	        this.EntryText = source["EntryText"];
+	        this.Title = source["Title"];
+	        this.SongbookAcronym = source["SongbookAcronym"];
+	        this.Score = source["Score"];
+	        this.Reason = source["Reason"];
+	    }
+	}
 	export class dtoSong {
 	    Id: number;
 	    Entry: number;
@@ -53,6 +509,11 @@ export namespace app {
 	    AuthorLyric: string;
 	    KytaraFile: string;
 	    SongbookAcronym: string;
+	    LastUsed: string;
+	    IsFavorite: boolean;
+	    Chapter: string;
+	    AlsoIn: string;
+	    LocallyModified: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new dtoSong(source);
@@ -68,7 +529,146 @@ export namespace app {
 	        this.AuthorLyric = source["AuthorLyric"];
 	        this.KytaraFile = source["KytaraFile"];
 	        this.SongbookAcronym = source["SongbookAcronym"];
+	        this.LastUsed = source["LastUsed"];
+	        this.IsFavorite = source["IsFavorite"];
+	        this.Chapter = source["Chapter"];
+	        this.AlsoIn = source["AlsoIn"];
+	        this.LocallyModified = source["LocallyModified"];
+	    }
+	}
+	export class dtoSongChapter {
+	    Id: number;
+	    SongbookAcronym: string;
+	    Position: number;
+	    Section: string;
+	    Name: string;
+	    Ranges: string;
+	    SongCount: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoSongChapter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.SongbookAcronym = source["SongbookAcronym"];
+	        this.Position = source["Position"];
+	        this.Section = source["Section"];
+	        this.Name = source["Name"];
+	        this.Ranges = source["Ranges"];
+	        this.SongCount = source["SongCount"];
+	    }
+	}
+	export class dtoVerseDetail {
+	    Name: string;
+	    Lang: string;
+	    Translit: string;
+	    Lines: string;
+	    Chords: string;
+	    Comment: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoVerseDetail(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.Lang = source["Lang"];
+	        this.Translit = source["Translit"];
+	        this.Lines = source["Lines"];
+	        this.Chords = source["Chords"];
+	        this.Comment = source["Comment"];
+	    }
+	}
+	export class dtoSongDetails {
+	    Titles: string[];
+	    Themes: string[];
+	    Copyright: string;
+	    CcliNo: string;
+	    Key: string;
+	    Tempo: string;
+	    Verses: dtoVerseDetail[];
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoSongDetails(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Titles = source["Titles"];
+	        this.Themes = source["Themes"];
+	        this.Copyright = source["Copyright"];
+	        this.CcliNo = source["CcliNo"];
+	        this.Key = source["Key"];
+	        this.Tempo = source["Tempo"];
+	        this.Verses = this.convertValues(source["Verses"], dtoVerseDetail);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class dtoSongDraft {
+	    Id: number;
+	    SongbookAcronym: string;
+	    Entry: number;
+	    Title: string;
+	    Authors: Author[];
+	    Verses: dtoVerseDetail[];
+	    VerseOrder: string;
+	    Copyright: string;
+	    EditedBy: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoSongDraft(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.SongbookAcronym = source["SongbookAcronym"];
+	        this.Entry = source["Entry"];
+	        this.Title = source["Title"];
+	        this.Authors = this.convertValues(source["Authors"], Author);
+	        this.Verses = this.convertValues(source["Verses"], dtoVerseDetail);
+	        this.VerseOrder = source["VerseOrder"];
+	        this.Copyright = source["Copyright"];
+	        this.EditedBy = source["EditedBy"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class dtoSongHeader {
 	    Id: number;
@@ -90,6 +690,189 @@ export namespace app {
 	        this.KytaraFile = source["KytaraFile"];
 	    }
 	}
+	export class dtoSongOverlay {
+	    Field: string;
+	    VerseName: string;
+	    Value: string;
+	    Original: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoSongOverlay(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Field = source["Field"];
+	        this.VerseName = source["VerseName"];
+	        this.Value = source["Value"];
+	        this.Original = source["Original"];
+	    }
+	}
+	export class dtoSongRevision {
+	    Id: number;
+	    CreatedAt: string;
+	    EditedBy: string;
+	    Title: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoSongRevision(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.CreatedAt = source["CreatedAt"];
+	        this.EditedBy = source["EditedBy"];
+	        this.Title = source["Title"];
+	    }
+	}
+	export class dtoSongSuggestion {
+	    Id: number;
+	    Entry: number;
+	    EntryText: string;
+	    Title: string;
+	    SongbookAcronym: string;
+	    Occasion: string;
+	    LastUsed: string;
+	    UseCount: number;
+	    Score: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoSongSuggestion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.Entry = source["Entry"];
+	        this.EntryText = source["EntryText"];
+	        this.Title = source["Title"];
+	        this.SongbookAcronym = source["SongbookAcronym"];
+	        this.Occasion = source["Occasion"];
+	        this.LastUsed = source["LastUsed"];
+	        this.UseCount = source["UseCount"];
+	        this.Score = source["Score"];
+	    }
+	}
+	export class dtoSongTune {
+	    TuneName: string;
+	    Meter: string;
+	    ComputedMeter: string;
+	    MeterOverridden: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoSongTune(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.TuneName = source["TuneName"];
+	        this.Meter = source["Meter"];
+	        this.ComputedMeter = source["ComputedMeter"];
+	        this.MeterOverridden = source["MeterOverridden"];
+	    }
+	}
+	export class dtoSongUsage {
+	    Id: number;
+	    Entry: number;
+	    EntryText: string;
+	    Title: string;
+	    SongbookAcronym: string;
+	    UseCount: number;
+	    LastUsed: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoSongUsage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.Entry = source["Entry"];
+	        this.EntryText = source["EntryText"];
+	        this.Title = source["Title"];
+	        this.SongbookAcronym = source["SongbookAcronym"];
+	        this.UseCount = source["UseCount"];
+	        this.LastUsed = source["LastUsed"];
+	    }
+	}
+	export class dtoSongbook {
+	    Acronym: string;
+	    Name: string;
+	    SongCount: number;
+	    Editable: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoSongbook(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Acronym = source["Acronym"];
+	        this.Name = source["Name"];
+	        this.SongCount = source["SongCount"];
+	        this.Editable = source["Editable"];
+	    }
+	}
+	export class dtoTag {
+	    Name: string;
+	    SongCount: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoTag(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.SongCount = source["SongCount"];
+	    }
+	}
+	export class dtoTuneMatch {
+	    Id: number;
+	    Entry: number;
+	    EntryText: string;
+	    Title: string;
+	    SongbookAcronym: string;
+	    TuneName: string;
+	    Meter: string;
+	    Inferred: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoTuneMatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.Entry = source["Entry"];
+	        this.EntryText = source["EntryText"];
+	        this.Title = source["Title"];
+	        this.SongbookAcronym = source["SongbookAcronym"];
+	        this.TuneName = source["TuneName"];
+	        this.Meter = source["Meter"];
+	        this.Inferred = source["Inferred"];
+	    }
+	}
+	
+	export class dtoVerseOrder {
+	    Original: string;
+	    Custom: string;
+	    Effective: string;
+	    VerseNames: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new dtoVerseOrder(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Original = source["Original"];
+	        this.Custom = source["Custom"];
+	        this.Effective = source["Effective"];
+	        this.VerseNames = source["VerseNames"];
+	    }
+	}
 
 }
 
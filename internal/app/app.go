@@ -2,7 +2,6 @@ package app
 
 import (
 	"context"
-	"database/sql"
 	"encoding/base64"
 	"fmt"
 	"io"
@@ -25,12 +24,19 @@ type App struct {
 	pdfFiles    SongFilesSources
 	pdfDir      string
 	xmlUrl      string
+	xmlUrlKK    string
 	dbFilePath  string
 	songBookDir string
 	urlDomain   string
 	status      AppStatus
 	logFile     *os.File
 	testRun     bool
+	// number of database snapshots kept in backups/, 0 disables them
+	snapshotKeep int
+	// effective settings and the settings.yaml they were loaded from
+	settings     AppSettings
+	settingsPath string
+	settingsMu   sync.RWMutex
 	// supplemental download coordination
 	supplementalMu    sync.Mutex
 	supplementalErrCh chan error
@@ -53,8 +59,13 @@ func NewApp() *App {
 		return &App{}
 	}
 
+	// Settings are read before logging is set up as they choose the log level
+	settingsPath := filepath.Join(path, settingsFileName)
+	settings, overrides, settingsErr := loadSettings(settingsPath)
+	logLevel.Set(logLevels[settings.LogLevel])
+
 	// Open or create a log file
-	logFile, err := os.OpenFile(filepath.Join(homeDir, "Lyyyra", "app.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	logFile, err := os.OpenFile(filepath.Join(path, "app.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		slog.Error("Failed to open log file", "error", err)
 		// Continue without file logging, use stderr
@@ -64,32 +75,42 @@ func NewApp() *App {
 	if logFile != nil {
 		logWriter = logFile
 	}
-	logOptions := slog.HandlerOptions{Level: slog.LevelInfo}
+	logOptions := slog.HandlerOptions{Level: logLevel}
 	logger := slog.New(slog.NewTextHandler(logWriter, &logOptions))
 	slog.SetDefault(logger)
 
 	slog.Info("=========================================================================================")
+	if settingsErr != nil {
+		slog.Warn("Using default settings", "path", settingsPath, "error", settingsErr)
+	}
+	if len(overrides) > 0 {
+		slog.Info("Settings overridden by environment", "variables", overrides)
+	}
 
-	xmlUrl := XMLUrl
-	pdfUrl := PDFUrl
+	pdfUrl := settings.PDFUrl
 	// Parse the URL
 	parsedURL, err := url.Parse(pdfUrl)
 	if err != nil {
 		slog.Error("Failed to parse PDF URL", "url", pdfUrl, "error", err)
 		return &App{}
 	}
-	appDir := filepath.Join(homeDir, "Lyyyra", strings.Replace(parsedURL.Host, ":", "_", -1))
+	dataDir := path
+	if settings.DataDir != "" {
+		dataDir = settings.DataDir
+	}
+	appDir := filepath.Join(dataDir, dataFolderName())
 
 	app := App{
-		appDir:      appDir,
-		pdfDir:      filepath.Join(appDir, "PdfSources"),
-		pdfFiles:    SongFilesSources{Domain: parsedURL.Host, Url: pdfUrl, UrlScheme: parsedURL.Scheme, Items: []FileItem{}},
-		xmlUrl:      xmlUrl,
-		dbFilePath:  filepath.Join(appDir, "Songs.db"),
-		songBookDir: filepath.Join(appDir, "SongBook"),
-		urlDomain:   parsedURL.Host,
-		logFile:     logFile,
+		appDir:       appDir,
+		pdfDir:       filepath.Join(appDir, "PdfSources"),
+		pdfFiles:     SongFilesSources{Domain: parsedURL.Host, Url: pdfUrl, UrlScheme: parsedURL.Scheme, Items: []FileItem{}},
+		dbFilePath:   filepath.Join(appDir, "Songs.db"),
+		songBookDir:  filepath.Join(appDir, "SongBook"),
+		urlDomain:    parsedURL.Host,
+		logFile:      logFile,
+		settingsPath: settingsPath,
 	}
+	app.applySettings(settings)
 
 	if err := os.MkdirAll(app.songBookDir, os.ModePerm); err != nil {
 		slog.Error(fmt.Sprintf("Failed to create directories %s: %v", app.songBookDir, err))
@@ -110,6 +131,13 @@ func NewApp() *App {
 	return &app
 }
 
+// dataFolderName names the data folder after the host of the built-in
+// chapter page, so editing pdfUrl does not move the data
+func dataFolderName() string {
+	parsedURL, _ := url.Parse(PDFUrl)
+	return strings.Replace(parsedURL.Host, ":", "_", -1)
+}
+
 // Shutdown flushes status to disk and closes the log file.
 func (a *App) Shutdown() {
 	if a == nil {
@@ -171,22 +199,39 @@ func (a *App) GetPdfFile(filename string) (string, error) {
 }
 
 // ResetData deletes all stored app data and re-initializes songs and database.
+// User songbooks, service plans and the user data of songs are kept.
 func (a *App) ResetData() error {
 	// Show progress in UI
 	a.startProgress("Mažu uložená data...")
 	defer a.clearProgress()
 
-	// Force close any database connections by opening and immediately closing
-	// This ensures SQLite releases any file locks on Windows
-	_ = a.withDB(func(db *sql.DB) error {
-		return nil
-	})
+	// User data survives the reset. Reading it also closes the database, so
+	// SQLite releases any file locks on Windows.
+	userData, err := a.exportBackupData()
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to keep user data: %s", err))
+		return err
+	}
+
+	if _, err := a.snapshotDatabase(snapshotReset); err != nil {
+		slog.Warn("Failed to snapshot database before reset", "error", err)
+	}
 
-	// Remove application data directory
-	if err := os.RemoveAll(a.appDir); err != nil {
-		slog.Error("Failed to remove app directory", "path", a.appDir, "error", err)
+	// Remove application data, database snapshots are kept
+	entries, err := os.ReadDir(a.appDir)
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
+	for _, entry := range entries {
+		if entry.Name() == backupsDirName {
+			continue
+		}
+		path := filepath.Join(a.appDir, entry.Name())
+		if err := os.RemoveAll(path); err != nil {
+			slog.Error("Failed to remove app data", "path", path, "error", err)
+			return err
+		}
+	}
 
 	// Recreate required directories
 	if err := os.MkdirAll(a.appDir, os.ModePerm); err != nil {
@@ -214,7 +259,19 @@ func (a *App) ResetData() error {
 
 	// Re-run full download and DB preparation
 	a.updateProgress("Znovu stahuji a připravuji data...", 0)
-	return a.DownloadEz()
+	downloadErr := a.DownloadEz()
+
+	// Restored after the download, so service plans find the EZ and KK
+	// songs again. The reset snapshot keeps what could not be restored.
+	var summary dtoBackupSummary
+	if err := a.importBackupData(userData, false, &summary); err != nil {
+		slog.Error(fmt.Sprintf("Failed to restore user data after reset: %s", err))
+		return err
+	}
+	if len(summary.Skipped) > 0 {
+		slog.Warn("User data not restored after reset", "skipped", summary.Skipped)
+	}
+	return downloadErr
 }
 
 func (a *App) saveStatus() {
@@ -0,0 +1,318 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// chordProExtensions are the file extensions imported from a ChordPro directory
+var chordProExtensions = []string{".cho", ".chordpro", ".chopro", ".crd", ".pro"}
+
+// chordProChord matches an inline chord like [G] or [D/F#]
+var chordProChord = regexp.MustCompile(`\[[^\]]*\]`)
+
+// chordProVerseOrderMeta is the {meta: ...} name carrying the verse order
+const chordProVerseOrderMeta = "verse_order"
+
+// parseChordProDirective splits "{name: value}" into lower-cased name and value
+func parseChordProDirective(line string) (string, string, bool) {
+	if !strings.HasPrefix(line, "{") || !strings.HasSuffix(line, "}") {
+		return "", "", false
+	}
+	content := strings.TrimSpace(line[1 : len(line)-1])
+	name, value := content, ""
+	if i := strings.IndexAny(content, ": "); i >= 0 {
+		name, value = content[:i], strings.TrimSpace(content[i+1:])
+	}
+	return strings.ToLower(strings.TrimSpace(name)), value, true
+}
+
+// parseChordPro converts a ChordPro document to a song. Sections come from
+// start_of_verse/chorus/bridge directives, named by their label when it is a
+// verse name like "c2", or from blank-line separated paragraphs. Inline
+// chords are kept in the Chords of each verse. The verse order is read from
+// {meta: verse_order ...} when it names parsed verses only, otherwise it is
+// derived from the sections and {chorus} repeats.
+func parseChordPro(text string) (*Song, error) {
+	song := &Song{}
+	counters := map[string]int{}
+	used := map[string]bool{}
+	var order []string
+	var lastChorus string
+	var current *Verse
+	var lines, chords []string
+	hasChords, inSection := false, false
+	var explicitOrder string
+
+	finish := func() {
+		if current != nil && len(lines) > 0 {
+			current.Lines = trimVerseLines(strings.Join(lines, "\n"))
+			if hasChords {
+				current.Chords = trimVerseLines(strings.Join(chords, "\n"))
+			}
+			song.Lyrics.Verses = append(song.Lyrics.Verses, *current)
+			order = append(order, current.Name)
+		}
+		current, lines, chords, hasChords = nil, nil, nil, false
+	}
+	start := func(prefix string, label string) {
+		finish()
+		name := strings.ToLower(strings.TrimSpace(label))
+		if !verseNamePattern.MatchString(name) || used[name] {
+			name = ""
+		}
+		for name == "" || used[name] {
+			counters[prefix]++
+			name = prefix + strconv.Itoa(counters[prefix])
+			if prefix != "v" && counters[prefix] == 1 {
+				name = prefix
+			}
+		}
+		used[name] = true
+		if prefix == "c" {
+			lastChorus = name
+		}
+		current = &Verse{Name: name}
+	}
+
+	for _, raw := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if name, value, ok := parseChordProDirective(line); ok {
+			switch name {
+			case "title", "t":
+				song.Title = value
+				song.Titles = append([]SongTitle{{Value: value}}, song.Titles...)
+			case "subtitle", "st":
+				song.Titles = append(song.Titles, SongTitle{Value: value})
+			case "artist", "lyricist":
+				song.Authors = append(song.Authors, Author{Type: "words", Value: value})
+			case "composer":
+				song.Authors = append(song.Authors, Author{Type: "music", Value: value})
+			case "key":
+				song.Key = value
+			case "tempo":
+				song.Tempo = Tempo{Type: "bpm", Value: value}
+				if _, err := strconv.Atoi(value); err != nil {
+					song.Tempo.Type = "text"
+				}
+			case "copyright":
+				song.Copyright = value
+			case "ccli":
+				song.CcliNo = value
+			case "meta":
+				if metaName, metaValue, _ := strings.Cut(value, " "); metaName == chordProVerseOrderMeta {
+					explicitOrder = strings.TrimSpace(metaValue)
+				}
+			case "start_of_verse", "sov":
+				start("v", value)
+				inSection = true
+			case "start_of_chorus", "soc":
+				start("c", value)
+				inSection = true
+			case "start_of_bridge", "sob":
+				start("b", value)
+				inSection = true
+			case "end_of_verse", "eov", "end_of_chorus", "eoc", "end_of_bridge", "eob":
+				finish()
+				inSection = false
+			case "chorus":
+				finish()
+				if lastChorus != "" {
+					order = append(order, lastChorus)
+				}
+			case "comment", "c", "comment_italic", "ci", "comment_box", "cb":
+				if current == nil {
+					start("v", "")
+				}
+				current.Comment = strings.TrimSpace(current.Comment + "\n" + value)
+			}
+			continue
+		}
+		if line == "" {
+			if !inSection {
+				finish()
+			}
+			continue
+		}
+		if current == nil {
+			start("v", "")
+		}
+		lines = append(lines, strings.Join(strings.Fields(chordProChord.ReplaceAllString(line, "")), " "))
+		chords = append(chords, line)
+		hasChords = hasChords || chordProChord.MatchString(line)
+	}
+	finish()
+
+	if song.Title == "" {
+		return nil, fmt.Errorf("ChordPro song has no title")
+	}
+	if explicitOrder != "" {
+		verses := make([]songVerse, len(song.Lyrics.Verses))
+		for i, v := range song.Lyrics.Verses {
+			verses[i] = songVerse{Name: v.Name}
+		}
+		var err error
+		if song.VerseOrder, err = validateVerseOrder(explicitOrder, verses); err != nil {
+			slog.Warn("Ignoring ChordPro verse order", "title", song.Title, "error", err)
+		}
+	}
+	if song.VerseOrder == "" && len(order) > len(song.Lyrics.Verses) {
+		// only repeats make an explicit order worth storing
+		song.VerseOrder = strings.Join(order, " ")
+	}
+	return song, nil
+}
+
+// ImportChordPro imports a ChordPro file, or all ChordPro files of a
+// directory, into the given user songbook, which is created when missing.
+// The downloaded EZ and KK songbooks are refused. Songs are numbered after
+// the last song of the songbook. It returns the number of imported songs.
+func (a *App) ImportChordPro(path string, songbookAcronym string, songbookName string) (int, error) {
+	songbookAcronym = strings.TrimSpace(songbookAcronym)
+	if !songbookAcronymPattern.MatchString(songbookAcronym) {
+		return 0, fmt.Errorf("songbook acronym must be 1 to 10 letters or digits")
+	}
+	if isBuiltinSongbook(songbookAcronym) {
+		return 0, fmt.Errorf("songbook acronym %s is reserved", songbookAcronym)
+	}
+	files, err := listImportFiles(path, chordProExtensions...)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = a.withDB(func(db *sql.DB) error {
+		// a failed import leaves no partial songbook behind
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		acronym, err := a.getOrCreateSongbook(tx, songbookAcronym, songbookName)
+		if err != nil {
+			return err
+		}
+		var lastEntry int
+		if err := tx.QueryRow(`SELECT COALESCE(MAX(entry), 0) FROM songs WHERE songbook_acronym = ?`, acronym).Scan(&lastEntry); err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			song, err := parseChordPro(strings.TrimPrefix(string(data), "\ufeff"))
+			if err != nil {
+				slog.Warn("Skipping invalid ChordPro file", "file", file, "error", err)
+				continue
+			}
+			lastEntry++
+			song.Songbook = Songbook{Name: songbookName, Entry: strconv.Itoa(lastEntry)}
+			if _, err := a.insertFullSong(tx, song, acronym, filepath.Base(file)); err != nil {
+				return fmt.Errorf("failed to import %s: %w", filepath.Base(file), err)
+			}
+			count++
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("ChordPro import failed: %s", err))
+		return 0, err
+	}
+	a.markSimilarSongsStale()
+	return count, nil
+}
+
+// chordProSection returns the start and end directives of a verse. The verse
+// name is the label, so the sections import back under the names the verse
+// order refers to.
+func chordProSection(name string) (string, string) {
+	switch {
+	case strings.HasPrefix(name, "c"):
+		return "{start_of_chorus: " + name + "}", "{end_of_chorus}"
+	case strings.HasPrefix(name, "b"):
+		return "{start_of_bridge: " + name + "}", "{end_of_bridge}"
+	default:
+		return "{start_of_verse: " + name + "}", "{end_of_verse}"
+	}
+}
+
+// songToChordPro renders a song from the database as a ChordPro document
+func (a *App) songToChordPro(db *sql.DB, songID int) (string, error) {
+	song, err := a.loadExportSong(db, songID)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "{title: %s}\n", song.Title)
+	for _, subtitle := range song.Titles[1:] {
+		fmt.Fprintf(&b, "{subtitle: %s}\n", subtitle)
+	}
+	for _, author := range song.Authors {
+		if author.Type != "music" {
+			fmt.Fprintf(&b, "{lyricist: %s}\n", author.Value)
+		}
+	}
+	for _, author := range song.Authors {
+		if author.Type == "music" {
+			fmt.Fprintf(&b, "{composer: %s}\n", author.Value)
+		}
+	}
+	for _, field := range []struct{ name, value string }{
+		{"key", song.Key},
+		{"tempo", strings.TrimSuffix(song.Tempo, " bpm")},
+		{"copyright", song.Copyright},
+		{"ccli", song.CcliNo},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(&b, "{%s: %s}\n", field.name, field.value)
+		}
+	}
+	if song.SongbookName != "" {
+		fmt.Fprintf(&b, "{meta: songbook %s %s}\n", song.SongbookName, song.EntryText)
+	}
+	if song.VerseOrder != "" {
+		fmt.Fprintf(&b, "{meta: %s %s}\n", chordProVerseOrderMeta, song.VerseOrder)
+	}
+
+	for _, verse := range song.Verses {
+		lines := verse.Lines
+		if verse.Chords != "" {
+			lines = verse.Chords
+		}
+		startDirective, endDirective := chordProSection(verse.Name)
+		b.WriteString("\n" + startDirective + "\n")
+		if verse.Comment != "" {
+			fmt.Fprintf(&b, "{comment: %s}\n", strings.ReplaceAll(verse.Comment, "\n", " "))
+		}
+		b.WriteString(lines + "\n" + endDirective + "\n")
+	}
+	return b.String(), nil
+}
+
+// ExportChordPro returns a song as a ChordPro (.cho) data URL
+func (a *App) ExportChordPro(songId int) (string, error) {
+	var document string
+	err := a.withDB(func(db *sql.DB) error {
+		var err error
+		document, err = a.songToChordPro(db, songId)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return "data:application/x-chordpro;charset=utf-8;base64," + base64.StdEncoding.EncodeToString([]byte(document)), nil
+}
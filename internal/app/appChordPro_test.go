@@ -0,0 +1,207 @@
+package app
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const chordProSample = `# Sample song
+{title: Amazing Grace}
+{subtitle: Úžasná milost}
+{artist: John Newton}
+{composer: traditional}
+{key: G}
+{tempo: 80}
+
+{start_of_verse: Verse 1}
+A[G]mazing grace, how [C]sweet the [G]sound
+that saved a wretch like me
+{end_of_verse}
+
+{soc}
+{c: all}
+[D]My chains are gone
+{eoc}
+
+Second verse without
+a section directive
+
+{chorus}
+`
+
+func TestParseChordPro(t *testing.T) {
+	song, err := parseChordPro(chordProSample)
+	if err != nil {
+		t.Fatalf("parseChordPro() error = %v", err)
+	}
+	if song.Title != "Amazing Grace" || len(song.Titles) != 2 || song.Titles[1].Value != "Úžasná milost" {
+		t.Errorf("unexpected titles: %q %+v", song.Title, song.Titles)
+	}
+	wantAuthors := []Author{{Type: "words", Value: "John Newton"}, {Type: "music", Value: "traditional"}}
+	if !reflect.DeepEqual(song.Authors, wantAuthors) {
+		t.Errorf("authors = %+v, want %+v", song.Authors, wantAuthors)
+	}
+	if song.Key != "G" || song.Tempo.String() != "80 bpm" {
+		t.Errorf("unexpected key/tempo: %q %q", song.Key, song.Tempo.String())
+	}
+	wantVerses := []Verse{
+		{Name: "v1", Lines: "Amazing grace, how sweet the sound\nthat saved a wretch like me",
+			Chords: "A[G]mazing grace, how [C]sweet the [G]sound\nthat saved a wretch like me"},
+		{Name: "c", Lines: "My chains are gone", Chords: "[D]My chains are gone", Comment: "all"},
+		{Name: "v2", Lines: "Second verse without\na section directive"},
+	}
+	if !reflect.DeepEqual(song.Lyrics.Verses, wantVerses) {
+		t.Errorf("verses = %+v, want %+v", song.Lyrics.Verses, wantVerses)
+	}
+	if song.VerseOrder != "v1 c v2 c" {
+		t.Errorf("verse order = %q, want derived order with chorus repeat", song.VerseOrder)
+	}
+
+	if _, err := parseChordPro("[G]no title"); err == nil {
+		t.Error("expected error for song without title")
+	}
+	song, _ = parseChordPro("{t: X}\n{meta: verse_order v1 v1}\nText")
+	if song.VerseOrder != "v1 v1" {
+		t.Errorf("expected explicit verse order, got %q", song.VerseOrder)
+	}
+
+	labeled := "{t: X}\n{meta: verse_order v1 c1 v2 c1}\n{sov: v1}\nA\n{eov}\n{soc: c1}\nB\n{eoc}\n{sov}\nC\n{eov}\n{chorus}"
+	song, _ = parseChordPro(labeled)
+	var names []string
+	for _, v := range song.Lyrics.Verses {
+		names = append(names, v.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"v1", "c1", "v2"}) || song.VerseOrder != "v1 c1 v2 c1" {
+		t.Errorf("section labels should name the verses, got %v with order %q", names, song.VerseOrder)
+	}
+	song, _ = parseChordPro("{t: X}\n{meta: verse_order v1 c1 v2 c1}\n{sov}\nA\n{eov}\n{soc}\nB\n{eoc}\n{sov}\nC\n{eov}")
+	if song.VerseOrder != "" {
+		t.Errorf("verse order naming missing verses should be dropped, got %q", song.VerseOrder)
+	}
+}
+
+func TestChordProImportExport(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "grace.cho"), []byte("\ufeff"+chordProSample), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("{title: ignored}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	count, err := app.ImportChordPro(dir, "BAND", "Kapela")
+	if err != nil || count != 1 {
+		t.Fatalf("ImportChordPro() = %d, %v", count, err)
+	}
+
+	songs, err := app.GetSongs("entry", "", "BAND")
+	if err != nil || len(songs) != 1 {
+		t.Fatalf("GetSongs: %v %+v", err, songs)
+	}
+	if songs[0].Entry != 1 || songs[0].AuthorLyric != "John Newton" || songs[0].AuthorMusic != "traditional" {
+		t.Errorf("unexpected imported song: %+v", songs[0])
+	}
+
+	dataURL, err := app.ExportChordPro(songs[0].Id)
+	if err != nil {
+		t.Fatalf("ExportChordPro: %v", err)
+	}
+	prefix := "data:application/x-chordpro;charset=utf-8;base64,"
+	if !strings.HasPrefix(dataURL, prefix) {
+		t.Fatalf("unexpected data URL: %.60s", dataURL)
+	}
+	document, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(dataURL, prefix))
+	for _, want := range []string{"{title: Amazing Grace}", "{subtitle: Úžasná milost}", "{lyricist: John Newton}",
+		"{key: G}", "{tempo: 80}", "{meta: songbook Kapela 1}", "{meta: verse_order v1 c v2 c}", "{start_of_chorus: c}\n{comment: all}\n[D]My chains"} {
+		if !strings.Contains(string(document), want) {
+			t.Errorf("exported document misses %q:\n%s", want, document)
+		}
+	}
+
+	// the export imports back to the same song
+	again, err := parseChordPro(string(document))
+	if err != nil {
+		t.Fatalf("parsing export: %v", err)
+	}
+	original, _ := parseChordPro(chordProSample)
+	if !reflect.DeepEqual(again.Lyrics.Verses, original.Lyrics.Verses) || again.VerseOrder != original.VerseOrder {
+		t.Errorf("round trip changed the song:\n%+v\n%+v", again.Lyrics.Verses, original.Lyrics.Verses)
+	}
+
+	if _, err := app.ExportChordPro(99999); err == nil {
+		t.Error("expected error for unknown song")
+	}
+}
+
+func TestChordProSection_RoundTrip(t *testing.T) {
+	names := []string{"v1", "c1", "v2", "c2", "b1"}
+	var b strings.Builder
+	b.WriteString("{title: Sloky}\n{meta: verse_order v1 c1 v2 c2 b1 c2}\n")
+	for _, name := range names {
+		start, end := chordProSection(name)
+		b.WriteString(start + "\ntext " + name + "\n" + end + "\n")
+	}
+
+	song, err := parseChordPro(b.String())
+	if err != nil {
+		t.Fatalf("parseChordPro: %v", err)
+	}
+	var got []string
+	for _, verse := range song.Lyrics.Verses {
+		got = append(got, verse.Name)
+	}
+	if !reflect.DeepEqual(got, names) || song.VerseOrder != "v1 c1 v2 c2 b1 c2" {
+		t.Errorf("sections should keep their names, got %v with order %q", got, song.VerseOrder)
+	}
+}
+
+func TestImportChordPro_FailureLeavesNoSongs(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a-grace.cho"), []byte(chordProSample), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// unreadable after the first song was inserted
+	if err := os.Symlink(filepath.Join(dir, "chybi.cho"), filepath.Join(dir, "b-broken.cho")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	if count, err := app.ImportChordPro(dir, "BAND", "Kapela"); err == nil || count != 0 {
+		t.Fatalf("ImportChordPro() = %d, %v, expected a failure", count, err)
+	}
+	books, _ := app.GetSongbooks()
+	for _, book := range books {
+		if book.Acronym == "BAND" {
+			t.Errorf("failed import should not create the songbook, got %+v", book)
+		}
+	}
+
+	os.Remove(filepath.Join(dir, "b-broken.cho"))
+	if count, err := app.ImportChordPro(dir, "BAND", "Kapela"); err != nil || count != 1 {
+		t.Errorf("retry should import the song, got %d, %v", count, err)
+	}
+}
+
+func TestImportChordPro_RejectsSongbookAcronyms(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	file := filepath.Join(t.TempDir(), "grace.cho")
+	if err := os.WriteFile(file, []byte(chordProSample), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, acronym := range []string{"EZ", "kk", "", "MY BAND", "KAPELA12345"} {
+		if _, err := app.ImportChordPro(file, acronym, "Kapela"); err == nil {
+			t.Errorf("acronym %q should be rejected", acronym)
+		}
+	}
+	if songs, _ := app.GetSongs("entry", "", ""); len(songs) != 0 {
+		t.Errorf("no song should be imported, got %+v", songs)
+	}
+}
@@ -0,0 +1,266 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Concordance scoring. A pair is stored when its confidence reaches
+// concordanceMinConfidence, which lies above every single score so at least
+// two signals have to agree; duplicates are collapsed in song lists from
+// concordanceCollapseConfidence on.
+const (
+	concordanceTitleScore         = 0.5
+	concordanceFirstLineScore     = 0.6
+	concordanceLinePrefixScore    = 0.3
+	concordanceAuthorScore        = 0.2
+	concordanceMinConfidence      = 0.7
+	concordanceCollapseConfidence = 0.8
+	concordancePrefixWords        = 4
+)
+
+// authorStopWords are common words of author attributions that say nothing about the author
+var authorStopWords = map[string]bool{
+	"podle": true, "puvodni": true, "neznamy": true, "anonym": true, "lidova": true,
+	"pisen": true, "zpevnik": true, "preklad": true, "text": true, "napev": true,
+}
+
+// concordanceSong is the normalized data of one song compared across songbooks
+type concordanceSong struct {
+	id        int
+	acronym   string
+	entryText string
+	title     string
+	firstLine string
+	authors   map[string]bool
+}
+
+// normalizeForMatch lowercases, strips diacritics and punctuation and collapses spaces
+func normalizeForMatch(s string) string {
+	s = strings.ToLower(removeDiacritics(s))
+	fields := strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	return strings.Join(fields, " ")
+}
+
+// firstLineOf returns the first non-empty line of a verse text
+func firstLineOf(lines string) string {
+	for _, line := range strings.Split(lines, "\n") {
+		if strings.TrimSpace(line) != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// linePrefix returns the first concordancePrefixWords words of a normalized line
+func linePrefix(line string) string {
+	words := strings.Fields(line)
+	if len(words) < concordancePrefixWords {
+		return ""
+	}
+	return strings.Join(words[:concordancePrefixWords], " ")
+}
+
+// authorWords returns the distinctive words of author attributions
+func authorWords(authors string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.Fields(normalizeForMatch(authors)) {
+		if len(word) >= 4 && !authorStopWords[word] && !unicode.IsDigit(rune(word[0])) {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// concordanceScore rates how likely two songs are the same hymn and explains why
+func concordanceScore(x, y concordanceSong) (float64, string) {
+	score := 0.0
+	var reasons []string
+	if x.title != "" && x.title == y.title {
+		score += concordanceTitleScore
+		reasons = append(reasons, "title")
+	}
+	switch {
+	case x.firstLine != "" && x.firstLine == y.firstLine:
+		score += concordanceFirstLineScore
+		reasons = append(reasons, "first line")
+	case linePrefix(x.firstLine) != "" && linePrefix(x.firstLine) == linePrefix(y.firstLine):
+		score += concordanceLinePrefixScore
+		reasons = append(reasons, "first words")
+	}
+	for word := range x.authors {
+		if y.authors[word] {
+			score += concordanceAuthorScore
+			reasons = append(reasons, "author")
+			break
+		}
+	}
+	return math.Min(math.Round(score*100)/100, 1), strings.Join(reasons, ", ")
+}
+
+// loadConcordanceSongs loads title, first line and authors of all songs
+func (a *App) loadConcordanceSongs(db *sql.DB) ([]concordanceSong, error) {
+	rows, err := db.Query(`
+		SELECT s.id, COALESCE(s.songbook_acronym, ''), ` + songEntryKeyExpr + `, COALESCE(s.title_d, ''),
+		       COALESCE((SELECT v.lines_d FROM verses v WHERE v.song_id = s.id ORDER BY v.id LIMIT 1), ''),
+		       COALESCE((SELECT GROUP_CONCAT(author_value_d, ' ') FROM authors WHERE song_id = s.id), '')
+		FROM songs s
+		WHERE COALESCE(s.songbook_acronym, '') <> ''`)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error querying songs for concordance: %s", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var songs []concordanceSong
+	for rows.Next() {
+		var s concordanceSong
+		var title, firstVerse, authors string
+		if err := rows.Scan(&s.id, &s.acronym, &s.entryText, &title, &firstVerse, &authors); err != nil {
+			return nil, err
+		}
+		s.title = normalizeForMatch(title)
+		s.firstLine = normalizeForMatch(firstLineOf(firstVerse))
+		s.authors = authorWords(authors)
+		songs = append(songs, s)
+	}
+	return songs, rows.Err()
+}
+
+// rebuildConcordance compares songs of different songbooks and replaces the
+// stored pairs. Only songs sharing a title or the first words of the first
+// line (or the whole line when shorter) are compared, so the job stays fast
+// for whole songbooks.
+func (a *App) rebuildConcordance(db *sql.DB) (int, error) {
+	songs, err := a.loadConcordanceSongs(db)
+	if err != nil {
+		return 0, err
+	}
+
+	buckets := map[string][]int{}
+	for i, s := range songs {
+		if s.title != "" {
+			buckets["t:"+s.title] = append(buckets["t:"+s.title], i)
+		}
+		if prefix := linePrefix(s.firstLine); prefix != "" {
+			buckets["l:"+prefix] = append(buckets["l:"+prefix], i)
+		} else if s.firstLine != "" {
+			buckets["f:"+s.firstLine] = append(buckets["f:"+s.firstLine], i)
+		}
+	}
+
+	type pairKey struct{ x, y int }
+	seen := map[pairKey]bool{}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM song_concordance`); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, members := range buckets {
+		for i, xi := range members {
+			for _, yi := range members[i+1:] {
+				x, y := songs[xi], songs[yi]
+				if x.acronym == y.acronym {
+					continue
+				}
+				if x.acronym > y.acronym {
+					x, y = y, x
+				}
+				key := pairKey{x.id, y.id}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				confidence, reason := concordanceScore(x, y)
+				if confidence < concordanceMinConfidence {
+					continue
+				}
+				_, err := tx.Exec(`
+					INSERT OR REPLACE INTO song_concordance
+						(songbook_acronym_a, entry_text_a, songbook_acronym_b, entry_text_b, confidence, reason)
+					VALUES (?, ?, ?, ?, ?, ?)`,
+					x.acronym, x.entryText, y.acronym, y.entryText, confidence, reason)
+				if err != nil {
+					return 0, err
+				}
+				count++
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	slog.Info(fmt.Sprintf("Concordance rebuilt with %d pairs", count))
+	return count, nil
+}
+
+// RebuildConcordance recomputes matching songs across songbooks and returns the number of pairs
+func (a *App) RebuildConcordance() (int, error) {
+	count := 0
+	err := a.withDB(func(db *sql.DB) error {
+		var err error
+		count, err = a.rebuildConcordance(db)
+		return err
+	})
+	return count, err
+}
+
+// GetSongConcordance lists songs in other songbooks matching the given song
+func (a *App) GetSongConcordance(songId int) ([]dtoConcordance, error) {
+	result := []dtoConcordance{}
+	err := a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		rows, err := db.Query(`
+			SELECT COALESCE(o.id, 0), COALESCE(o.entry, 0), m.acronym, m.entry_text, COALESCE(o.title, ''), m.confidence, m.reason
+			FROM (
+				SELECT songbook_acronym_b AS acronym, entry_text_b AS entry_text, confidence, reason
+				FROM song_concordance WHERE songbook_acronym_a = ? AND entry_text_a = ?
+				UNION ALL
+				SELECT songbook_acronym_a, entry_text_a, confidence, reason
+				FROM song_concordance WHERE songbook_acronym_b = ? AND entry_text_b = ?
+			) m
+			LEFT JOIN songs o ON o.songbook_acronym = m.acronym
+			                 AND COALESCE(NULLIF(o.entry_text, ''), CAST(o.entry AS TEXT)) = m.entry_text
+			ORDER BY m.confidence DESC, m.acronym, o.entry`,
+			acronym, entryText, acronym, entryText)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error querying concordance: %s", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var c dtoConcordance
+			if err := rows.Scan(&c.Id, &c.Entry, &c.SongbookAcronym, &c.EntryText, &c.Title, &c.Confidence, &c.Reason); err != nil {
+				slog.Error(fmt.Sprintf("Error scanning row: %s", err))
+				return err
+			}
+			result = append(result, c)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
+
+// SaveCollapseDuplicates toggles hiding of confident duplicates when all songbooks are listed
+func (a *App) SaveCollapseDuplicates(collapse bool) {
+	if collapse != a.status.CollapseDuplicates {
+		a.status.CollapseDuplicates = collapse
+		a.saveStatus()
+	}
+}
@@ -0,0 +1,171 @@
+package app
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestNormalizeForMatch(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{"Ejhle, oltář!", "ejhle oltar"},
+		{"  Tebe,  Bože, chválíme ", "tebe boze chvalime"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeForMatch(tt.raw); got != tt.want {
+			t.Errorf("normalizeForMatch(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestConcordanceScore(t *testing.T) {
+	base := concordanceSong{title: "tebe boze chvalime", firstLine: "tebe boze chvalime tebe pane", authors: authorWords("Jan Blahoslav, 1561")}
+	tests := []struct {
+		name       string
+		other      concordanceSong
+		want       float64
+		wantReason string
+	}{
+		{name: "all match", other: base, want: 1, wantReason: "title, first line, author"},
+		{name: "title only", other: concordanceSong{title: "tebe boze chvalime"}, want: 0.5, wantReason: "title"},
+		{name: "first words and author", other: concordanceSong{firstLine: "tebe boze chvalime tebe vyznavame", authors: authorWords("Roh")},
+			want: 0.3, wantReason: "first words"},
+		{name: "nothing", other: concordanceSong{title: "jina", firstLine: "jiny text"}, want: 0, wantReason: ""},
+	}
+	for _, single := range []float64{concordanceTitleScore, concordanceFirstLineScore, concordanceLinePrefixScore, concordanceAuthorScore} {
+		if single >= concordanceMinConfidence {
+			t.Errorf("single score %v should not reach the minimum confidence", single)
+		}
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := concordanceScore(base, tt.other)
+			if got != tt.want || reason != tt.wantReason {
+				t.Errorf("concordanceScore = %v %q, want %v %q", got, reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
+
+// insertConcordanceSongs inserts EZ 10 / KK 501 as the same hymn with a
+// different title, EZ 11 / KK 502 sharing the title and the author, and KK 503
+// sharing only the first line with EZ 11
+func insertConcordanceSongs(t *testing.T, app *App) map[string]int {
+	t.Helper()
+	ids := map[string]int{}
+	err := app.withDB(func(db *sql.DB) error {
+		songs := []struct {
+			acronym, entry, title, verse, author string
+		}{
+			{"EZ", "10", "Tebe, Bože, chválíme", "Tebe, Bože, chválíme,\ntebe, Pane, vyznáváme", "Martin Luther"},
+			{"KK", "501", "Te Deum", "Tebe, Bože, chválíme,\ntebe, Pane, vyznáváme", "Martin Luther, 1529"},
+			{"EZ", "11", "Ranní píseň", "Již jasné slunce vychází", "Jan Blahoslav"},
+			{"KK", "502", "Ranní píseň", "Probuď se, duše má", "Jan Blahoslav, 1561"},
+			{"KK", "503", "Jiná píseň", "Již jasné slunce vychází", ""},
+		}
+		for _, s := range songs {
+			r, err := db.Exec(`INSERT INTO songs (songbook_acronym, title, title_d, verse_order, entry, entry_text) VALUES (?, ?, ?, '', ?, ?)`,
+				s.acronym, s.title, removeDiacritics(s.title), s.entry, s.entry)
+			if err != nil {
+				return err
+			}
+			id, _ := r.LastInsertId()
+			if _, err := db.Exec(`INSERT INTO verses (song_id, name, lines, lines_d) VALUES (?, 'v1', ?, ?)`, id, s.verse, removeDiacritics(s.verse)); err != nil {
+				return err
+			}
+			if s.author != "" {
+				if err := app.insertAuthors(db, id, []Author{{Type: "words", Value: s.author}}, "test"); err != nil {
+					return err
+				}
+			}
+			ids[s.acronym+" "+s.entry] = int(id)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	return ids
+}
+
+func TestRebuildConcordance(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertConcordanceSongs(t, app)
+
+	count, err := app.RebuildConcordance()
+	if err != nil {
+		t.Fatalf("RebuildConcordance: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 pairs, got %d", count)
+	}
+	// rebuilding replaces the pairs instead of adding them again
+	if count, _ = app.RebuildConcordance(); count != 2 {
+		t.Errorf("expected 2 pairs after rebuild, got %d", count)
+	}
+
+	matches, err := app.GetSongConcordance(ids["EZ 10"])
+	if err != nil {
+		t.Fatalf("GetSongConcordance: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Id != ids["KK 501"] || matches[0].Title != "Te Deum" ||
+		matches[0].Confidence < concordanceCollapseConfidence || matches[0].Reason != "first line, author" {
+		t.Errorf("unexpected matches for EZ 10: %+v", matches)
+	}
+
+	// the lookup works from either side of the pair
+	matches, _ = app.GetSongConcordance(ids["KK 502"])
+	if len(matches) != 1 || matches[0].SongbookAcronym != "EZ" || matches[0].EntryText != "11" || matches[0].Confidence != 0.7 ||
+		matches[0].Reason != "title, author" {
+		t.Errorf("unexpected matches for KK 502: %+v", matches)
+	}
+	// a single signal is not enough, not even the whole first line
+	matches, _ = app.GetSongConcordance(ids["KK 503"])
+	if len(matches) != 0 {
+		t.Errorf("expected no matches for KK 503, got %+v", matches)
+	}
+}
+
+func TestGetSongs_ConcordanceLinksAndCollapse(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	insertConcordanceSongs(t, app)
+	if _, err := app.RebuildConcordance(); err != nil {
+		t.Fatalf("RebuildConcordance: %v", err)
+	}
+
+	songs, err := app.GetSongs("entry", "", "")
+	if err != nil {
+		t.Fatalf("GetSongs: %v", err)
+	}
+	if len(songs) != 5 {
+		t.Fatalf("expected all 5 songs without collapsing, got %d", len(songs))
+	}
+	alsoIn := map[string]string{}
+	for _, s := range songs {
+		alsoIn[s.Title] = s.AlsoIn
+	}
+	if alsoIn["Tebe, Bože, chválíme"] != "KK 501" || alsoIn["Te Deum"] != "EZ 10" || alsoIn["Jiná píseň"] != "" {
+		t.Errorf("unexpected AlsoIn links: %v", alsoIn)
+	}
+
+	app.status.CollapseDuplicates = true
+	songs, _ = app.GetSongs("entry", "", "")
+	if len(songs) != 4 {
+		t.Errorf("expected the confident KK duplicate to be collapsed, got %d songs", len(songs))
+	}
+	for _, s := range songs {
+		if s.Title == "Te Deum" {
+			t.Error("KK 501 should be hidden as duplicate of EZ 10")
+		}
+	}
+
+	// a single songbook is never collapsed
+	songs, _ = app.GetSongs("entry", "", "KK")
+	if len(songs) != 3 {
+		t.Errorf("expected 3 KK songs, got %d", len(songs))
+	}
+}
@@ -0,0 +1,209 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// backupsDirName is the folder in appDir holding database snapshots. It is
+// kept when ResetData deletes the app data.
+const backupsDirName = "backups"
+
+// defaultSnapshotKeep is the number of database snapshots kept by NewApp
+const defaultSnapshotKeep = 5
+
+// snapshotTimeLayout sorts snapshot file names by time
+const snapshotTimeLayout = "20060102-150405.000"
+
+// Reasons of automatic database snapshots
+const (
+	snapshotReset     = "reset"
+	snapshotReimport  = "reimport"
+	snapshotMigration = "migration"
+	snapshotRestore   = "restore"
+	snapshotImport    = "import"
+)
+
+// snapshotFileName matches names like "Songs-20261018-153000.123-reset.db"
+var snapshotFileName = regexp.MustCompile(`^Songs-(\d{8}-\d{6}\.\d{3})-([a-z0-9-]+)\.db$`)
+
+// copySQLiteDatabase copies a database file with SQLite's online backup API,
+// which gives a consistent copy even while other connections use the source
+func copySQLiteDatabase(srcPath, dstPath string) error {
+	src, err := sql.Open("sqlite3", srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := sql.Open("sqlite3", dstPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	ctx := context.Background()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+	dstConn, err := dst.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+
+	return dstConn.Raw(func(dstDriver interface{}) error {
+		return srcConn.Raw(func(srcDriver interface{}) error {
+			dstSQLite, ok := dstDriver.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected database driver %T", dstDriver)
+			}
+			srcSQLite, ok := srcDriver.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected database driver %T", srcDriver)
+			}
+			backup, err := dstSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			if _, err := backup.Step(-1); err != nil {
+				backup.Close()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
+
+// snapshotDir returns the folder of database snapshots
+func (a *App) snapshotDir() string {
+	return filepath.Join(a.appDir, backupsDirName)
+}
+
+// snapshotDatabase copies Songs.db into the backups folder before a
+// destructive operation and drops the oldest snapshots above the retention
+func (a *App) snapshotDatabase(reason string) (string, error) {
+	name, err := a.createSnapshot(reason)
+	if err != nil || name == "" {
+		return name, err
+	}
+	return name, a.pruneSnapshots()
+}
+
+// createSnapshot copies Songs.db into the backups folder. Snapshots are
+// disabled when no retention is set, and a missing or empty database is not
+// copied.
+func (a *App) createSnapshot(reason string) (string, error) {
+	if a.snapshotKeep <= 0 {
+		return "", nil
+	}
+	if info, err := os.Stat(a.dbFilePath); err != nil || info.Size() == 0 {
+		return "", nil
+	}
+	if err := os.MkdirAll(a.snapshotDir(), os.ModePerm); err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("Songs-%s-%s.db", time.Now().UTC().Format(snapshotTimeLayout), reason)
+	path := filepath.Join(a.snapshotDir(), name)
+	if err := copySQLiteDatabase(a.dbFilePath, path); err != nil {
+		os.Remove(path)
+		slog.Error(fmt.Sprintf("Database snapshot %s failed: %s", name, err))
+		return "", err
+	}
+	slog.Info(fmt.Sprintf("Database snapshot created: %s", path))
+	return name, nil
+}
+
+// pruneSnapshots deletes all but the newest snapshots
+func (a *App) pruneSnapshots() error {
+	snapshots, err := a.GetDatabaseSnapshots()
+	if err != nil {
+		return err
+	}
+	for i := a.snapshotKeep; i < len(snapshots); i++ {
+		if err := os.Remove(filepath.Join(a.snapshotDir(), snapshots[i].Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDatabaseSnapshots lists the stored database snapshots, newest first
+func (a *App) GetDatabaseSnapshots() ([]dtoDatabaseSnapshot, error) {
+	snapshots := []dtoDatabaseSnapshot{}
+	entries, err := os.ReadDir(a.snapshotDir())
+	if os.IsNotExist(err) {
+		return snapshots, nil
+	}
+	if err != nil {
+		return snapshots, err
+	}
+	for _, entry := range entries {
+		match := snapshotFileName.FindStringSubmatch(entry.Name())
+		if match == nil || entry.IsDir() {
+			continue
+		}
+		created, err := time.Parse(snapshotTimeLayout, match[1])
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return snapshots, err
+		}
+		snapshots = append(snapshots, dtoDatabaseSnapshot{
+			Name:      entry.Name(),
+			Reason:    match[2],
+			CreatedAt: created.Format(time.RFC3339),
+			Size:      info.Size(),
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Name > snapshots[j].Name })
+	return snapshots, nil
+}
+
+// RestoreDatabaseSnapshot replaces Songs.db with a stored snapshot. The
+// current database is snapshotted first, so the restore can be undone, and
+// older snapshots are migrated to the current schema.
+func (a *App) RestoreDatabaseSnapshot(name string) error {
+	if !snapshotFileName.MatchString(name) || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+	path := filepath.Join(a.snapshotDir(), name)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("snapshot %s not found", name)
+	}
+
+	a.startProgress("Obnovuji databázi ze zálohy...")
+	defer a.clearProgress()
+
+	// pruning waits until the restored snapshot has been copied
+	if _, err := a.createSnapshot(snapshotRestore); err != nil {
+		return err
+	}
+	if err := copySQLiteDatabase(path, a.dbFilePath); err != nil {
+		slog.Error(fmt.Sprintf("Restoring database snapshot %s failed: %s", name, err))
+		return err
+	}
+	slog.Info(fmt.Sprintf("Database restored from snapshot %s", name))
+	if err := a.pruneSnapshots(); err != nil {
+		slog.Warn("Failed to prune database snapshots", "error", err)
+	}
+
+	a.InitializeDatabase()
+	a.status.DatabaseReady = a.hasDatabaseContent()
+	a.saveStatus()
+	return nil
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// setupSnapshotApp creates an app with its database inside a temporary app directory
+func setupSnapshotApp(t *testing.T, keep int) *App {
+	t.Helper()
+	appDir := t.TempDir()
+	app := &App{appDir: appDir, dbFilePath: filepath.Join(appDir, "Songs.db"), snapshotKeep: keep}
+	app.InitializeDatabase()
+	return app
+}
+
+func TestSnapshotDatabase_Retention(t *testing.T) {
+	app := setupSnapshotApp(t, 2)
+
+	var names []string
+	for _, reason := range []string{snapshotReset, snapshotReimport, snapshotImport} {
+		name, err := app.snapshotDatabase(reason)
+		if err != nil || name == "" {
+			t.Fatalf("snapshotDatabase(%s): %q, %v", reason, name, err)
+		}
+		names = append(names, name)
+	}
+
+	snapshots, err := app.GetDatabaseSnapshots()
+	if err != nil {
+		t.Fatalf("GetDatabaseSnapshots: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Name != names[2] || snapshots[1].Name != names[1] {
+		t.Fatalf("expected the two newest snapshots, got %+v", snapshots)
+	}
+	if snapshots[0].Reason != snapshotImport || snapshots[0].Size == 0 || snapshots[0].CreatedAt == "" {
+		t.Errorf("unexpected snapshot %+v", snapshots[0])
+	}
+
+	disabled := setupSnapshotApp(t, 0)
+	if name, err := disabled.snapshotDatabase(snapshotReset); err != nil || name != "" {
+		t.Errorf("snapshots should be disabled without retention, got %q, %v", name, err)
+	}
+}
+
+func TestSnapshotDatabase_BeforeMigration(t *testing.T) {
+	app := setupSnapshotApp(t, 5)
+	err := app.withDB(func(db *sql.DB) error {
+		_, err := db.Exec(`DELETE FROM schema_version WHERE version = ?`, CurrentDBVersion)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.InitializeDatabase()
+	snapshots, _ := app.GetDatabaseSnapshots()
+	want := fmt.Sprintf("%s-v%d", snapshotMigration, CurrentDBVersion-1)
+	if len(snapshots) != 1 || snapshots[0].Reason != want {
+		t.Errorf("expected a %s snapshot, got %+v", want, snapshots)
+	}
+
+	// an up-to-date database is not snapshotted again
+	app.InitializeDatabase()
+	if snapshots, _ := app.GetDatabaseSnapshots(); len(snapshots) != 1 {
+		t.Errorf("expected no new snapshot, got %+v", snapshots)
+	}
+}
+
+func TestRestoreDatabaseSnapshot(t *testing.T) {
+	app := setupSnapshotApp(t, 5)
+	name, err := app.snapshotDatabase(snapshotReset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.CreateSongbook("SBOR", "Zpěvník sboru"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.RestoreDatabaseSnapshot(name); err != nil {
+		t.Fatalf("RestoreDatabaseSnapshot: %v", err)
+	}
+	books, _ := app.GetSongbooks()
+	for _, book := range books {
+		if book.Acronym == "SBOR" {
+			t.Error("songbook created after the snapshot should be gone")
+		}
+	}
+
+	// the replaced database was kept and restores the songbook again
+	snapshots, _ := app.GetDatabaseSnapshots()
+	if len(snapshots) != 2 || snapshots[0].Reason != snapshotRestore {
+		t.Fatalf("expected a restore snapshot, got %+v", snapshots)
+	}
+	if err := app.RestoreDatabaseSnapshot(snapshots[0].Name); err != nil {
+		t.Fatal(err)
+	}
+	books, _ = app.GetSongbooks()
+	found := false
+	for _, book := range books {
+		found = found || book.Acronym == "SBOR"
+	}
+	if !found {
+		t.Error("undoing the restore should bring the songbook back")
+	}
+
+	for _, invalid := range []string{"../Songs.db", "Songs-20260101-000000.000-reset.db", "jiny.db"} {
+		if err := app.RestoreDatabaseSnapshot(invalid); err == nil {
+			t.Errorf("RestoreDatabaseSnapshot(%q) should fail", invalid)
+		}
+	}
+}
@@ -10,7 +10,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
-	"time"
+	"strings"
 )
 
 func toLocalFilePath(parsedURL *url.URL) (string, error) {
@@ -38,6 +38,16 @@ func toLocalFilePath(parsedURL *url.URL) (string, error) {
 	return filepath.FromSlash(unescapedPath), nil
 }
 
+// localFileURL returns a file:// URL of a local path, understood by downloadFile
+func localFileURL(path string) string {
+	slashed := filepath.ToSlash(path)
+	if !strings.HasPrefix(slashed, "/") {
+		// Windows paths like C:/dir become /C:/dir
+		slashed = "/" + slashed
+	}
+	return (&url.URL{Scheme: "file", Path: slashed}).String()
+}
+
 func (a *App) downloadFile(fileUrl, fileName string) (string, error) {
 	// Create or truncate the file in the app directory
 	fullPath := filepath.Join(a.appDir, fileName)
@@ -70,38 +80,75 @@ func (a *App) downloadFile(fileUrl, fileName string) (string, error) {
 			return "", err
 		}
 	} else {
-		requestCtx := a.ctx
-		if requestCtx == nil {
-			requestCtx = context.Background()
+		err = a.fetchURL(fileUrl, destFile)
+		for _, mirror := range a.mirrorURLs(parsedURL) {
+			if err == nil {
+				break
+			}
+			slog.Warn("Download failed, trying mirror", "fileUrl", fileUrl, "mirror", mirror, "error", err)
+			if err = resetFile(destFile); err != nil {
+				return "", err
+			}
+			err = a.fetchURL(mirror, destFile)
 		}
-
-		downloadCtx, cancel := context.WithTimeout(requestCtx, 2*time.Minute)
-		defer cancel()
-
-		req, err := http.NewRequestWithContext(downloadCtx, http.MethodGet, fileUrl, nil)
 		if err != nil {
 			return "", err
 		}
+	}
+	slog.Info("Downloaded file", "fileUrl", fileUrl, "fullPath", fullPath)
+	return fullPath, nil
+}
 
-		response, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return "", err
-		}
-		defer response.Body.Close()
+// fetchURL downloads a web URL into destFile using the configured proxy and
+// timeout
+func (a *App) fetchURL(fileUrl string, destFile *os.File) error {
+	requestCtx := a.ctx
+	if requestCtx == nil {
+		requestCtx = context.Background()
+	}
 
-		// Check if the request was successful (status code 200)
-		if response.StatusCode != http.StatusOK {
-			return "", fmt.Errorf("HTTP request failed with status %d", response.StatusCode)
-		}
+	downloadCtx, cancel := context.WithTimeout(requestCtx, a.downloadTimeout())
+	defer cancel()
 
-		// Copy the content from the response body to the file
-		_, err = io.Copy(destFile, response.Body)
-		if err != nil {
-			return "", err
-		}
+	req, err := http.NewRequestWithContext(downloadCtx, http.MethodGet, fileUrl, nil)
+	if err != nil {
+		return err
 	}
-	slog.Info("Downloaded file", "fileUrl", fileUrl, "fullPath", fullPath)
-	return fullPath, nil
+
+	response, err := a.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// Check if the request was successful (status code 200)
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP request failed with status %d", response.StatusCode)
+	}
+
+	// Copy the content from the response body to the file
+	_, err = io.Copy(destFile, response.Body)
+	return err
+}
+
+// mirrorURLs returns the addresses of a file on the configured mirrors, which
+// serve the same paths as the original servers
+func (a *App) mirrorURLs(parsedURL *url.URL) []string {
+	mirrors := a.currentSettings().Mirrors
+	urls := make([]string, 0, len(mirrors))
+	for _, mirror := range mirrors {
+		urls = append(urls, strings.TrimSuffix(mirror, "/")+parsedURL.RequestURI())
+	}
+	return urls
+}
+
+// resetFile empties a partially written download before another attempt
+func resetFile(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := file.Seek(0, io.SeekStart)
+	return err
 }
 
 func (a *App) downloadParts() {
@@ -122,7 +169,8 @@ func (a *App) downloadParts() {
 }
 
 func (a *App) downloadSupplementalPDFs() error {
-	if len(SupplementalPDFs) == 0 || a.testRun {
+	pdfs := a.supplementalPDFs()
+	if len(pdfs) == 0 || a.testRun {
 		return nil
 	}
 
@@ -135,7 +183,7 @@ func (a *App) downloadSupplementalPDFs() error {
 		return err
 	}
 
-	for _, pdf := range SupplementalPDFs {
+	for _, pdf := range pdfs {
 		fileName := pdf.FileName
 		if fileName == "" {
 			fileName = path.Base(pdf.URL)
@@ -160,7 +208,7 @@ func (a *App) downloadSupplementalPDFs() error {
 }
 
 func (a *App) startSupplementalDownload() <-chan error {
-	if a.status.WebResourcesReady || len(SupplementalPDFs) == 0 {
+	if a.status.WebResourcesReady || len(a.supplementalPDFs()) == 0 {
 		return nil
 	}
 
@@ -206,60 +254,41 @@ func (a *App) ensureBackgroundSupplementalDownload() {
 }
 
 func (a *App) DownloadSongBase() error {
-	if err := os.MkdirAll(a.songBookDir, os.ModePerm); err != nil {
-		return err
-	}
 	a.updateProgress("Stahuji XML soubory...", 0)
-
-	fileName, err := a.downloadFile(a.xmlUrl, "Songs.zip")
-	if err != nil {
-		slog.Error(err.Error())
-		return err
-	}
-	defer os.Remove(fileName)
-
-	a.updateProgress("Rozbaluji soubory...", 50)
-
-	ezDir := filepath.Join(a.songBookDir, "EZ")
-	if err := os.MkdirAll(ezDir, os.ModePerm); err != nil {
-		slog.Error("Failed to create EZ directory", "dir", ezDir, "error", err)
-		return err
-	}
-
-	if err := unzip(fileName, ezDir); err != nil {
-		slog.Error("Failed to unzip EZ song base", "error", err)
-		return err
-	}
-	return nil
+	return a.installSongArchive(a.xmlUrl, "Songs.zip", Acronym_EZ)
 }
 
 func (a *App) DownloadKK() error {
+	a.updateProgress("Stahuji KK XML soubory...", 0)
+	return a.installSongArchive(a.xmlUrlKK, "SongsKK.zip", Acronym_KK)
+}
+
+// installSongArchive downloads a zip of song files, from the web or a
+// file:// URL, and unpacks it into the songbook directory of acronym
+func (a *App) installSongArchive(archiveUrl, fileName, acronym string) error {
 	if err := os.MkdirAll(a.songBookDir, os.ModePerm); err != nil {
 		return err
 	}
 
-	a.updateProgress("Stahuji KK XML soubory...", 0)
-
-	fileName, err := a.downloadFile(XMLUrl_KK, "SongsKK.zip")
+	archivePath, err := a.downloadFile(archiveUrl, fileName)
 	if err != nil {
 		slog.Error(err.Error())
 		return err
 	}
-	defer os.Remove(fileName)
+	defer os.Remove(archivePath)
 
-	a.updateProgress("Rozbaluji KK soubory...", 50)
+	a.updateProgress(fmt.Sprintf("Rozbaluji %s soubory...", acronym), 50)
 
-	kkDir := filepath.Join(a.songBookDir, "KK")
-	if err := os.MkdirAll(kkDir, os.ModePerm); err != nil {
-		slog.Error("Failed to create KK directory", "dir", kkDir, "error", err)
+	songDir := filepath.Join(a.songBookDir, acronym)
+	if err := os.MkdirAll(songDir, os.ModePerm); err != nil {
+		slog.Error(fmt.Sprintf("Failed to create %s directory", acronym), "dir", songDir, "error", err)
 		return err
 	}
 
-	if err := unzip(fileName, kkDir); err != nil {
-		slog.Error("Failed to unzip KK song base", "error", err)
+	if err := unzip(archivePath, songDir); err != nil {
+		slog.Error(fmt.Sprintf("Failed to unzip %s song base", acronym), "error", err)
 		return err
 	}
-
 	return nil
 }
 
@@ -271,13 +300,16 @@ func (a *App) DownloadInternal() error {
 		return err
 	}
 
-	fileName, err := a.downloadFile(a.pdfFiles.Url, "INDEX")
+	fileName, err := a.downloadFile(a.pdfFiles.Url, chapterPageFileName)
 	if err != nil {
 		slog.Error(err.Error())
 		return err
 	}
 
 	a.pdfFiles.Items = a.parseHtml(fileName)
+	if err := a.importSongChapters(fileName); err != nil {
+		slog.Warn("Failed to import EZ chapters", "error", err)
+	}
 	if !a.testRun {
 		a.downloadParts()
 	}
@@ -317,6 +349,13 @@ func (a *App) DownloadEz() error {
 		a.updateProgress("Naplňuji databázi...", 0)
 
 		a.FillDatabase()
+		if !a.testRun {
+			// Chapters only group songs, a failure must not block the import
+			if err := a.importStoredSongChapters(); err != nil {
+				slog.Warn("Failed to import EZ chapters", "error", err)
+			}
+		}
+		a.refreshSimilarSongs()
 		a.status.DatabaseReady = true
 		a.saveStatus()
 	}
@@ -259,14 +259,10 @@ func TestStartSupplementalDownload_WebResourcesReady(t *testing.T) {
 }
 
 func TestStartSupplementalDownload_EmptySupplementalPDFs(t *testing.T) {
-	original := SupplementalPDFs
-	SupplementalPDFs = []SupplementalPDF{}
-	t.Cleanup(func() { SupplementalPDFs = original })
-
-	app := &App{}
+	app := &App{settings: AppSettings{SupplementalPDFs: []SupplementalPDF{}}}
 	ch := app.startSupplementalDownload()
 	if ch != nil {
-		t.Error("expected nil when SupplementalPDFs is empty")
+		t.Error("expected nil without supplemental PDFs")
 	}
 }
 
@@ -274,12 +270,9 @@ func TestStartSupplementalDownload_AlreadyRunning(t *testing.T) {
 	existing := make(chan error, 1)
 	app := &App{
 		supplementalErrCh: existing,
+		settings:          AppSettings{SupplementalPDFs: []SupplementalPDF{{URL: "http://x", FileName: "x.pdf"}}},
 	}
 
-	original := SupplementalPDFs
-	SupplementalPDFs = []SupplementalPDF{{URL: "http://x", FileName: "x.pdf"}}
-	t.Cleanup(func() { SupplementalPDFs = original })
-
 	ch := app.startSupplementalDownload()
 	if ch != existing {
 		t.Error("expected the existing channel to be returned")
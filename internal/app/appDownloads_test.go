@@ -23,6 +23,7 @@ func setupTestApp(t *testing.T) *App {
 		pdfDir:      filepath.Join(appDir, "PdfSources"),
 		songBookDir: filepath.Join(appDir, "songbooks"),
 		status:      AppStatus{WebResourcesReady: true},
+		settings:    defaultSettings(),
 	}
 
 	return app
@@ -218,14 +219,10 @@ func TestDownloadSupplementalPDFs(t *testing.T) {
 	}))
 	defer server.Close()
 
-	original := SupplementalPDFs
-	SupplementalPDFs = []SupplementalPDF{{
+	app.settings.SupplementalPDFs = []SupplementalPDF{{
 		URL:      server.URL + "/test.pdf",
 		FileName: "choralnik.pdf",
 	}}
-	t.Cleanup(func() {
-		SupplementalPDFs = original
-	})
 
 	if err := app.downloadSupplementalPDFs(); err != nil {
 		t.Fatalf("Failed to download supplemental pdfs: %v", err)
@@ -248,14 +245,10 @@ func TestDownloadEzTriggersSupplementalDownloads(t *testing.T) {
 	}))
 	defer server.Close()
 
-	original := SupplementalPDFs
-	SupplementalPDFs = []SupplementalPDF{{
+	app.settings.SupplementalPDFs = []SupplementalPDF{{
 		URL:      server.URL + "/async.pdf",
 		FileName: "kytara.pdf",
 	}}
-	t.Cleanup(func() {
-		SupplementalPDFs = original
-	})
 
 	if err := app.DownloadEz(); err != nil {
 		t.Fatalf("DownloadEz should finish even when supplemental PDFs download concurrently: %v", err)
@@ -4,10 +4,24 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// sqlExecer is implemented by both *sql.DB and *sql.Tx, so insert helpers
+// can be used inside and outside of transactions
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// sqlQueryExecer also reads single rows, for helpers that look up data
+// within the same transaction
+type sqlQueryExecer interface {
+	sqlExecer
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 // withDB opens a database connection, executes the provided function, and ensures cleanup
 func (a *App) withDB(fn func(*sql.DB) error) error {
 	db, err := sql.Open("sqlite3", a.dbFilePath)
@@ -39,3 +53,14 @@ func (a *App) startProgress(message string) {
 	a.status.IsProgress = true
 	a.updateProgress(message, 0)
 }
+
+// joinNonEmpty joins the non-empty parts with sep
+func joinNonEmpty(sep string, parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, sep)
+}
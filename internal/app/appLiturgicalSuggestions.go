@@ -0,0 +1,272 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Suggestion weighting: feasts outrank seasons, and songs sung within
+// repeatWindowDays are pushed down proportionally to how recently they were used.
+const (
+	feastWeight        = 2.0
+	seasonWeight       = 1.0
+	repeatWindowDays   = 56
+	yearlyUsePenalty   = 0.05
+	defaultSuggestions = 20
+)
+
+// GetLiturgicalDay returns the season and feast of a date (YYYY-MM-DD)
+func (a *App) GetLiturgicalDay(date string) (dtoLiturgicalDay, error) {
+	parsed, err := parseServiceDate(date)
+	if err != nil {
+		return dtoLiturgicalDay{}, err
+	}
+	day := newLiturgicalDay(parsed)
+	return dtoLiturgicalDay{
+		Date:       day.Date.Format(serviceDateLayout),
+		Name:       day.Name,
+		Season:     day.Season,
+		SeasonName: liturgicalNames[day.Season],
+		Feast:      day.Feast,
+		FeastName:  liturgicalNames[day.Feast],
+	}, nil
+}
+
+// parseServiceDate validates a YYYY-MM-DD date and returns it as time.Time
+func parseServiceDate(raw string) (time.Time, error) {
+	normalized, err := normalizeServiceDate(raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(serviceDateLayout, normalized)
+}
+
+// GetLiturgicalRules lists the configured season/feast to song mappings
+func (a *App) GetLiturgicalRules() ([]dtoLiturgicalRule, error) {
+	result := []dtoLiturgicalRule{}
+	err := a.withDB(func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT id, occasion, tag, songbook_acronym, entry_from, entry_to
+			FROM liturgical_song_rules
+			ORDER BY occasion, id`)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error querying liturgical rules: %s", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var r dtoLiturgicalRule
+			if err := rows.Scan(&r.Id, &r.Occasion, &r.Tag, &r.SongbookAcronym, &r.EntryFrom, &r.EntryTo); err != nil {
+				return err
+			}
+			result = append(result, r)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
+
+// validateLiturgicalRule normalizes a rule; it needs a known occasion and
+// either a tag or a complete songbook entry range
+func validateLiturgicalRule(rule dtoLiturgicalRule) (dtoLiturgicalRule, error) {
+	rule.Occasion = strings.TrimSpace(rule.Occasion)
+	if _, ok := liturgicalNames[rule.Occasion]; !ok {
+		return rule, fmt.Errorf("unknown liturgical occasion %q", rule.Occasion)
+	}
+	rule.Tag = normalizeTag(rule.Tag)
+	rule.SongbookAcronym = strings.TrimSpace(rule.SongbookAcronym)
+	hasRange := rule.SongbookAcronym != "" || rule.EntryFrom != 0 || rule.EntryTo != 0
+	if hasRange {
+		if rule.SongbookAcronym == "" || rule.EntryFrom <= 0 || rule.EntryTo < rule.EntryFrom {
+			return rule, fmt.Errorf("invalid entry range %s %d-%d", rule.SongbookAcronym, rule.EntryFrom, rule.EntryTo)
+		}
+	}
+	if rule.Tag == "" && !hasRange {
+		return rule, fmt.Errorf("rule needs a tag or an entry range")
+	}
+	return rule, nil
+}
+
+// SaveLiturgicalRule creates (Id == 0) or updates a rule and returns its id
+func (a *App) SaveLiturgicalRule(rule dtoLiturgicalRule) (int, error) {
+	rule, err := validateLiturgicalRule(rule)
+	if err != nil {
+		return 0, err
+	}
+	id := rule.Id
+	err = a.withDB(func(db *sql.DB) error {
+		if rule.Id == 0 {
+			r, err := db.Exec(`
+				INSERT INTO liturgical_song_rules (occasion, tag, songbook_acronym, entry_from, entry_to)
+				VALUES (?, ?, ?, ?, ?)`,
+				rule.Occasion, rule.Tag, rule.SongbookAcronym, rule.EntryFrom, rule.EntryTo)
+			if err != nil {
+				return err
+			}
+			newID, err := r.LastInsertId()
+			id = int(newID)
+			return err
+		}
+		r, err := db.Exec(`
+			UPDATE liturgical_song_rules
+			SET occasion = ?, tag = ?, songbook_acronym = ?, entry_from = ?, entry_to = ?
+			WHERE id = ?`,
+			rule.Occasion, rule.Tag, rule.SongbookAcronym, rule.EntryFrom, rule.EntryTo, rule.Id)
+		if err != nil {
+			return err
+		}
+		if n, _ := r.RowsAffected(); n == 0 {
+			return fmt.Errorf("liturgical rule %d not found", rule.Id)
+		}
+		return nil
+	})
+	return id, err
+}
+
+// DeleteLiturgicalRule removes a rule
+func (a *App) DeleteLiturgicalRule(ruleId int) error {
+	return a.withDB(func(db *sql.DB) error {
+		_, err := db.Exec(`DELETE FROM liturgical_song_rules WHERE id = ?`, ruleId)
+		return err
+	})
+}
+
+// occasionSongCondition builds the WHERE clause matching songs of one occasion:
+// songs tagged with the occasion's name plus all configured rules
+func occasionSongCondition(db *sql.DB, occasion string) (string, []interface{}, error) {
+	tagCondition := "EXISTS (SELECT 1 FROM song_tags t WHERE t.songbook_acronym = COALESCE(s.songbook_acronym, '') AND t.entry_text = " + songEntryKeyExpr + " AND t.tag = ?)"
+	conditions := []string{tagCondition}
+	args := []interface{}{normalizeTag(liturgicalNames[occasion])}
+
+	rows, err := db.Query(`SELECT tag, songbook_acronym, entry_from, entry_to FROM liturgical_song_rules WHERE occasion = ?`, occasion)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag, acronym string
+		var from, to int
+		if err := rows.Scan(&tag, &acronym, &from, &to); err != nil {
+			return "", nil, err
+		}
+		if tag != "" {
+			conditions = append(conditions, tagCondition)
+			args = append(args, tag)
+		}
+		if acronym != "" {
+			conditions = append(conditions, "(s.songbook_acronym = ? AND s.entry BETWEEN ? AND ?)")
+			args = append(args, acronym, from, to)
+		}
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args, rows.Err()
+}
+
+// GetSuggestedSongs proposes songs for the service on date, ranked by how well
+// they fit the feast or season and how long ago they were last sung.
+func (a *App) GetSuggestedSongs(date string, limit int) ([]dtoSongSuggestion, error) {
+	parsed, err := parseServiceDate(date)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultSuggestions
+	}
+	day := newLiturgicalDay(parsed)
+	dateText := parsed.Format(serviceDateLayout)
+	yearAgo := parsed.AddDate(-1, 0, 0).Format(serviceDateLayout)
+
+	candidates := map[int]*dtoSongSuggestion{}
+	err = a.withDB(func(db *sql.DB) error {
+		for _, occasion := range day.occasions() {
+			weight := seasonWeight
+			if occasion == day.Feast {
+				weight = feastWeight
+			}
+			condition, args, err := occasionSongCondition(db, occasion)
+			if err != nil {
+				return err
+			}
+			rows, err := db.Query(`
+				SELECT s.id, s.entry, `+songEntryKeyExpr+`, COALESCE(s.title, ''), COALESCE(s.songbook_acronym, '')
+				FROM songs s
+				WHERE `+condition, args...)
+			if err != nil {
+				slog.Error(fmt.Sprintf("Error querying songs for %s: %s", occasion, err))
+				return err
+			}
+			for rows.Next() {
+				var s dtoSongSuggestion
+				if err := rows.Scan(&s.Id, &s.Entry, &s.EntryText, &s.Title, &s.SongbookAcronym); err != nil {
+					rows.Close()
+					return err
+				}
+				if existing, ok := candidates[s.Id]; ok && existing.Score >= weight {
+					continue
+				}
+				s.Occasion = occasion
+				s.Score = weight
+				candidates[s.Id] = &s
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+		}
+
+		for _, s := range candidates {
+			err := db.QueryRow(`
+				SELECT COALESCE(MAX(used_on), ''), COUNT(CASE WHEN used_on >= ? THEN 1 END)
+				FROM song_usage
+				WHERE songbook_acronym = ? AND entry_text = ? AND used_on < ?`,
+				yearAgo, s.SongbookAcronym, s.EntryText, dateText).Scan(&s.LastUsed, &s.UseCount)
+			if err != nil {
+				return err
+			}
+			s.Score = suggestionScore(s.Score, parsed, s.LastUsed, s.UseCount)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dtoSongSuggestion, 0, len(candidates))
+	for _, s := range candidates {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		if result[i].LastUsed != result[j].LastUsed {
+			return result[i].LastUsed < result[j].LastUsed
+		}
+		if result[i].SongbookAcronym != result[j].SongbookAcronym {
+			return result[i].SongbookAcronym < result[j].SongbookAcronym
+		}
+		return result[i].Entry < result[j].Entry
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// suggestionScore lowers the occasion weight of recently or frequently sung songs
+func suggestionScore(weight float64, date time.Time, lastUsed string, yearlyUses int) float64 {
+	score := weight
+	if lastUsed != "" {
+		if last, err := time.Parse(serviceDateLayout, lastUsed); err == nil {
+			if days := daysBetween(last, date); days < repeatWindowDays {
+				score *= float64(days) / repeatWindowDays
+			}
+		}
+	}
+	return score - yearlyUsePenalty*float64(yearlyUses)
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateLiturgicalRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    dtoLiturgicalRule
+		wantErr bool
+	}{
+		{name: "tag rule", rule: dtoLiturgicalRule{Occasion: SeasonAdvent, Tag: " Příchod "}},
+		{name: "range rule", rule: dtoLiturgicalRule{Occasion: FeastEaster, SongbookAcronym: "EZ", EntryFrom: 1, EntryTo: 3}},
+		{name: "unknown occasion", rule: dtoLiturgicalRule{Occasion: "harvest", Tag: "x"}, wantErr: true},
+		{name: "empty rule", rule: dtoLiturgicalRule{Occasion: SeasonLent}, wantErr: true},
+		{name: "reversed range", rule: dtoLiturgicalRule{Occasion: SeasonLent, SongbookAcronym: "EZ", EntryFrom: 5, EntryTo: 2}, wantErr: true},
+		{name: "range without songbook", rule: dtoLiturgicalRule{Occasion: SeasonLent, EntryFrom: 1, EntryTo: 2}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateLiturgicalRule(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLiturgicalRulesCrud(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+
+	id, err := app.SaveLiturgicalRule(dtoLiturgicalRule{Occasion: SeasonAdvent, Tag: "Příchod"})
+	if err != nil {
+		t.Fatalf("SaveLiturgicalRule: %v", err)
+	}
+	if _, err := app.SaveLiturgicalRule(dtoLiturgicalRule{Id: id, Occasion: SeasonAdvent, SongbookAcronym: "EZ", EntryFrom: 1, EntryTo: 2}); err != nil {
+		t.Fatalf("SaveLiturgicalRule (update): %v", err)
+	}
+	if _, err := app.SaveLiturgicalRule(dtoLiturgicalRule{Id: 999, Occasion: SeasonAdvent, Tag: "x"}); err == nil {
+		t.Error("expected error for unknown rule id")
+	}
+
+	rules, err := app.GetLiturgicalRules()
+	if err != nil {
+		t.Fatalf("GetLiturgicalRules: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Tag != "" || rules[0].EntryTo != 2 {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+
+	if err := app.DeleteLiturgicalRule(id); err != nil {
+		t.Fatalf("DeleteLiturgicalRule: %v", err)
+	}
+	rules, _ = app.GetLiturgicalRules()
+	if len(rules) != 0 {
+		t.Errorf("expected no rules, got %+v", rules)
+	}
+}
+
+func TestGetLiturgicalDay(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+
+	day, err := app.GetLiturgicalDay("2026-04-05")
+	if err != nil {
+		t.Fatalf("GetLiturgicalDay: %v", err)
+	}
+	if day.Feast != FeastEaster || day.FeastName != "Velikonoce" || day.SeasonName != "Velikonoční doba" {
+		t.Errorf("unexpected day: %+v", day)
+	}
+	if _, err := app.GetLiturgicalDay("5.4.2026"); err == nil {
+		t.Error("expected error for invalid date")
+	}
+}
+
+func TestGetSuggestedSongs(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertUsageSongs(t, app)
+
+	// Easter Sunday: the feast rule beats the season tag
+	_ = app.AddSongTag(ids[3], "velikonoční doba")
+	if _, err := app.SaveLiturgicalRule(dtoLiturgicalRule{Occasion: FeastEaster, SongbookAcronym: "EZ", EntryFrom: 1, EntryTo: 2}); err != nil {
+		t.Fatalf("SaveLiturgicalRule: %v", err)
+	}
+	// EZ 1 was sung a week ago, so EZ 2 should come first
+	recordUsage(t, app, ids[0], "2026-03-29", "")
+	// usage after the date must not influence the ranking
+	recordUsage(t, app, ids[1], "2026-04-12", "")
+
+	suggestions, err := app.GetSuggestedSongs("2026-04-05", 0)
+	if err != nil {
+		t.Fatalf("GetSuggestedSongs: %v", err)
+	}
+	if len(suggestions) != 3 {
+		t.Fatalf("expected 3 suggestions, got %+v", suggestions)
+	}
+	if suggestions[0].Id != ids[1] || suggestions[0].Occasion != FeastEaster || suggestions[0].LastUsed != "" {
+		t.Errorf("unexpected first suggestion: %+v", suggestions[0])
+	}
+	if suggestions[1].Id != ids[3] || suggestions[1].Occasion != SeasonEaster {
+		t.Errorf("unexpected second suggestion: %+v", suggestions[1])
+	}
+	if suggestions[2].Id != ids[0] || suggestions[2].LastUsed != "2026-03-29" {
+		t.Errorf("unexpected third suggestion: %+v", suggestions[2])
+	}
+
+	limited, _ := app.GetSuggestedSongs("2026-04-05", 1)
+	if len(limited) != 1 {
+		t.Errorf("expected limit to apply, got %d", len(limited))
+	}
+
+	none, err := app.GetSuggestedSongs("2026-07-12", 5)
+	if err != nil || len(none) != 0 {
+		t.Errorf("expected no suggestions for an unmapped Sunday, got %+v, %v", none, err)
+	}
+}
+
+func TestSuggestionScore(t *testing.T) {
+	date, _ := time.Parse(serviceDateLayout, "2026-04-05")
+	if got := suggestionScore(2, date, "", 0); got != 2 {
+		t.Errorf("unused song score = %v, want 2", got)
+	}
+	if got := suggestionScore(2, date, "2026-03-08", 0); got != 1 {
+		t.Errorf("song sung 4 weeks ago score = %v, want 1", got)
+	}
+	if got := suggestionScore(1, date, "2025-12-24", 2); got != 0.9 {
+		t.Errorf("song outside window score = %v, want 0.9", got)
+	}
+}
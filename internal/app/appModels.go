@@ -11,6 +11,25 @@ type AppStatus struct {
 	Sorting           SortingOption
 	SearchPattern     string
 	BuildVersion      string
+	// CollapseDuplicates hides songs matching a song of another songbook when all songbooks are listed
+	CollapseDuplicates bool
+	// SimilarSongsStale is set when songs or tags changed after the similar songs were computed
+	SimilarSongsStale bool
+}
+
+// AppSettings are the user settings stored in settings.yaml. Empty DataDir
+// means the Lyyyra folder in the home directory, zero timeout the default.
+type AppSettings struct {
+	XMLUrlEZ               string            `yaml:"xmlUrlEz"`
+	XMLUrlKK               string            `yaml:"xmlUrlKk"`
+	PDFUrl                 string            `yaml:"pdfUrl"`
+	SupplementalPDFs       []SupplementalPDF `yaml:"supplementalPdfs"`
+	Mirrors                []string          `yaml:"mirrors"`
+	DataDir                string            `yaml:"dataDir"`
+	LogLevel               string            `yaml:"logLevel"`
+	Proxy                  string            `yaml:"proxy"`
+	DownloadTimeoutSeconds int               `yaml:"downloadTimeoutSeconds"`
+	SnapshotKeep           int               `yaml:"snapshotKeep"`
 }
 
 type SongFilesSources struct {
@@ -28,14 +47,36 @@ type FileItem struct {
 	LocalFileName string
 }
 
-// Song represents the simplified structure of the XML document
+// Song represents the structure of an OpenLyrics XML document
 type Song struct {
-	Version    string   `xml:"version,attr"`
-	Title      string   `xml:"properties>titles>title"`
-	Songbook   Songbook `xml:"properties>songbooks>songbook"`
-	VerseOrder string   `xml:"properties>verseOrder"`
-	Authors    []Author `xml:"properties>authors>author"`
-	Lyrics     Lyrics   `xml:"lyrics"`
+	Version    string      `xml:"version,attr"`
+	Title      string      `xml:"-"` // the first of Titles, filled by parseXmlSong
+	Titles     []SongTitle `xml:"properties>titles>title"`
+	Songbook   Songbook    `xml:"properties>songbooks>songbook"`
+	VerseOrder string      `xml:"properties>verseOrder"`
+	Authors    []Author    `xml:"properties>authors>author"`
+	Copyright  string      `xml:"properties>copyright"`
+	CcliNo     string      `xml:"properties>ccliNo"`
+	Key        string      `xml:"properties>key"`
+	Tempo      Tempo       `xml:"properties>tempo"`
+	Themes     []Theme     `xml:"properties>themes>theme"`
+	Lyrics     Lyrics      `xml:"lyrics"`
+}
+
+type SongTitle struct {
+	Lang     string `xml:"lang,attr"`
+	Original bool   `xml:"original,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type Tempo struct {
+	Type  string `xml:"type,attr"` // "bpm" or "text"
+	Value string `xml:",chardata"`
+}
+
+type Theme struct {
+	Lang  string `xml:"lang,attr"`
+	Value string `xml:",chardata"`
 }
 
 type Songbook struct {
@@ -53,16 +94,28 @@ type Lyrics struct {
 }
 
 type Verse struct {
-	Name  string `xml:"name,attr"`
-	Lines string `xml:",innerxml"`
+	Name     string `xml:"name,attr"`
+	Lang     string `xml:"lang,attr"`
+	Translit string `xml:"translit,attr"`
+	Lines    string `xml:",innerxml"`
+	Chords   string `xml:"-"` // lines with inline [chord] markers, empty without chords
+	Comment  string `xml:"-"`
 }
 
-// SongKK represents the flat structure used by Katolický kancionál
+// SongKK represents the flat OpenSong structure, used e.g. by Katolický kancionál
 type SongKK struct {
-	Title      string `xml:"title"`
-	Author     string `xml:"author"`
-	HymnNumber string `xml:"hymn_number"`
-	Lyrics     string `xml:"lyrics"`
+	Title        string `xml:"title"`
+	Aka          string `xml:"aka"`
+	Author       string `xml:"author"`
+	Copyright    string `xml:"copyright"`
+	Ccli         string `xml:"ccli"`
+	HymnNumber   string `xml:"hymn_number"`
+	Presentation string `xml:"presentation"`
+	Key          string `xml:"key"`
+	Tempo        string `xml:"tempo"`
+	Theme        string `xml:"theme"`
+	AltTheme     string `xml:"alttheme"`
+	Lyrics       string `xml:"lyrics"`
 }
 
 type dtoSong struct {
@@ -74,6 +127,11 @@ type dtoSong struct {
 	AuthorLyric     string
 	KytaraFile      string
 	SongbookAcronym string
+	LastUsed        string
+	IsFavorite      bool
+	Chapter         string
+	AlsoIn          string // matching songs in other songbooks, e.g. "KK 123"
+	LocallyModified bool   // the song has local edits on top of the imported data
 }
 
 type dtoSongHeader struct {
@@ -84,6 +142,323 @@ type dtoSongHeader struct {
 	KytaraFile string
 }
 
+// dtoVerseOrder describes the imported and user-defined verse order of a song
+type dtoVerseOrder struct {
+	Original   string
+	Custom     string
+	Effective  string
+	VerseNames []string
+}
+
+// dtoService is a service plan (setlist) prepared for a given date
+type dtoService struct {
+	Id          int
+	ServiceDate string
+	Title       string
+	Notes       string
+	ItemCount   int
+	Items       []dtoServiceItem
+}
+
+// dtoServiceItem is one entry of a service plan. Song items are stored by
+// SongbookAcronym and EntryText; SongId, Title and Entry are filled from the
+// matching song when reading, and Missing is set when no song matches any
+// more. Other item types carry their own Title, Content and ScriptureRef.
+type dtoServiceItem struct {
+	Id              int
+	Position        int
+	ItemType        ServiceItemType
+	SongId          int
+	VerseOrder      string
+	Notes           string
+	Title           string
+	Content         string
+	ScriptureRef    string
+	Entry           int
+	SongbookAcronym string
+	EntryText       string
+	Missing         bool
+}
+
+type ServiceItemType string
+
+const (
+	SongItem         ServiceItemType = "song"
+	ReadingItem      ServiceItemType = "reading"
+	AnnouncementItem ServiceItemType = "announcement"
+	PrayerItem       ServiceItemType = "prayer"
+	LiturgyItem      ServiceItemType = "liturgy"
+)
+
+// dtoSongUsage summarizes how often and when a song was used
+type dtoSongUsage struct {
+	Id              int
+	Entry           int
+	EntryText       string
+	Title           string
+	SongbookAcronym string
+	UseCount        int
+	LastUsed        string
+}
+
+// dtoTag is a user tag with the number of songs carrying it
+type dtoTag struct {
+	Name      string
+	SongCount int
+}
+
+// dtoLiturgicalDay describes the place of a date in the church year
+type dtoLiturgicalDay struct {
+	Date       string
+	Name       string
+	Season     string
+	SeasonName string
+	Feast      string
+	FeastName  string
+}
+
+// dtoLiturgicalRule links a season or feast to songs by tag or songbook entry range
+type dtoLiturgicalRule struct {
+	Id              int
+	Occasion        string
+	Tag             string
+	SongbookAcronym string
+	EntryFrom       int
+	EntryTo         int
+}
+
+// dtoSongSuggestion is a song proposed for a date with its ranking score
+type dtoSongSuggestion struct {
+	Id              int
+	Entry           int
+	EntryText       string
+	Title           string
+	SongbookAcronym string
+	Occasion        string
+	LastUsed        string
+	UseCount        int
+	Score           float64
+}
+
+// dtoSongChapter is a printed chapter of a songbook with the entries it contains
+type dtoSongChapter struct {
+	Id              int
+	SongbookAcronym string
+	Position        int
+	Section         string
+	Name            string
+	Ranges          string // e.g. "271-288, 290"
+	SongCount       int
+}
+
+// dtoScriptureRef is a bible passage linked to a song
+type dtoScriptureRef struct {
+	Id        int
+	Reference string
+	Book      string
+	BookName  string
+}
+
+// dtoScriptureMatch is a song whose linked passage overlaps a searched reference
+type dtoScriptureMatch struct {
+	Id              int
+	Entry           int
+	EntryText       string
+	Title           string
+	SongbookAcronym string
+	Reference       string
+}
+
+// dtoSongTune is the tune and metrical pattern of a song
+type dtoSongTune struct {
+	TuneName        string
+	Meter           string // effective meter: the override or the computed one
+	ComputedMeter   string
+	MeterOverridden bool
+}
+
+// dtoTuneMatch is a song sharing a meter or tune with another song
+type dtoTuneMatch struct {
+	Id              int
+	Entry           int
+	EntryText       string
+	Title           string
+	SongbookAcronym string
+	TuneName        string
+	Meter           string
+	Inferred        bool // tune guessed from identical music attribution and meter
+}
+
+// dtoConcordance is a matching song in another songbook
+type dtoConcordance struct {
+	Id              int // 0 when the matching song is not in the database
+	Entry           int
+	EntryText       string
+	Title           string
+	SongbookAcronym string
+	Confidence      float64
+	Reason          string
+}
+
+// dtoSimilarSong is a song recommended as an alternative to another song
+type dtoSimilarSong struct {
+	Id              int
+	Entry           int
+	EntryText       string
+	Title           string
+	SongbookAcronym string
+	Score           float64
+	Reason          string
+}
+
+// dtoSongDetails is the imported metadata of a song beyond title, authors and verses
+type dtoSongDetails struct {
+	Titles    []string // all titles, the main title first
+	Themes    []string
+	Copyright string
+	CcliNo    string
+	Key       string
+	Tempo     string
+	Verses    []dtoVerseDetail
+}
+
+// dtoVerseDetail is one verse with its language and chord information
+type dtoVerseDetail struct {
+	Name     string
+	Lang     string
+	Translit string
+	Lines    string
+	Chords   string
+	Comment  string
+}
+
+// dtoPresentationOptions configures an exported slide deck. Format is "pptx"
+// or "odp", colors are hex values like "#FFFFFF" and FontSize is the size of
+// verse text in points. Empty fields fall back to the defaults.
+type dtoPresentationOptions struct {
+	Format          string
+	FontFamily      string
+	FontSize        int
+	TextColor       string
+	BackgroundColor string
+}
+
+// dtoImportSummary describes the songs found in a foreign song database.
+// A dry run fills everything except Imported; Skipped lists songs that cannot
+// be imported with the reason.
+type dtoImportSummary struct {
+	Songs     int
+	Verses    int
+	Authors   int
+	Songbooks []string
+	Titles    []string
+	Skipped   []string
+	Imported  int
+}
+
+// dtoSongbook is a songbook with its number of songs. EZ and KK come from
+// downloads and are read-only, all other songbooks are Editable.
+type dtoSongbook struct {
+	Acronym   string
+	Name      string
+	SongCount int
+	Editable  bool
+}
+
+// dtoSongDraft is a song of a user songbook as edited in the song editor.
+// Id 0 creates a new song; Entry 0 numbers it after the last song of the
+// songbook.
+type dtoSongDraft struct {
+	Id              int
+	SongbookAcronym string
+	Entry           int
+	Title           string
+	Authors         []Author
+	Verses          []dtoVerseDetail
+	VerseOrder      string
+	Copyright       string
+	EditedBy        string // who saves the song, kept in the revision history
+}
+
+// dtoSongRevision is a stored revision of a song
+type dtoSongRevision struct {
+	Id        int
+	CreatedAt string
+	EditedBy  string
+	Title     string
+}
+
+// dtoDeletedSong is a deleted song that can be restored from its latest
+// revision. DeletedAfter is the time of that revision.
+type dtoDeletedSong struct {
+	RevisionId   int
+	EntryText    string
+	Title        string
+	DeletedAfter string
+}
+
+// dtoRevisionChange is a difference between two song revisions. Field is
+// "title", "copyright", "authors", "verse_order" or "verse", Change is
+// "changed", "added" or "removed".
+type dtoRevisionChange struct {
+	Field     string
+	VerseName string
+	Change    string
+	Before    string
+	After     string
+}
+
+// dtoSongOverlay is one locally edited field of an imported song with the
+// imported value it replaces. VerseName is set for verse lines and chords.
+type dtoSongOverlay struct {
+	Field     string
+	VerseName string
+	Value     string
+	Original  string
+}
+
+// dtoBackupSummary describes an imported user data archive. Rows counts
+// tags, favorites, local edits and other user data rows actually added;
+// Skipped lists entries kept out with the reason.
+type dtoBackupSummary struct {
+	CreatedAt     string
+	SchemaVersion int
+	Songbooks     int
+	Songs         int
+	Services      int
+	Rows          int
+	Skipped       []string
+}
+
+// dtoDatabaseSnapshot is a stored copy of Songs.db taken before a reset,
+// re-import, migration, restore or replacing import
+type dtoDatabaseSnapshot struct {
+	Name      string
+	Reason    string
+	CreatedAt string
+	Size      int64
+}
+
+// dtoOfflineBundle describes an exported or imported offline bundle. Missing
+// lists songbooks and files that were not available.
+type dtoOfflineBundle struct {
+	CreatedAt    string
+	BuildVersion string
+	Songbooks    []string
+	Files        []string
+	Missing      []string
+}
+
+// dtoSettings holds the settings saved in settings.yaml and the effective
+// ones after environment overrides. RestartRequired is set when a saved change
+// applies only after a restart.
+type dtoSettings struct {
+	Saved           AppSettings
+	Effective       AppSettings
+	Overrides       []string
+	RestartRequired bool
+}
+
 type SortingOption string
 
 const (
@@ -91,4 +466,6 @@ const (
 	Title       SortingOption = "title"
 	AuthorMusic SortingOption = "authorMusic"
 	AuthorLyric SortingOption = "authorLyric"
+	LastUsed    SortingOption = "lastUsed"
+	Chapter     SortingOption = "chapter"
 )
@@ -0,0 +1,283 @@
+package app
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Offline bundle layout
+const (
+	bundleFormat          = "lyyyra-offline-bundle"
+	bundleFormatVersion   = 1
+	bundleManifestName    = "manifest.json"
+	bundleSongbooksDir    = "songbooks"
+	bundlePdfDir          = "pdf"
+	bundleDownloadsName   = "data.yaml"
+	bundleChapterPageName = "INDEX"
+)
+
+// bundleManifest describes an offline bundle and where its data came from
+type bundleManifest struct {
+	Format       string   `json:"format"`
+	Version      int      `json:"version"`
+	BuildVersion string   `json:"buildVersion"`
+	CreatedAt    string   `json:"createdAt"`
+	Sources      []string `json:"sources"`
+	Songbooks    []string `json:"songbooks"`
+	Files        []string `json:"files"`
+}
+
+// addBundleFile copies a local file into the bundle
+func addBundleFile(zipWriter *zip.Writer, name, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w, err := zipWriter.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// ExportOfflineBundle writes the downloaded EZ and KK songs, the supplemental
+// PDFs and the download manifest into one file at path, which can be
+// imported by ImportOfflineBundle on a machine without internet.
+func (a *App) ExportOfflineBundle(path string) (dtoOfflineBundle, error) {
+	var summary dtoOfflineBundle
+	manifest := bundleManifest{
+		Format:       bundleFormat,
+		Version:      bundleFormatVersion,
+		BuildVersion: buildVersion,
+		CreatedAt:    time.Now().UTC().Format(time.RFC3339),
+		Sources:      []string{a.xmlUrl, a.xmlUrlKK, a.pdfFiles.Url},
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return summary, err
+	}
+	defer out.Close()
+	zipWriter := zip.NewWriter(out)
+
+	for _, acronym := range []string{Acronym_EZ, Acronym_KK} {
+		songDir := filepath.Join(a.songBookDir, acronym)
+		if entries, err := os.ReadDir(songDir); err != nil || len(entries) == 0 {
+			summary.Missing = append(summary.Missing, acronym)
+			continue
+		}
+		name := bundleSongbooksDir + "/" + acronym + ".zip"
+		w, err := zipWriter.Create(name)
+		if err != nil {
+			return summary, err
+		}
+		if err := zipDirectory(songDir, w); err != nil {
+			return summary, fmt.Errorf("failed to pack %s songs: %w", acronym, err)
+		}
+		manifest.Songbooks = append(manifest.Songbooks, acronym)
+	}
+	if len(manifest.Songbooks) == 0 {
+		out.Close()
+		os.Remove(path)
+		return summary, fmt.Errorf("no downloaded songs to export")
+	}
+
+	files := []struct{ name, path string }{
+		{bundleDownloadsName, filepath.Join(a.appDir, bundleDownloadsName)},
+		{bundleChapterPageName, filepath.Join(a.appDir, bundleChapterPageName)},
+	}
+	for _, pdf := range a.supplementalPDFs() {
+		files = append(files, struct{ name, path string }{bundlePdfDir + "/" + pdf.FileName, filepath.Join(a.pdfDir, pdf.FileName)})
+	}
+	for _, file := range files {
+		if _, err := os.Stat(file.path); err != nil {
+			summary.Missing = append(summary.Missing, file.name)
+			continue
+		}
+		if err := addBundleFile(zipWriter, file.name, file.path); err != nil {
+			return summary, err
+		}
+		manifest.Files = append(manifest.Files, file.name)
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return summary, err
+	}
+	w, err := zipWriter.Create(bundleManifestName)
+	if err != nil {
+		return summary, err
+	}
+	if _, err := w.Write(manifestJSON); err != nil {
+		return summary, err
+	}
+	if err := zipWriter.Close(); err != nil {
+		return summary, err
+	}
+
+	summary.CreatedAt = manifest.CreatedAt
+	summary.BuildVersion = manifest.BuildVersion
+	summary.Songbooks = manifest.Songbooks
+	summary.Files = manifest.Files
+	slog.Info(fmt.Sprintf("Offline bundle exported to %s: %+v", path, summary))
+	return summary, nil
+}
+
+// readBundleManifest validates an offline bundle and returns its manifest
+func readBundleManifest(path string) (bundleManifest, error) {
+	var manifest bundleManifest
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return manifest, fmt.Errorf("not an offline bundle: %w", err)
+	}
+	defer reader.Close()
+
+	file, err := reader.Open(bundleManifestName)
+	if err != nil {
+		return manifest, fmt.Errorf("not an offline bundle: missing %s", bundleManifestName)
+	}
+	defer file.Close()
+	if err := json.NewDecoder(file).Decode(&manifest); err != nil || manifest.Format != bundleFormat {
+		return manifest, fmt.Errorf("not an offline bundle: invalid %s", bundleManifestName)
+	}
+	if manifest.Version > bundleFormatVersion {
+		return manifest, fmt.Errorf("offline bundle was created by a newer version (%s)", manifest.BuildVersion)
+	}
+	if len(manifest.Songbooks) == 0 {
+		return manifest, fmt.Errorf("offline bundle contains no songs")
+	}
+	return manifest, nil
+}
+
+// requireNoBuiltinSongs fails when EZ or KK songs are already in the
+// database, as filling the database again would insert them twice
+func (a *App) requireNoBuiltinSongs() error {
+	return a.withDB(func(db *sql.DB) error {
+		var count int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM songs WHERE songbook_acronym IN (?, ?)`, Acronym_EZ, Acronym_KK).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("database already contains %d downloaded songs, reset the data before importing an offline bundle", count)
+		}
+		return nil
+	})
+}
+
+// ImportOfflineBundle installs an offline bundle created by
+// ExportOfflineBundle into a database without downloaded songs and runs the
+// usual preparation: unpacking the songs, filling the database, importing
+// chapters and splitting kytara.pdf. Files are taken over through file://
+// URLs like regular downloads.
+func (a *App) ImportOfflineBundle(path string) (dtoOfflineBundle, error) {
+	var summary dtoOfflineBundle
+	manifest, err := readBundleManifest(path)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Reading offline bundle failed: %s", err))
+		return summary, err
+	}
+	summary.CreatedAt = manifest.CreatedAt
+	summary.BuildVersion = manifest.BuildVersion
+	if err := a.requireNoBuiltinSongs(); err != nil {
+		slog.Error(fmt.Sprintf("Importing offline bundle refused: %s", err))
+		return summary, err
+	}
+
+	tempDir, err := os.MkdirTemp("", "lyyyra-bundle-*")
+	if err != nil {
+		return summary, err
+	}
+	defer os.RemoveAll(tempDir)
+	if err := unzip(path, tempDir); err != nil {
+		return summary, err
+	}
+
+	a.startProgress("Importuji offline balíček...")
+	defer a.clearProgress()
+
+	for _, acronym := range manifest.Songbooks {
+		if acronym != Acronym_EZ && acronym != Acronym_KK {
+			summary.Missing = append(summary.Missing, acronym)
+			continue
+		}
+		archive := filepath.Join(tempDir, bundleSongbooksDir, acronym+".zip")
+		if err := a.installSongArchive(localFileURL(archive), "Songs"+acronym+".zip", acronym); err != nil {
+			return summary, fmt.Errorf("failed to install %s songs: %w", acronym, err)
+		}
+		summary.Songbooks = append(summary.Songbooks, acronym)
+	}
+	if len(summary.Songbooks) == 0 {
+		return summary, fmt.Errorf("offline bundle contains no songs")
+	}
+	a.status.SongsReady = true
+	a.status.DatabaseReady = false
+	a.saveStatus()
+
+	pdfs := a.supplementalPDFs()
+	pdfReady := len(pdfs) > 0
+	for _, pdf := range pdfs {
+		source := filepath.Join(tempDir, bundlePdfDir, pdf.FileName)
+		if _, err := os.Stat(source); err != nil {
+			pdfReady = false
+			summary.Missing = append(summary.Missing, bundlePdfDir+"/"+pdf.FileName)
+			continue
+		}
+		target, err := filepath.Rel(a.appDir, filepath.Join(a.pdfDir, pdf.FileName))
+		if err != nil {
+			return summary, err
+		}
+		if _, err := a.downloadFile(localFileURL(source), target); err != nil {
+			return summary, err
+		}
+		summary.Files = append(summary.Files, bundlePdfDir+"/"+pdf.FileName)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, bundleDownloadsName)); err == nil {
+		if _, err := a.downloadFile(localFileURL(filepath.Join(tempDir, bundleDownloadsName)), bundleDownloadsName); err != nil {
+			return summary, err
+		}
+		var sources SongFilesSources
+		if err := a.deserializeFromYaml(&sources, bundleDownloadsName); err != nil {
+			slog.Warn("Ignoring invalid download manifest in offline bundle", "error", err)
+		} else {
+			a.pdfFiles = sources
+		}
+		summary.Files = append(summary.Files, bundleDownloadsName)
+	}
+
+	a.updateProgress("Naplňuji databázi...", 0)
+	a.FillDatabase()
+	if _, err := os.Stat(filepath.Join(tempDir, bundleChapterPageName)); err == nil {
+		indexPath, err := a.downloadFile(localFileURL(filepath.Join(tempDir, bundleChapterPageName)), bundleChapterPageName)
+		if err == nil {
+			err = a.importSongChapters(indexPath)
+		}
+		if err != nil {
+			slog.Warn("Failed to import EZ chapters from offline bundle", "error", err)
+		} else {
+			summary.Files = append(summary.Files, bundleChapterPageName)
+		}
+	}
+	a.refreshSimilarSongs()
+	a.status.DatabaseReady = true
+	a.status.WebResourcesReady = pdfReady
+	a.saveStatus()
+
+	if pdfReady {
+		a.updateProgress("Zpracovávám PDF soubory...", 90)
+		if err := a.ProcessKytaraPDF(); err != nil {
+			slog.Warn("Error processing kytara.pdf", "error", err)
+		}
+	}
+	slog.Info(fmt.Sprintf("Offline bundle imported from %s: %+v", path, summary))
+	return summary, nil
+}
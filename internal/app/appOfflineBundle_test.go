@@ -0,0 +1,131 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setupBundleApp creates an app with its data inside a temporary app directory
+func setupBundleApp(t *testing.T) *App {
+	t.Helper()
+	appDir := t.TempDir()
+	app := &App{
+		appDir:      appDir,
+		dbFilePath:  filepath.Join(appDir, "Songs.db"),
+		songBookDir: filepath.Join(appDir, "SongBook"),
+		pdfDir:      filepath.Join(appDir, "PdfSources"),
+		testRun:     true,
+		settings:    defaultSettings(),
+	}
+	app.InitializeDatabase()
+	return app
+}
+
+func TestOfflineBundle_RoundTrip(t *testing.T) {
+	source := setupBundleApp(t)
+	if err := copyDir("testdata/", filepath.Join(source.songBookDir, Acronym_EZ)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(source.pdfDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for _, pdf := range source.supplementalPDFs() {
+		if err := os.WriteFile(filepath.Join(source.pdfDir, pdf.FileName), []byte("%PDF-1.4 "+pdf.FileName), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	source.pdfFiles = SongFilesSources{Domain: "example.org", Url: "https://example.org/zpevnik/", UrlScheme: "https"}
+	source.serializeToYaml(bundleDownloadsName, source.pdfFiles)
+	if err := os.WriteFile(filepath.Join(source.appDir, bundleChapterPageName), []byte(`<html><body><section><div><div><div>
+<h3>Žalmy</h3>
+<h4><a href="/z.pdf" title="Žalmy 1–4" download>Žalmy 1–4</a></h4>
+</div></div></div></section></body></html>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bundlePath := filepath.Join(t.TempDir(), "lyyyra-offline.zip")
+	exported, err := source.ExportOfflineBundle(bundlePath)
+	if err != nil {
+		t.Fatalf("ExportOfflineBundle: %v", err)
+	}
+	if !reflect.DeepEqual(exported.Songbooks, []string{Acronym_EZ}) || !reflect.DeepEqual(exported.Missing, []string{Acronym_KK}) {
+		t.Errorf("unexpected export summary %+v", exported)
+	}
+
+	target := setupBundleApp(t)
+	imported, err := target.ImportOfflineBundle(bundlePath)
+	if err != nil {
+		t.Fatalf("ImportOfflineBundle: %v", err)
+	}
+	if !reflect.DeepEqual(imported.Songbooks, []string{Acronym_EZ}) || len(imported.Missing) != 0 {
+		t.Errorf("unexpected import summary %+v", imported)
+	}
+	if !target.status.SongsReady || !target.status.DatabaseReady || !target.status.WebResourcesReady {
+		t.Errorf("status should be ready after import, got %+v", target.status)
+	}
+	if target.pdfFiles.Url != "https://example.org/zpevnik/" {
+		t.Errorf("download manifest should be restored, got %+v", target.pdfFiles)
+	}
+	for _, pdf := range target.supplementalPDFs() {
+		data, err := os.ReadFile(filepath.Join(target.pdfDir, pdf.FileName))
+		if err != nil || !strings.Contains(string(data), pdf.FileName) {
+			t.Errorf("%s not installed: %v", pdf.FileName, err)
+		}
+	}
+	songs, err := target.GetSongs("entry", "", Acronym_EZ)
+	if err != nil || len(songs) == 0 {
+		t.Fatalf("songs should be imported into the database: %v %d", err, len(songs))
+	}
+	if _, err := os.Stat(filepath.Join(target.songBookDir, Acronym_EZ, "song-1.xml")); err != nil {
+		t.Errorf("song files should be unpacked: %v", err)
+	}
+	// similar songs are computed after the chapters are imported
+	var psalm dtoSong
+	for _, song := range songs {
+		if song.Entry == 1 {
+			psalm = song
+		}
+	}
+	similar, err := target.GetSimilarSongs(psalm.Id, 0)
+	if err != nil || len(similar) == 0 || !strings.Contains(similar[0].Reason, "chapter") {
+		t.Errorf("similar songs should include the shared chapter, got %+v %v", similar, err)
+	}
+
+	// a second import would insert the songs again
+	if _, err := target.ImportOfflineBundle(bundlePath); err == nil || !strings.Contains(err.Error(), "already contains") {
+		t.Errorf("importing into a filled database should be refused, got %v", err)
+	}
+	if again, _ := target.GetSongs("entry", "", Acronym_EZ); len(again) != len(songs) {
+		t.Errorf("song count changed from %d to %d", len(songs), len(again))
+	}
+}
+
+func TestOfflineBundle_Invalid(t *testing.T) {
+	app := setupBundleApp(t)
+	if _, err := app.ExportOfflineBundle(filepath.Join(t.TempDir(), "empty.zip")); err == nil {
+		t.Error("exporting without downloaded songs should fail")
+	}
+
+	backup := setupBundleApp(t)
+	backup.status.Sorting = Title
+	dataURL, err := backup.ExportUserData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userData := filepath.Join(t.TempDir(), "zaloha.zip")
+	os.WriteFile(userData, decodeDataURL(t, dataURL, "data:application/zip;base64,"), 0644)
+	if _, err := app.ImportOfflineBundle(userData); err == nil || !strings.Contains(err.Error(), "not an offline bundle") {
+		t.Errorf("user data archive should be rejected, got %v", err)
+	}
+}
+
+func TestUnzip_RejectsEscapingPaths(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "evil.zip")
+	writeTestZip(t, archive, map[string]string{"../evil.txt": "x"})
+	if err := unzip(archive, t.TempDir()); err == nil {
+		t.Error("entries outside the destination should be rejected")
+	}
+}
@@ -0,0 +1,347 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// openLPPreviewTitles is the number of song titles listed in an import summary
+const openLPPreviewTitles = 20
+
+// openLPFormattingTag matches OpenLP formatting tags like {r} or {/st}
+var openLPFormattingTag = regexp.MustCompile(`\{/?[a-z]+\}`)
+
+// openLPLyrics is the XML stored in the lyrics column of OpenLP songs
+type openLPLyrics struct {
+	Verses []openLPVerse `xml:"lyrics>verse"`
+}
+
+type openLPVerse struct {
+	Type  string `xml:"type,attr"`
+	Label string `xml:"label,attr"`
+	Lang  string `xml:"lang,attr"`
+	Text  string `xml:",chardata"`
+}
+
+// parseOpenLPLyrics converts OpenLP lyrics to verses named like "v1" or "c1".
+// Formatting tags and optional split markers are dropped, inline [chord]
+// markers of OpenLP 3 are kept in the Chords of a verse. Lyrics that are not
+// XML, as written by very old versions, become a single verse.
+func parseOpenLPLyrics(lyrics string) []Verse {
+	var doc openLPLyrics
+	if err := xml.Unmarshal([]byte(lyrics), &doc); err != nil {
+		doc.Verses = []openLPVerse{{Type: "v", Label: "1", Text: lyrics}}
+	}
+
+	verses := []Verse{}
+	for _, v := range doc.Verses {
+		text := strings.ReplaceAll(v.Text, "\r\n", "\n")
+		text = strings.ReplaceAll(text, "[---]", "")
+		text = openLPFormattingTag.ReplaceAllString(text, "")
+		label := strings.TrimSpace(v.Label)
+		if label == "" {
+			label = "1"
+		}
+		verseType := strings.ToLower(strings.TrimSpace(v.Type))
+		if verseType == "" {
+			verseType = "v"
+		}
+
+		var plain []string
+		for _, line := range strings.Split(text, "\n") {
+			plain = append(plain, strings.Join(strings.Fields(chordProChord.ReplaceAllString(line, "")), " "))
+		}
+		verse := Verse{Name: verseType + label, Lang: v.Lang, Lines: trimVerseLines(strings.Join(plain, "\n"))}
+		if chordProChord.MatchString(text) {
+			verse.Chords = trimVerseLines(text)
+		}
+		if verse.Lines != "" || verse.Chords != "" {
+			verses = append(verses, verse)
+		}
+	}
+	return verses
+}
+
+// openLPVerseOrder lower-cases an OpenLP verse order ("V1 C1 V2") and drops
+// names without a matching verse
+func openLPVerseOrder(order string, verses []Verse) string {
+	var names []string
+	for _, name := range parseVerseOrder(order) {
+		if slices.ContainsFunc(verses, func(v Verse) bool { return v.Name == name }) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// openLPAuthors maps an OpenLP author type to Lyyyra authors; "words+music"
+// authors are listed for both
+func openLPAuthors(name, authorType string) []Author {
+	switch authorType {
+	case "words+music":
+		return []Author{{Type: "words", Value: name}, {Type: "music", Value: name}}
+	case "translation", "music":
+		return []Author{{Type: authorType, Value: name}}
+	default:
+		return []Author{{Type: "words", Value: name}}
+	}
+}
+
+// openLPTableExists reports whether the OpenLP database has the given table
+func openLPTableExists(db *sql.DB, table string) (bool, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&count)
+	return count > 0, err
+}
+
+// readOpenLPSongs reads all songs of an OpenLP songs.sqlite database. The
+// OpenLP song book name and entry are returned in the Songbook of each song.
+// Both the song book link table of OpenLP 2.4+ and the older song_book_id
+// column are understood.
+func (a *App) readOpenLPSongs(path string) ([]*Song, []string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil, err
+	}
+	db, err := sql.Open("sqlite3", "file:"+filepath.ToSlash(path)+"?mode=ro")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+
+	if ok, err := a.columnExists(db, "songs", "lyrics"); err != nil || !ok {
+		return nil, nil, fmt.Errorf("%s is not an OpenLP song database", filepath.Base(path))
+	}
+
+	query := `SELECT id, COALESCE(title, ''), COALESCE(alternate_title, ''), COALESCE(lyrics, ''),
+		COALESCE(verse_order, ''), COALESCE(copyright, ''), COALESCE(ccli_number, '') FROM songs`
+	if ok, _ := a.columnExists(db, "songs", "temporary"); ok {
+		query += ` WHERE COALESCE(temporary, 0) = 0`
+	}
+	rows, err := db.Query(query + ` ORDER BY id`)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := map[int]*Song{}
+	var ids []int
+	var skipped []string
+	for rows.Next() {
+		var id int
+		var alternate, lyrics string
+		song := &Song{}
+		if err := rows.Scan(&id, &song.Title, &alternate, &lyrics, &song.VerseOrder, &song.Copyright, &song.CcliNo); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		song.Title = strings.TrimSpace(song.Title)
+		song.Lyrics.Verses = parseOpenLPLyrics(lyrics)
+		if song.Title == "" || len(song.Lyrics.Verses) == 0 {
+			skipped = append(skipped, fmt.Sprintf("#%d %s: bez názvu nebo textu", id, song.Title))
+			continue
+		}
+		song.VerseOrder = openLPVerseOrder(song.VerseOrder, song.Lyrics.Verses)
+		song.Titles = []SongTitle{{Value: song.Title}}
+		if alternate = strings.TrimSpace(alternate); alternate != "" && alternate != song.Title {
+			song.Titles = append(song.Titles, SongTitle{Value: alternate})
+		}
+		byID[id] = song
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	authorType := "''"
+	if ok, _ := a.columnExists(db, "authors_songs", "author_type"); ok {
+		authorType = "COALESCE(l.author_type, '')"
+	}
+	authorQuery := `SELECT l.song_id,
+			COALESCE(NULLIF(TRIM(au.display_name), ''), TRIM(COALESCE(au.first_name, '') || ' ' || COALESCE(au.last_name, ''))), ` +
+		authorType + ` FROM authors_songs l JOIN authors au ON au.id = l.author_id ORDER BY l.song_id, au.id`
+	if err := scanOpenLPPairs(db, authorQuery, func(song *Song, name, kind string) {
+		if name != "" {
+			song.Authors = append(song.Authors, openLPAuthors(name, kind)...)
+		}
+	}, byID); err != nil {
+		return nil, nil, err
+	}
+
+	bookQuery := ""
+	if ok, _ := openLPTableExists(db, "songs_songbooks"); ok {
+		bookQuery = `SELECT l.song_id, b.name, COALESCE(l.entry, '') FROM songs_songbooks l
+			JOIN song_books b ON b.id = l.songbook_id ORDER BY l.song_id, b.id`
+	} else if ok, _ := a.columnExists(db, "songs", "song_book_id"); ok {
+		bookQuery = `SELECT s.id, b.name, COALESCE(s.song_number, '') FROM songs s
+			JOIN song_books b ON b.id = s.song_book_id ORDER BY s.id`
+	}
+	if bookQuery != "" {
+		if err := scanOpenLPPairs(db, bookQuery, func(song *Song, name, entry string) {
+			if song.Songbook.Name == "" {
+				song.Songbook = Songbook{Name: strings.TrimSpace(name), Entry: strings.TrimSpace(entry)}
+			}
+		}, byID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if ok, _ := openLPTableExists(db, "songs_topics"); ok {
+		if err := scanOpenLPPairs(db, `SELECT l.song_id, t.name, '' FROM songs_topics l
+			JOIN topics t ON t.id = l.topic_id ORDER BY l.song_id, t.name`, func(song *Song, name, _ string) {
+			song.Themes = append(song.Themes, Theme{Value: name})
+		}, byID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	songs := make([]*Song, 0, len(ids))
+	for _, id := range ids {
+		songs = append(songs, byID[id])
+	}
+	return songs, skipped, nil
+}
+
+// scanOpenLPPairs runs a query returning song id and two strings and passes
+// each row of a known song to apply
+func scanOpenLPPairs(db *sql.DB, query string, apply func(song *Song, first, second string), byID map[int]*Song) error {
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var songID int
+		var first, second string
+		if err := rows.Scan(&songID, &first, &second); err != nil {
+			return err
+		}
+		if song, ok := byID[songID]; ok {
+			apply(song, first, second)
+		}
+	}
+	return rows.Err()
+}
+
+// summarizeOpenLPSongs builds the dry-run summary of songs read from OpenLP
+func summarizeOpenLPSongs(songs []*Song, skipped []string) dtoImportSummary {
+	summary := dtoImportSummary{Songs: len(songs), Songbooks: []string{}, Titles: []string{}, Skipped: skipped}
+	if summary.Skipped == nil {
+		summary.Skipped = []string{}
+	}
+	authors := map[string]bool{}
+	for _, song := range songs {
+		summary.Verses += len(song.Lyrics.Verses)
+		for _, author := range song.Authors {
+			authors[author.Value] = true
+		}
+		if song.Songbook.Name != "" && !slices.Contains(summary.Songbooks, song.Songbook.Name) {
+			summary.Songbooks = append(summary.Songbooks, song.Songbook.Name)
+		}
+		if len(summary.Titles) < openLPPreviewTitles {
+			summary.Titles = append(summary.Titles, song.Title)
+		}
+	}
+	summary.Authors = len(authors)
+	return summary
+}
+
+// numberOpenLPSongs assigns songbook entries. Numeric OpenLP song book
+// entries are kept when unique, the other songs are numbered after them.
+func numberOpenLPSongs(songs []*Song) {
+	used := map[int]bool{}
+	last := 0
+	keep := make([]bool, len(songs))
+	for i, song := range songs {
+		entry, err := strconv.Atoi(song.Songbook.Entry)
+		if err != nil || entry <= 0 || used[entry] {
+			continue
+		}
+		used[entry], keep[i] = true, true
+		last = max(last, entry)
+	}
+	for i, song := range songs {
+		if keep[i] {
+			continue
+		}
+		last++
+		song.Songbook.Entry = strconv.Itoa(last)
+	}
+}
+
+// PreviewOpenLPImport reads an OpenLP songs.sqlite database without changing
+// anything and returns what an import would bring in
+func (a *App) PreviewOpenLPImport(path string) (dtoImportSummary, error) {
+	songs, skipped, err := a.readOpenLPSongs(path)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Reading OpenLP database failed: %s", err))
+		return dtoImportSummary{}, err
+	}
+	return summarizeOpenLPSongs(songs, skipped), nil
+}
+
+// ImportOpenLP imports all songs of an OpenLP songs.sqlite database with
+// their authors, verses, alternate titles and topics into a new user
+// songbook. The songbook must not contain songs yet, the downloaded EZ and
+// KK songbooks are refused.
+func (a *App) ImportOpenLP(path string, songbookAcronym string, songbookName string) (dtoImportSummary, error) {
+	songbookAcronym = strings.TrimSpace(songbookAcronym)
+	if !songbookAcronymPattern.MatchString(songbookAcronym) {
+		return dtoImportSummary{}, fmt.Errorf("songbook acronym must be 1 to 10 letters or digits")
+	}
+	if isBuiltinSongbook(songbookAcronym) {
+		return dtoImportSummary{}, fmt.Errorf("songbook acronym %s is reserved", songbookAcronym)
+	}
+	songs, skipped, err := a.readOpenLPSongs(path)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Reading OpenLP database failed: %s", err))
+		return dtoImportSummary{}, err
+	}
+	summary := summarizeOpenLPSongs(songs, skipped)
+	if len(songs) == 0 {
+		return summary, fmt.Errorf("no songs found in %s", filepath.Base(path))
+	}
+	numberOpenLPSongs(songs)
+
+	err = a.withDB(func(db *sql.DB) error {
+		// a failed import leaves no partial songbook behind
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		acronym, err := a.getOrCreateSongbook(tx, songbookAcronym, songbookName)
+		if err != nil {
+			return err
+		}
+		var existing int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM songs WHERE songbook_acronym = ?`, acronym).Scan(&existing); err != nil {
+			return err
+		}
+		if existing > 0 {
+			return fmt.Errorf("songbook %s already contains %d songs", acronym, existing)
+		}
+
+		for _, song := range songs {
+			if _, err := a.insertFullSong(tx, song, acronym, filepath.Base(path)); err != nil {
+				return fmt.Errorf("failed to import %s: %w", song.Title, err)
+			}
+			summary.Imported++
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("OpenLP import failed: %s", err))
+		summary.Imported = 0
+		return summary, err
+	}
+	a.markSimilarSongsStale()
+	return summary, nil
+}
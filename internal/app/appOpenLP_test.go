@@ -0,0 +1,194 @@
+package app
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseOpenLPLyrics(t *testing.T) {
+	tests := []struct {
+		name   string
+		lyrics string
+		want   []Verse
+	}{
+		{
+			name: "verses with formatting and chords",
+			lyrics: `<?xml version='1.0' encoding='UTF-8'?>
+<song version="1.0"><lyrics>
+<verse type="v" label="1"><![CDATA[{r}Chval{/r} duše má
+[---]
+Hospodina]]></verse>
+<verse type="c" label="1" lang="en"><![CDATA[[G]Glory to [D]God]]></verse>
+<verse type="b" label="1"><![CDATA[   ]]></verse>
+</lyrics></song>`,
+			want: []Verse{
+				{Name: "v1", Lines: "Chval duše má\n\nHospodina"},
+				{Name: "c1", Lang: "en", Lines: "Glory to God", Chords: "[G]Glory to [D]God"},
+			},
+		},
+		{
+			name:   "plain text of old versions",
+			lyrics: "Jediná sloka\ndruhý řádek",
+			want:   []Verse{{Name: "v1", Lines: "Jediná sloka\ndruhý řádek"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseOpenLPLyrics(tt.lyrics); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumberOpenLPSongs(t *testing.T) {
+	songs := []*Song{
+		{Songbook: Songbook{Name: "Písně", Entry: "5"}},
+		{Songbook: Songbook{Name: "Písně", Entry: "5"}},
+		{},
+		{Songbook: Songbook{Name: "Jiné", Entry: "12a"}},
+		{Songbook: Songbook{Name: "Jiné", Entry: "2"}},
+	}
+	numberOpenLPSongs(songs)
+	var got []string
+	for _, song := range songs {
+		got = append(got, song.Songbook.Entry)
+	}
+	if want := []string{"5", "6", "7", "8", "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+}
+
+// createOpenLPDatabase writes a small OpenLP 2.4 style songs.sqlite
+func createOpenLPDatabase(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "songs.sqlite")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`CREATE TABLE songs (id INTEGER PRIMARY KEY, title TEXT, alternate_title TEXT, lyrics TEXT, verse_order TEXT,
+			copyright TEXT, comments TEXT, ccli_number TEXT, theme_name TEXT, search_title TEXT, search_lyrics TEXT, temporary BOOLEAN)`,
+		`CREATE TABLE authors (id INTEGER PRIMARY KEY, first_name TEXT, last_name TEXT, display_name TEXT)`,
+		`CREATE TABLE authors_songs (author_id INTEGER, song_id INTEGER, author_type TEXT)`,
+		`CREATE TABLE song_books (id INTEGER PRIMARY KEY, name TEXT, publisher TEXT)`,
+		`CREATE TABLE songs_songbooks (songbook_id INTEGER, song_id INTEGER, entry TEXT)`,
+		`CREATE TABLE topics (id INTEGER PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE songs_topics (topic_id INTEGER, song_id INTEGER)`,
+		`INSERT INTO songs (id, title, alternate_title, lyrics, verse_order, copyright, ccli_number, temporary) VALUES
+			(1, 'Amazing Grace', 'Úžasná milost', '<song version="1.0"><lyrics><verse type="v" label="1"><![CDATA[Amazing grace]]></verse><verse type="c" label="1"><![CDATA[How sweet]]></verse><verse type="v" label="2"><![CDATA[Twas grace]]></verse></lyrics></song>', 'V1 C1 V2 C1 E1', 'Public Domain', '22025', 0),
+			(2, 'Dočasná', '', '<song version="1.0"><lyrics><verse type="v" label="1"><![CDATA[x]]></verse></lyrics></song>', '', '', '', 1),
+			(3, 'Bez textu', '', '', '', '', '', 0),
+			(4, 'Kristus vstal', '', '<song version="1.0"><lyrics><verse type="v" label="1"><![CDATA[Kristus vstal z mrtvých]]></verse></lyrics></song>', '', '', '', 0)`,
+		`INSERT INTO authors VALUES (1, 'John', 'Newton', 'John Newton'), (2, 'Jan', 'Novák', '')`,
+		`INSERT INTO authors_songs VALUES (1, 1, 'words'), (2, 1, 'music'), (2, 4, 'words+music')`,
+		`INSERT INTO song_books VALUES (1, 'Hymns', '')`,
+		`INSERT INTO songs_songbooks VALUES (1, 1, '42')`,
+		`INSERT INTO topics VALUES (1, 'Milost')`,
+		`INSERT INTO songs_topics VALUES (1, 1)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("creating OpenLP database: %v\n%s", err, stmt)
+		}
+	}
+	return path
+}
+
+func TestPreviewOpenLPImport(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	path := createOpenLPDatabase(t)
+
+	summary, err := app.PreviewOpenLPImport(path)
+	if err != nil {
+		t.Fatalf("PreviewOpenLPImport: %v", err)
+	}
+	want := dtoImportSummary{
+		Songs:     2,
+		Verses:    4,
+		Authors:   2,
+		Songbooks: []string{"Hymns"},
+		Titles:    []string{"Amazing Grace", "Kristus vstal"},
+		Skipped:   []string{"#3 Bez textu: bez názvu nebo textu"},
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+	songs, _ := app.GetSongs("entry", "", "OLP")
+	if len(songs) != 0 {
+		t.Errorf("dry run must not import songs, got %d", len(songs))
+	}
+}
+
+func TestImportOpenLP(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	path := createOpenLPDatabase(t)
+
+	summary, err := app.ImportOpenLP(path, "OLP", "OpenLP")
+	if err != nil {
+		t.Fatalf("ImportOpenLP: %v", err)
+	}
+	if summary.Imported != 2 {
+		t.Errorf("Imported = %d, want 2", summary.Imported)
+	}
+
+	songs, err := app.GetSongs("entry", "", "OLP")
+	if err != nil || len(songs) != 2 {
+		t.Fatalf("GetSongs: %v %+v", err, songs)
+	}
+	var grace dtoSong
+	for _, song := range songs {
+		if song.Title == "Amazing Grace" {
+			grace = song
+		}
+	}
+	song := mustLoadExportSong(t, app, grace.Id)
+	if song.EntryText != "42" || song.VerseOrder != "v1 c1 v2 c1" || song.CcliNo != "22025" {
+		t.Errorf("unexpected song %+v", song)
+	}
+	if want := []string{"Amazing Grace", "Úžasná milost"}; !reflect.DeepEqual(song.Titles, want) {
+		t.Errorf("titles = %v, want %v", song.Titles, want)
+	}
+	if want := []string{"Milost"}; !reflect.DeepEqual(song.Themes, want) {
+		t.Errorf("themes = %v, want %v", song.Themes, want)
+	}
+	wantAuthors := []Author{{Type: "words", Value: "John Newton"}, {Type: "music", Value: "Jan Novák"}}
+	if !reflect.DeepEqual(song.Authors, wantAuthors) {
+		t.Errorf("authors = %+v, want %+v", song.Authors, wantAuthors)
+	}
+
+	if _, err := app.ImportOpenLP(path, "OLP", "OpenLP"); err == nil {
+		t.Error("importing into a songbook with songs should fail")
+	}
+	if _, err := app.ImportOpenLP(app.dbFilePath, "OLP2", "Lyyyra"); err == nil {
+		t.Error("a database without OpenLP songs should be rejected")
+	}
+	for _, acronym := range []string{"KK", "", "OPEN-LP"} {
+		if _, err := app.ImportOpenLP(path, acronym, "OpenLP"); err == nil {
+			t.Errorf("acronym %q should be rejected", acronym)
+		}
+	}
+	if songs, _ := app.GetSongs("entry", "", "KK"); len(songs) != 0 {
+		t.Errorf("no song should be imported into KK, got %+v", songs)
+	}
+}
+
+// mustLoadExportSong loads a song the way exporters see it
+func mustLoadExportSong(t *testing.T, app *App, songID int) *exportSong {
+	t.Helper()
+	var song *exportSong
+	err := app.withDB(func(db *sql.DB) error {
+		var err error
+		song, err = app.loadExportSong(db, songID)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("loadExportSong(%d): %v", songID, err)
+	}
+	return song
+}
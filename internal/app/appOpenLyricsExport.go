@@ -0,0 +1,241 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const (
+	openLyricsNamespace = "http://openlyrics.info/namespace/2009/song"
+	openLyricsVersion   = "0.9"
+)
+
+// openLyricsDocument is the OpenLyrics 0.9 structure written by the exporter
+type openLyricsDocument struct {
+	XMLName    xml.Name             `xml:"song"`
+	Xmlns      string               `xml:"xmlns,attr"`
+	Version    string               `xml:"version,attr"`
+	CreatedIn  string               `xml:"createdIn,attr"`
+	Properties openLyricsProperties `xml:"properties"`
+	Verses     []openLyricsVerse    `xml:"lyrics>verse"`
+}
+
+type openLyricsProperties struct {
+	Titles     []openLyricsValue    `xml:"titles>title"`
+	Authors    []openLyricsAuthor   `xml:"authors>author"`
+	Copyright  string               `xml:"copyright,omitempty"`
+	CcliNo     string               `xml:"ccliNo,omitempty"`
+	Key        string               `xml:"key,omitempty"`
+	Tempo      *openLyricsTempo     `xml:"tempo,omitempty"`
+	VerseOrder string               `xml:"verseOrder,omitempty"`
+	Songbooks  []openLyricsSongbook `xml:"songbooks>songbook"`
+	Themes     []openLyricsValue    `xml:"themes>theme"`
+}
+
+type openLyricsAuthor struct {
+	Type  string `xml:"type,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type openLyricsValue struct {
+	Value string `xml:",chardata"`
+}
+
+type openLyricsTempo struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type openLyricsSongbook struct {
+	Name  string `xml:"name,attr"`
+	Entry string `xml:"entry,attr,omitempty"`
+}
+
+type openLyricsVerse struct {
+	Name     string          `xml:"name,attr"`
+	Lang     string          `xml:"lang,attr,omitempty"`
+	Translit string          `xml:"translit,attr,omitempty"`
+	Lines    openLyricsLines `xml:"lines"`
+}
+
+type openLyricsLines struct {
+	Markup string `xml:",innerxml"`
+}
+
+// openLyricsChord matches inline [chord] markers of stored chord lines
+var openLyricsChord = regexp.MustCompile(`\[([^\]]*)\]`)
+
+// openLyricsMarkup converts verse lines with inline [chord] markers and an
+// optional comment to the mixed content of an OpenLyrics <lines> element
+func openLyricsMarkup(lines, comment string) string {
+	var b strings.Builder
+	if comment != "" {
+		b.WriteString("<comment>")
+		xml.EscapeText(&b, []byte(comment))
+		b.WriteString("</comment>")
+	}
+	for i, line := range strings.Split(lines, "\n") {
+		if i > 0 {
+			b.WriteString("<br/>")
+		}
+		last := 0
+		for _, match := range openLyricsChord.FindAllStringSubmatchIndex(line, -1) {
+			xml.EscapeText(&b, []byte(line[last:match[0]]))
+			b.WriteString(`<chord name="`)
+			xml.EscapeText(&b, []byte(line[match[2]:match[3]]))
+			b.WriteString(`"/>`)
+			last = match[1]
+		}
+		xml.EscapeText(&b, []byte(line[last:]))
+	}
+	return b.String()
+}
+
+// newOpenLyricsDocument maps an exported song to the OpenLyrics structure
+func (a *App) newOpenLyricsDocument(song *exportSong) openLyricsDocument {
+	doc := openLyricsDocument{
+		Xmlns:     openLyricsNamespace,
+		Version:   openLyricsVersion,
+		CreatedIn: strings.TrimSpace("Lyyyra " + a.status.BuildVersion),
+		Properties: openLyricsProperties{
+			Copyright:  song.Copyright,
+			CcliNo:     song.CcliNo,
+			Key:        song.Key,
+			VerseOrder: song.VerseOrder,
+		},
+	}
+	for _, author := range song.Authors {
+		doc.Properties.Authors = append(doc.Properties.Authors, openLyricsAuthor{Type: author.Type, Value: author.Value})
+	}
+	for _, title := range song.Titles {
+		doc.Properties.Titles = append(doc.Properties.Titles, openLyricsValue{Value: title})
+	}
+	for _, theme := range song.Themes {
+		doc.Properties.Themes = append(doc.Properties.Themes, openLyricsValue{Value: theme})
+	}
+	if song.Tempo != "" {
+		tempo := &openLyricsTempo{Type: "text", Value: song.Tempo}
+		if bpm, ok := strings.CutSuffix(song.Tempo, " bpm"); ok {
+			tempo = &openLyricsTempo{Type: "bpm", Value: bpm}
+		}
+		doc.Properties.Tempo = tempo
+	}
+	if song.SongbookName != "" || song.SongbookAcronym != "" {
+		name := song.SongbookName
+		if name == "" {
+			name = song.SongbookAcronym
+		}
+		doc.Properties.Songbooks = []openLyricsSongbook{{Name: name, Entry: song.EntryText}}
+	}
+	for _, verse := range song.Verses {
+		lines := verse.Lines
+		if verse.Chords != "" {
+			lines = verse.Chords
+		}
+		doc.Verses = append(doc.Verses, openLyricsVerse{
+			Name:     verse.Name,
+			Lang:     verse.Lang,
+			Translit: verse.Translit,
+			Lines:    openLyricsLines{Markup: openLyricsMarkup(lines, verse.Comment)},
+		})
+	}
+	return doc
+}
+
+// songToOpenLyrics renders a song from the database as an OpenLyrics document
+func (a *App) songToOpenLyrics(db *sql.DB, songID int) ([]byte, *exportSong, error) {
+	song, err := a.loadExportSong(db, songID)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := xml.MarshalIndent(a.newOpenLyricsDocument(song), "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return append([]byte(xml.Header), data...), song, nil
+}
+
+// openLyricsFileName returns a file name like "EZ 288 Chvaliž Hospodina.xml"
+func openLyricsFileName(song *exportSong) string {
+	name := strings.Join(strings.Fields(song.SongbookAcronym+" "+song.EntryText+" "+song.Title), " ")
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	return name + ".xml"
+}
+
+// ExportOpenLyrics returns a song as an OpenLyrics 0.9 XML data URL
+func (a *App) ExportOpenLyrics(songId int) (string, error) {
+	var data []byte
+	err := a.withDB(func(db *sql.DB) error {
+		var err error
+		data, _, err = a.songToOpenLyrics(db, songId)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return "data:application/xml;charset=utf-8;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// ExportSongbookOpenLyrics returns all songs of a songbook as a zip of
+// OpenLyrics 0.9 XML files, one per song, as a data URL
+func (a *App) ExportSongbookOpenLyrics(songbookAcronym string) (string, error) {
+	var buf bytes.Buffer
+	err := a.withDB(func(db *sql.DB) error {
+		rows, err := db.Query(`SELECT id FROM songs WHERE songbook_acronym = ? ORDER BY entry, entry_text`, songbookAcronym)
+		if err != nil {
+			return err
+		}
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if len(ids) == 0 {
+			return fmt.Errorf("songbook %s has no songs", songbookAcronym)
+		}
+
+		zipWriter := zip.NewWriter(&buf)
+		used := map[string]bool{}
+		for _, id := range ids {
+			data, song, err := a.songToOpenLyrics(db, id)
+			if err != nil {
+				return err
+			}
+			name := openLyricsFileName(song)
+			for i := 2; used[name]; i++ {
+				name = fmt.Sprintf("%s (%d).xml", strings.TrimSuffix(openLyricsFileName(song), ".xml"), i)
+			}
+			used[name] = true
+			w, err := zipWriter.Create(name)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+		return zipWriter.Close()
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("OpenLyrics export of songbook %s failed: %s", songbookAcronym, err))
+		return "", err
+	}
+	return "data:application/zip;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
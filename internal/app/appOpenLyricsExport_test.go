@@ -0,0 +1,137 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOpenLyricsMarkup(t *testing.T) {
+	tests := []struct {
+		lines, comment, want string
+	}{
+		{"Sláva & čest\nna výsostech", "", "Sláva &amp; čest<br/>na výsostech"},
+		{"[G]Sláva [D/F#]Bohu", "pomalu", `<comment>pomalu</comment><chord name="G"/>Sláva <chord name="D/F#"/>Bohu`},
+	}
+	for _, tt := range tests {
+		if got := openLyricsMarkup(tt.lines, tt.comment); got != tt.want {
+			t.Errorf("openLyricsMarkup(%q, %q) = %q, want %q", tt.lines, tt.comment, got, tt.want)
+		}
+	}
+}
+
+// importOpenLyricsSample imports the OpenLyrics 0.9 sample as EZ song
+func importOpenLyricsSample(t *testing.T, app *App) int {
+	t.Helper()
+	app.songBookDir = t.TempDir()
+	ezDir := filepath.Join(app.songBookDir, "EZ")
+	if err := os.MkdirAll(ezDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", "song-openlyrics-09.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ezDir, "song.xml"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(ezDir)
+	err = app.withDB(func(db *sql.DB) error {
+		if _, err := app.getOrCreateSongbook(db, "EZ", "Evangelický zpěvník 2021"); err != nil {
+			return err
+		}
+		return app.processEZSongFile(db, entries[0], "EZ")
+	})
+	if err != nil {
+		t.Fatalf("processEZSongFile: %v", err)
+	}
+	songs, err := app.GetSongs("entry", "", "EZ")
+	if err != nil || len(songs) != 1 {
+		t.Fatalf("GetSongs: %v %+v", err, songs)
+	}
+	return songs[0].Id
+}
+
+func decodeDataURL(t *testing.T, dataURL, prefix string) []byte {
+	t.Helper()
+	if !strings.HasPrefix(dataURL, prefix) {
+		t.Fatalf("unexpected data URL: %.60s", dataURL)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(dataURL, prefix))
+	if err != nil {
+		t.Fatalf("decoding data URL: %v", err)
+	}
+	return data
+}
+
+func TestExportOpenLyrics_RoundTrip(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := importOpenLyricsSample(t, app)
+	if err := app.SaveSongVerseOrder(songID, "v1 c"); err != nil {
+		t.Fatalf("SaveSongVerseOrder: %v", err)
+	}
+
+	dataURL, err := app.ExportOpenLyrics(songID)
+	if err != nil {
+		t.Fatalf("ExportOpenLyrics: %v", err)
+	}
+	data := decodeDataURL(t, dataURL, "data:application/xml;charset=utf-8;base64,")
+	for _, want := range []string{`xmlns="http://openlyrics.info/namespace/2009/song" version="0.9"`,
+		`<songbook name="Evangelický zpěvník 2021" entry="23a"></songbook>`, `<tempo type="bpm">90</tempo>`,
+		`<verseOrder>v1 c</verseOrder>`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("export misses %q:\n%s", want, data)
+		}
+	}
+
+	// the exported file is read back by our own OpenLyrics parser
+	path := filepath.Join(t.TempDir(), "export.xml")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	exported, err := parseXmlSong(path)
+	if err != nil {
+		t.Fatalf("parseXmlSong(export): %v", err)
+	}
+	original, _ := parseXmlSong(filepath.Join("testdata", "song-openlyrics-09.xml"))
+	if exported.Title != original.Title || exported.Songbook.Entry != "23a" || exported.Tempo.String() != "90 bpm" ||
+		!reflect.DeepEqual(exported.Authors, original.Authors) || len(exported.Themes) != 2 || len(exported.Titles) != 2 {
+		t.Errorf("unexpected exported metadata: %+v", exported)
+	}
+	for i, verse := range exported.Lyrics.Verses {
+		want := original.Lyrics.Verses[i]
+		if verse.Name != want.Name || verse.Lines != want.Lines || verse.Chords != want.Chords || verse.Comment != want.Comment || verse.Lang != want.Lang {
+			t.Errorf("verse %d = %+v, want %+v", i, verse, want)
+		}
+	}
+}
+
+func TestExportSongbookOpenLyrics(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	importOpenLyricsSample(t, app)
+
+	dataURL, err := app.ExportSongbookOpenLyrics("EZ")
+	if err != nil {
+		t.Fatalf("ExportSongbookOpenLyrics: %v", err)
+	}
+	data := decodeDataURL(t, dataURL, "data:application/zip;base64,")
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("reading zip: %v", err)
+	}
+	if len(reader.File) != 1 || reader.File[0].Name != "EZ 23a Pán je můj pastýř.xml" {
+		t.Errorf("unexpected zip entries: %+v", reader.File)
+	}
+
+	if _, err := app.ExportSongbookOpenLyrics("XX"); err == nil {
+		t.Error("expected error for empty songbook")
+	}
+}
@@ -0,0 +1,325 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// openSongVerse collects the lines of one verse while parsing OpenSong lyrics
+type openSongVerse struct {
+	name      string
+	lines     []string
+	chords    []string
+	comments  []string
+	hasChords bool
+}
+
+func (v *openSongVerse) addLine(plain, chords string) {
+	v.lines = append(v.lines, plain)
+	v.chords = append(v.chords, chords)
+}
+
+// parseOpenSongLyrics splits OpenSong lyrics into verses. It understands
+// section markers like [V1], [C] or [B], chord lines starting with ".",
+// comments starting with ";" and numbered lines of multi-verse sections
+// ("1 first verse", "2 second verse" below [V]). Chords are merged as inline
+// [chord] markers into the Chords of the verse.
+func parseOpenSongLyrics(lyrics string) []Verse {
+	verses := map[string]*openSongVerse{}
+	var order []string
+	verse := func(name string) *openSongVerse {
+		if v, ok := verses[name]; ok {
+			return v
+		}
+		v := &openSongVerse{name: name}
+		verses[name] = v
+		order = append(order, name)
+		return v
+	}
+
+	section := "v1"
+	multiVerse := false
+	pendingChords, chordsUsed := "", false
+	flushChords := func() {
+		if pendingChords != "" && !chordsUsed {
+			v := verse(section)
+			v.addLine("", openSongChordsOnly(pendingChords))
+			v.hasChords = true
+		}
+		pendingChords, chordsUsed = "", false
+	}
+	addLyrics := func(name, text string) {
+		v := verse(name)
+		plain, chords := mergeOpenSongChords(pendingChords, text)
+		v.addLine(plain, chords)
+		v.hasChords = v.hasChords || pendingChords != ""
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(lyrics, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			continue
+		case strings.HasPrefix(trimmed, "[") && strings.Contains(trimmed, "]"):
+			flushChords()
+			section = strings.ToLower(strings.TrimSpace(trimmed[1:strings.Index(trimmed, "]")]))
+			if section == "" {
+				section = "v"
+			}
+			// a section without number may hold numbered lines of several verses
+			last := section[len(section)-1]
+			multiVerse = last < '0' || last > '9'
+		case strings.HasPrefix(line, "."):
+			flushChords()
+			pendingChords = line
+		case strings.HasPrefix(trimmed, ";"):
+			v := verse(section)
+			v.comments = append(v.comments, strings.TrimSpace(trimmed[1:]))
+		case multiVerse && line[0] >= '1' && line[0] <= '9':
+			// the chord line above applies to all numbered lines below it
+			addLyrics(section+line[:1], line[1:])
+			chordsUsed = pendingChords != ""
+		default:
+			if chordsUsed {
+				pendingChords = ""
+			}
+			addLyrics(section, strings.TrimPrefix(line, " "))
+			pendingChords, chordsUsed = "", false
+		}
+	}
+	flushChords()
+
+	result := []Verse{}
+	for _, name := range order {
+		v := verses[name]
+		lines := trimVerseLines(strings.Join(v.lines, "\n"))
+		if lines == "" && !v.hasChords {
+			continue
+		}
+		verse := Verse{Name: name, Lines: lines, Comment: strings.Join(v.comments, "\n")}
+		if v.hasChords {
+			verse.Chords = trimVerseLines(strings.Join(v.chords, "\n"))
+		}
+		result = append(result, verse)
+	}
+	return result
+}
+
+// openSongChord is a chord of a chord line with its column
+type openSongChord struct {
+	column int
+	name   string
+}
+
+// parseOpenSongChordLine returns the chords of a chord line (".G   D/F#") with
+// their columns relative to the lyric text
+func parseOpenSongChordLine(chordLine string) []openSongChord {
+	var chords []openSongChord
+	runes := []rune(strings.TrimPrefix(chordLine, "."))
+	for i := 0; i < len(runes); {
+		if runes[i] == ' ' {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && runes[j] != ' ' {
+			j++
+		}
+		chords = append(chords, openSongChord{column: i, name: string(runes[i:j])})
+		i = j
+	}
+	return chords
+}
+
+// openSongChordsOnly renders a chord line without lyrics, e.g. for intros
+func openSongChordsOnly(chordLine string) string {
+	var parts []string
+	for _, chord := range parseOpenSongChordLine(chordLine) {
+		parts = append(parts, "["+chord.name+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// mergeOpenSongChords cleans an OpenSong lyric line and places the chords of
+// the preceding chord line at their columns. It returns the plain line and
+// the line with inline [chord] markers.
+func mergeOpenSongChords(chordLine, text string) (string, string) {
+	plain := cleanOpenSongLine(text)
+	if chordLine == "" {
+		return plain, plain
+	}
+
+	runes := []rune(text)
+	var merged strings.Builder
+	column := 0
+	for _, chord := range parseOpenSongChordLine(chordLine) {
+		if chord.column > len(runes) {
+			chord.column = len(runes)
+		}
+		// a chord above a space belongs to the following word
+		for chord.column < len(runes) && runes[chord.column] == ' ' {
+			chord.column++
+		}
+		merged.WriteString(string(runes[column:chord.column]))
+		merged.WriteString("[" + chord.name + "]")
+		column = chord.column
+	}
+	merged.WriteString(string(runes[column:]))
+	return plain, cleanOpenSongLine(merged.String())
+}
+
+// cleanOpenSongLine turns "|" and "||" separators into line breaks, drops the
+// "_" syllable extenders and collapses spaces used for chord alignment
+func cleanOpenSongLine(text string) string {
+	text = strings.ReplaceAll(text, "||", "|")
+	text = strings.ReplaceAll(text, "_", "")
+	lines := strings.Split(text, "|")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// openSongVerseOrder converts an OpenSong presentation order ("V1 C V2 C")
+// to a verse order
+func openSongVerseOrder(presentation string) string {
+	return strings.ToLower(strings.Join(strings.Fields(presentation), " "))
+}
+
+// openSongMetadata returns the titles and themes of an OpenSong song in the
+// form stored by insertSongMetadata. Themes are separated by semicolons.
+func openSongMetadata(song *SongKK, title string) *Song {
+	metadata := &Song{Titles: []SongTitle{{Value: title}}}
+	if aka := strings.TrimSpace(song.Aka); aka != "" {
+		metadata.Titles = append(metadata.Titles, SongTitle{Value: aka})
+	}
+	for _, themes := range []string{song.Theme, song.AltTheme} {
+		for _, theme := range strings.Split(themes, ";") {
+			if theme = strings.TrimSpace(theme); theme != "" {
+				metadata.Themes = append(metadata.Themes, Theme{Value: theme})
+			}
+		}
+	}
+	return metadata
+}
+
+// insertOpenSong inserts a parsed OpenSong song with its verses, authors and
+// metadata and returns the song ID
+func (a *App) insertOpenSong(db sqlQueryExecer, song *SongKK, songbookAcronym string, filename string) (int64, error) {
+	songID, err := a.insertSongKK(db, song, songbookAcronym)
+	if err != nil {
+		return 0, err
+	}
+	if err := a.insertVersesKK(db, songID, song.Lyrics, filename); err != nil {
+		return 0, err
+	}
+	if author := strings.TrimSpace(song.Author); author != "" {
+		if err := a.insertAuthors(db, songID, []Author{{Type: "words", Value: author}}, filename); err != nil {
+			return 0, err
+		}
+	}
+	var title string
+	if err := db.QueryRow(`SELECT title FROM songs WHERE id = ?`, songID).Scan(&title); err != nil {
+		return 0, err
+	}
+	if err := a.insertSongMetadata(db, songID, openSongMetadata(song, title), filename); err != nil {
+		return 0, err
+	}
+	return songID, nil
+}
+
+// listImportFiles returns the file at path or the files of the directory at
+// path. Hidden files are skipped and, when extensions are given, only files
+// with one of them are listed from a directory.
+func listImportFiles(path string, extensions ...string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if len(extensions) == 0 || slices.Contains(extensions, ext) {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// ImportOpenSong imports an OpenSong file, or all files of a directory, into
+// the given user songbook, which is created when missing. The downloaded EZ
+// and KK songbooks are refused. Songs without a hymn number are numbered
+// after the last song of the songbook. It returns the number of imported
+// songs.
+func (a *App) ImportOpenSong(path string, songbookAcronym string, songbookName string) (int, error) {
+	songbookAcronym = strings.TrimSpace(songbookAcronym)
+	if !songbookAcronymPattern.MatchString(songbookAcronym) {
+		return 0, fmt.Errorf("songbook acronym must be 1 to 10 letters or digits")
+	}
+	if isBuiltinSongbook(songbookAcronym) {
+		return 0, fmt.Errorf("songbook acronym %s is reserved", songbookAcronym)
+	}
+	files, err := listImportFiles(path)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = a.withDB(func(db *sql.DB) error {
+		// a failed import leaves no partial songbook behind
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		acronym, err := a.getOrCreateSongbook(tx, songbookAcronym, songbookName)
+		if err != nil {
+			return err
+		}
+		var lastEntry int
+		if err := tx.QueryRow(`SELECT COALESCE(MAX(entry), 0) FROM songs WHERE songbook_acronym = ?`, acronym).Scan(&lastEntry); err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			song, err := parseXmlSongKK(file)
+			if err != nil {
+				slog.Warn("Skipping file that is not an OpenSong song", "file", file, "error", err)
+				continue
+			}
+			if strings.TrimSpace(song.HymnNumber) == "" {
+				lastEntry++
+				song.HymnNumber = fmt.Sprint(lastEntry)
+			} else if entry := parseHymnNumber(song.HymnNumber); entry > lastEntry {
+				lastEntry = entry
+			}
+			if _, err := a.insertOpenSong(tx, song, acronym, filepath.Base(file)); err != nil {
+				return fmt.Errorf("failed to import %s: %w", filepath.Base(file), err)
+			}
+			count++
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("OpenSong import failed: %s", err))
+		return 0, err
+	}
+	a.markSimilarSongsStale()
+	return count, nil
+}
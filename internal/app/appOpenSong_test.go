@@ -0,0 +1,134 @@
+package app
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseOpenSongLyrics(t *testing.T) {
+	tests := []struct {
+		name   string
+		lyrics string
+		want   []Verse
+	}{
+		{
+			name:   "kancionál verses",
+			lyrics: "[V1]\n Pane, smiluj se.\n     Kriste, smiluj se.\n[V2]\n\n Bože, náš Otče",
+			want: []Verse{
+				{Name: "v1", Lines: "Pane, smiluj se.\nKriste, smiluj se."},
+				{Name: "v2", Lines: "Bože, náš Otče"},
+			},
+		},
+		{
+			name:   "chorus, bridge, comments and chords",
+			lyrics: "[C]\n;všichni\n.G    D\n Sláva Bohu\n[B]\n.Em\n Aleluja",
+			want: []Verse{
+				{Name: "c", Lines: "Sláva Bohu", Chords: "[G]Sláva [D]Bohu", Comment: "všichni"},
+				{Name: "b", Lines: "Aleluja", Chords: "[Em]Aleluja"},
+			},
+		},
+		{
+			name:   "numbered lines share the chord line",
+			lyrics: "[V]\n.C      G\n1 První sloka\n2 Druhá sloka\n refrén bez akordů",
+			want: []Verse{
+				{Name: "v1", Lines: "První sloka", Chords: "[C]První [G]sloka"},
+				{Name: "v2", Lines: "Druhá sloka", Chords: "[C]Druhá [G]sloka"},
+				{Name: "v", Lines: "refrén bez akordů"},
+			},
+		},
+		{
+			name:   "text without marker and line separators",
+			lyrics: " jeden|dva||tři",
+			want:   []Verse{{Name: "v1", Lines: "jeden\ndva\ntři"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseOpenSongLyrics(tt.lyrics); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseOpenSongLyrics() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeOpenSongChords(t *testing.T) {
+	tests := []struct {
+		chordLine, text     string
+		wantPlain, wantChrd string
+	}{
+		{".G    D", "Sláva Bohu", "Sláva Bohu", "[G]Sláva [D]Bohu"},
+		{".   Am     E7", "Ať zní_ píseň", "Ať zní píseň", "Ať [Am]zní pí[E7]seň"},
+		{".Am    D   G", "Ať", "Ať", "[Am]Ať[D][G]"},
+		{"", "bez akordů", "bez akordů", "bez akordů"},
+	}
+	for _, tt := range tests {
+		plain, chords := mergeOpenSongChords(tt.chordLine, tt.text)
+		if plain != tt.wantPlain || chords != tt.wantChrd {
+			t.Errorf("mergeOpenSongChords(%q, %q) = %q, %q; want %q, %q",
+				tt.chordLine, tt.text, plain, chords, tt.wantPlain, tt.wantChrd)
+		}
+	}
+}
+
+func TestImportOpenSong(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+
+	for _, acronym := range []string{"KK", "ez", "", "OPEN SONG"} {
+		if _, err := app.ImportOpenSong(filepath.Join("testdata", "opensong-full.xml"), acronym, "OpenSong"); err == nil {
+			t.Errorf("acronym %q should be rejected", acronym)
+		}
+	}
+
+	count, err := app.ImportOpenSong(filepath.Join("testdata", "opensong-full.xml"), "OS", "OpenSong")
+	if err != nil || count != 1 {
+		t.Fatalf("ImportOpenSong() = %d, %v", count, err)
+	}
+	songs, err := app.GetSongs("entry", "", "OS")
+	if err != nil || len(songs) != 1 {
+		t.Fatalf("GetSongs: %v %+v", err, songs)
+	}
+	song := songs[0]
+	if song.Entry != 1 || song.Title != "Amazing Grace" || song.AuthorLyric != "John Newton" {
+		t.Errorf("unexpected song: %+v", song)
+	}
+
+	details, err := app.GetSongDetails(song.Id)
+	if err != nil {
+		t.Fatalf("GetSongDetails: %v", err)
+	}
+	if !reflect.DeepEqual(details.Titles, []string{"Amazing Grace", "Úžasná milost"}) ||
+		!reflect.DeepEqual(details.Themes, []string{"Grace", "Salvation", "Milost"}) ||
+		details.Copyright != "Public Domain" || details.CcliNo != "22025" || details.Key != "G" || details.Tempo != "Slow" {
+		t.Errorf("unexpected details: %+v", details)
+	}
+	var names []string
+	for _, v := range details.Verses {
+		names = append(names, v.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"v1", "v2", "v", "c"}) {
+		t.Errorf("unexpected verse names: %v", names)
+	}
+	if v := details.Verses[0]; v.Lines != "Amazing grace how sweet the sound" || v.Chords != "[G]Amazing grace [C]how sweet [G]the sound" {
+		t.Errorf("unexpected first verse: %+v", v)
+	}
+	if c := details.Verses[3]; c.Lines != "My chains are gone,\nI've been set free" || c.Comment != "all" ||
+		c.Chords != "[D]My chains [G]are gone,\nI've been set free" {
+		t.Errorf("unexpected chorus: %+v", c)
+	}
+
+	order, err := app.GetSongVerseOrder(song.Id)
+	if err != nil || order.Original != "v1 c v2 c" {
+		t.Errorf("unexpected verse order: %+v %v", order, err)
+	}
+
+	// a second import continues the numbering of the songbook
+	if _, err := app.ImportOpenSong(filepath.Join("testdata", "opensong-full.xml"), "OS", "OpenSong"); err != nil {
+		t.Fatalf("second ImportOpenSong: %v", err)
+	}
+	songs, _ = app.GetSongs("entry", "", "OS")
+	if len(songs) != 2 || songs[1].Entry != 2 {
+		t.Errorf("expected second song numbered 2, got %+v", songs)
+	}
+}
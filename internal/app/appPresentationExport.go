@@ -0,0 +1,256 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const (
+	presentationFormatPptx = "pptx"
+	presentationFormatOdp  = "odp"
+
+	defaultPresentationFont       = "Arial"
+	defaultPresentationFontSize   = 40
+	defaultPresentationText       = "FFFFFF"
+	defaultPresentationBackground = "000000"
+)
+
+// presentationMimeTypes are the media types of the generated decks
+var presentationMimeTypes = map[string]string{
+	presentationFormatPptx: "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	presentationFormatOdp:  "application/vnd.oasis.opendocument.presentation",
+}
+
+// presentationColor matches a hex color with or without the leading "#"
+var presentationColor = regexp.MustCompile(`^#?([0-9a-fA-F]{6})$`)
+
+// presentationParagraph is one line of slide text. Scale is relative to the
+// configured font size.
+type presentationParagraph struct {
+	Text  string
+	Scale float64
+	Bold  bool
+}
+
+// presentationSlide is one slide of a deck with centered paragraphs
+type presentationSlide struct {
+	Paragraphs []presentationParagraph
+}
+
+// normalizePresentationOptions fills in defaults and validates the options.
+// Colors are returned as upper-case hex without "#".
+func normalizePresentationOptions(options dtoPresentationOptions) (dtoPresentationOptions, error) {
+	options.Format = strings.ToLower(strings.TrimSpace(options.Format))
+	if options.Format == "" {
+		options.Format = presentationFormatPptx
+	}
+	if _, ok := presentationMimeTypes[options.Format]; !ok {
+		return options, fmt.Errorf("unsupported presentation format %q, expected pptx or odp", options.Format)
+	}
+	options.FontFamily = strings.TrimSpace(options.FontFamily)
+	if options.FontFamily == "" {
+		options.FontFamily = defaultPresentationFont
+	}
+	if options.FontSize <= 0 {
+		options.FontSize = defaultPresentationFontSize
+	}
+	if options.FontSize > 200 {
+		return options, fmt.Errorf("font size %d is too large", options.FontSize)
+	}
+	for _, color := range []struct {
+		value    *string
+		fallback string
+	}{
+		{&options.TextColor, defaultPresentationText},
+		{&options.BackgroundColor, defaultPresentationBackground},
+	} {
+		raw := strings.TrimSpace(*color.value)
+		if raw == "" {
+			*color.value = color.fallback
+			continue
+		}
+		match := presentationColor.FindStringSubmatch(raw)
+		if match == nil {
+			return options, fmt.Errorf("invalid color %q, expected #RRGGBB", raw)
+		}
+		*color.value = strings.ToUpper(match[1])
+	}
+	return options, nil
+}
+
+// textSlide turns multi-line text into a slide with one paragraph per line
+func textSlide(text string) presentationSlide {
+	var slide presentationSlide
+	for _, line := range strings.Split(text, "\n") {
+		slide.Paragraphs = append(slide.Paragraphs, presentationParagraph{Text: strings.TrimSpace(line), Scale: 1})
+	}
+	return slide
+}
+
+// titleSlide is the opening slide of a song or service item
+func titleSlide(title string, subtitles ...string) presentationSlide {
+	slide := presentationSlide{Paragraphs: []presentationParagraph{{Text: title, Scale: 1.2, Bold: true}}}
+	for _, subtitle := range subtitles {
+		if subtitle != "" {
+			slide.Paragraphs = append(slide.Paragraphs, presentationParagraph{Text: subtitle, Scale: 0.6})
+		}
+	}
+	return slide
+}
+
+// songCredits formats the authors of a song like "Text: A | Hudba: B"
+func songCredits(authors []Author) string {
+	var words, music []string
+	for _, author := range authors {
+		if author.Type == "music" {
+			music = append(music, author.Value)
+		} else {
+			words = append(words, author.Value)
+		}
+	}
+	var credits []string
+	if len(words) > 0 {
+		credits = append(credits, "Text: "+strings.Join(words, ", "))
+	}
+	if len(music) > 0 {
+		credits = append(credits, "Hudba: "+strings.Join(music, ", "))
+	}
+	return strings.Join(credits, " | ")
+}
+
+// songSlides returns the title slide and one slide per verse of a song. The
+// verses follow verseOrder when given, otherwise the effective order of the song.
+func (a *App) songSlides(db *sql.DB, songID int, verseOrder string) ([]presentationSlide, error) {
+	song, err := a.loadExportSong(db, songID)
+	if err != nil {
+		return nil, err
+	}
+	verses := make([]songVerse, len(song.Verses))
+	for i, v := range song.Verses {
+		verses[i] = songVerse{Name: v.Name, Lines: v.Lines}
+	}
+	order := song.VerseOrder
+	if strings.TrimSpace(verseOrder) != "" {
+		if order, err = validateVerseOrder(verseOrder, verses); err != nil {
+			return nil, err
+		}
+	}
+
+	number := strings.TrimSpace(song.SongbookAcronym + " " + song.EntryText)
+	slides := []presentationSlide{titleSlide(song.Title, number, songCredits(song.Authors))}
+	for _, verse := range arrangeVerses(verses, order) {
+		slides = append(slides, textSlide(verse.Lines))
+	}
+	return slides, nil
+}
+
+// serviceItemSlides returns the slides of a non-song service item: a title
+// slide followed by one slide per paragraph of its content
+func serviceItemSlides(item dtoServiceItem) []presentationSlide {
+	title := item.Title
+	if title == "" {
+		title = serviceItemLabels[item.ItemType]
+	}
+	slides := []presentationSlide{titleSlide(title, item.ScriptureRef)}
+	for _, paragraph := range splitParagraphs(item.Content) {
+		slides = append(slides, textSlide(paragraph))
+	}
+	return slides
+}
+
+// renderPresentation writes the slides in the requested format and returns a data URL
+func renderPresentation(slides []presentationSlide, options dtoPresentationOptions) (string, error) {
+	var data []byte
+	var err error
+	switch options.Format {
+	case presentationFormatOdp:
+		data, err = writeOdp(slides, options)
+	default:
+		data, err = writePptx(slides, options)
+	}
+	if err != nil {
+		return "", err
+	}
+	return "data:" + presentationMimeTypes[options.Format] + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// ExportSongsPresentation returns the given songs as a PPTX or ODP deck data
+// URL: a title slide with songbook number and authors for each song followed
+// by one slide per verse in the song's verse order
+func (a *App) ExportSongsPresentation(songIds []int, options dtoPresentationOptions) (string, error) {
+	options, err := normalizePresentationOptions(options)
+	if err != nil {
+		return "", err
+	}
+	if len(songIds) == 0 {
+		return "", fmt.Errorf("no songs to export")
+	}
+
+	var slides []presentationSlide
+	err = a.withDB(func(db *sql.DB) error {
+		for _, id := range songIds {
+			songSlides, err := a.songSlides(db, id, "")
+			if err != nil {
+				return err
+			}
+			slides = append(slides, songSlides...)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Presentation export failed: %s", err))
+		return "", err
+	}
+	return renderPresentation(slides, options)
+}
+
+// ExportServicePresentation returns a service plan as a PPTX or ODP deck data
+// URL. Songs use the verse selection of their service item, other items get a
+// title slide and one slide per paragraph. Songs no longer in the database
+// are left out.
+func (a *App) ExportServicePresentation(serviceId int, options dtoPresentationOptions) (string, error) {
+	options, err := normalizePresentationOptions(options)
+	if err != nil {
+		return "", err
+	}
+
+	var svc dtoService
+	var slides []presentationSlide
+	err = a.withDB(func(db *sql.DB) error {
+		var err error
+		if svc, err = a.loadService(db, serviceId); err != nil {
+			return err
+		}
+		heading := svc.Title
+		if heading == "" {
+			heading = "Bohoslužba"
+		}
+		slides = append(slides, titleSlide(heading, svc.ServiceDate))
+		for _, item := range svc.Items {
+			if item.ItemType != SongItem {
+				slides = append(slides, serviceItemSlides(item)...)
+				continue
+			}
+			if item.Missing {
+				slog.Warn("Skipping missing song in presentation export", "song", item.SongbookAcronym+" "+item.EntryText)
+				continue
+			}
+			songSlides, err := a.songSlides(db, item.SongId, item.VerseOrder)
+			if err != nil {
+				return err
+			}
+			slides = append(slides, songSlides...)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Presentation export of service %d failed: %s", serviceId, err))
+		return "", err
+	}
+
+	return renderPresentation(slides, options)
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNormalizePresentationOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options dtoPresentationOptions
+		want    dtoPresentationOptions
+		wantErr bool
+	}{
+		{
+			name:    "defaults",
+			options: dtoPresentationOptions{},
+			want:    dtoPresentationOptions{Format: "pptx", FontFamily: "Arial", FontSize: 40, TextColor: "FFFFFF", BackgroundColor: "000000"},
+		},
+		{
+			name:    "custom",
+			options: dtoPresentationOptions{Format: " ODP ", FontFamily: "DejaVu Sans", FontSize: 32, TextColor: "#ffee00", BackgroundColor: "102030"},
+			want:    dtoPresentationOptions{Format: "odp", FontFamily: "DejaVu Sans", FontSize: 32, TextColor: "FFEE00", BackgroundColor: "102030"},
+		},
+		{name: "unknown format", options: dtoPresentationOptions{Format: "key"}, wantErr: true},
+		{name: "invalid color", options: dtoPresentationOptions{TextColor: "white"}, wantErr: true},
+		{name: "huge font", options: dtoPresentationOptions{FontSize: 500}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizePresentationOptions(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// readPresentation unzips a deck, checks that every XML part is well-formed
+// and returns the parts by name with the entry names in archive order
+func readPresentation(t *testing.T, data []byte) (map[string]string, []*zip.File) {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range reader.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+		if strings.HasSuffix(f.Name, ".xml") || strings.HasSuffix(f.Name, ".rels") {
+			decoder := xml.NewDecoder(bytes.NewReader(content))
+			for {
+				if _, err := decoder.Token(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("%s is not well-formed: %v", f.Name, err)
+				}
+			}
+		}
+	}
+	return parts, reader.File
+}
+
+func TestExportSongsPresentation_Pptx(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := importOpenLyricsSample(t, app)
+
+	dataURL, err := app.ExportSongsPresentation([]int{songID}, dtoPresentationOptions{FontFamily: "DejaVu Sans", BackgroundColor: "#203040"})
+	if err != nil {
+		t.Fatalf("ExportSongsPresentation: %v", err)
+	}
+	parts, _ := readPresentation(t, decodeDataURL(t, dataURL, "data:"+presentationMimeTypes["pptx"]+";base64,"))
+
+	// title slide followed by the verse order v1 c v1
+	for _, name := range []string{"[Content_Types].xml", "ppt/presentation.xml", "ppt/slides/slide4.xml", "ppt/slides/_rels/slide4.xml.rels"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+	if _, ok := parts["ppt/slides/slide5.xml"]; ok {
+		t.Error("unexpected fifth slide")
+	}
+	if got := strings.Count(parts["ppt/presentation.xml"], "<p:sldId "); got != 4 {
+		t.Errorf("presentation lists %d slides, want 4", got)
+	}
+
+	title := parts["ppt/slides/slide1.xml"]
+	for _, want := range []string{"Pán je můj pastýř", "EZ 23a", "Text: Francis Rous | Hudba: Jessie Seymour Irvine", `sz="4800" b="1"`} {
+		if !strings.Contains(title, want) {
+			t.Errorf("title slide misses %q", want)
+		}
+	}
+	verse := parts["ppt/slides/slide2.xml"]
+	for _, want := range []string{"<a:t>nic mi nechybí &amp; nic</a:t>", `typeface="DejaVu Sans"`, `<a:srgbClr val="203040"/>`, `sz="4000"`} {
+		if !strings.Contains(verse, want) {
+			t.Errorf("verse slide misses %q", want)
+		}
+	}
+	if parts["ppt/slides/slide2.xml"] != parts["ppt/slides/slide4.xml"] {
+		t.Error("repeated verse v1 should produce identical slides")
+	}
+	if !strings.Contains(parts["ppt/slides/slide3.xml"], "Goodness and mercy") {
+		t.Error("third slide should show the chorus")
+	}
+}
+
+func TestExportServicePresentation_Odp(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := importOpenLyricsSample(t, app)
+
+	serviceID, err := app.SaveService(dtoService{
+		ServiceDate: "2025-11-30",
+		Title:       "1. neděle adventní",
+		Items: []dtoServiceItem{
+			{ItemType: SongItem, SongId: songID, VerseOrder: "c"},
+			{ItemType: ReadingItem, ScriptureRef: "Ž 23", Content: "Hospodin je můj pastýř.\n\nNic mi nebude scházet."},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SaveService: %v", err)
+	}
+
+	dataURL, err := app.ExportServicePresentation(serviceID, dtoPresentationOptions{Format: "odp", TextColor: "#FFFF00"})
+	if err != nil {
+		t.Fatalf("ExportServicePresentation: %v", err)
+	}
+	parts, files := readPresentation(t, decodeDataURL(t, dataURL, "data:"+presentationMimeTypes["odp"]+";base64,"))
+
+	if files[0].Name != "mimetype" || files[0].Method != zip.Store || parts["mimetype"] != presentationMimeTypes["odp"] {
+		t.Errorf("mimetype must be the first stored entry, got %s (method %d)", files[0].Name, files[0].Method)
+	}
+	content := parts["content.xml"]
+	// service title, song title, chorus, reading title and two paragraphs
+	if got := strings.Count(content, "<draw:page "); got != 6 {
+		t.Errorf("got %d pages, want 6", got)
+	}
+	for _, want := range []string{"1. neděle adventní", "Goodness and mercy", "Čtení", "Ž 23", "Nic mi nebude scházet.", `fo:color="#FFFF00"`} {
+		if !strings.Contains(content, want) {
+			t.Errorf("content.xml misses %q", want)
+		}
+	}
+	if strings.Contains(content, "nic mi nechybí") {
+		t.Error("verse selection of the service item should only show the chorus")
+	}
+
+	// decks are made ahead of the service, the projection records the usage
+	usage, err := app.GetSongLastUsed(songID)
+	if err != nil || usage.UseCount != 0 {
+		t.Errorf("export should not record song usage, got %+v, %v", usage, err)
+	}
+
+	// a song gone from the database is left out of the deck
+	err = app.withDB(func(db *sql.DB) error {
+		_, err := db.Exec(`DELETE FROM songs WHERE id = ?`, songID)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataURL, err = app.ExportServicePresentation(serviceID, dtoPresentationOptions{Format: "odp"})
+	if err != nil {
+		t.Fatalf("ExportServicePresentation with a missing song: %v", err)
+	}
+	parts, _ = readPresentation(t, decodeDataURL(t, dataURL, "data:"+presentationMimeTypes["odp"]+";base64,"))
+	if got := strings.Count(parts["content.xml"], "<draw:page "); got != 4 {
+		t.Errorf("got %d pages without the missing song, want 4", got)
+	}
+}
+
+func TestExportSongsPresentation_Errors(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+
+	if _, err := app.ExportSongsPresentation(nil, dtoPresentationOptions{}); err == nil {
+		t.Error("expected error for empty song list")
+	}
+	if _, err := app.ExportSongsPresentation([]int{999}, dtoPresentationOptions{}); err == nil {
+		t.Error("expected error for unknown song")
+	}
+	if _, err := app.ExportServicePresentation(999, dtoPresentationOptions{}); err == nil {
+		t.Error("expected error for unknown service")
+	}
+}
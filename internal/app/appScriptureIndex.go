@@ -0,0 +1,251 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// scriptureIndexFile is the editable index in the app directory. It maps
+// songbook acronym -> entry -> list of references, e.g.
+//
+//	EZ:
+//	  "23":
+//	  - Ž 23
+//	  - J 10,11-16
+const scriptureIndexFile = "scripture_index.yaml"
+
+type scriptureIndex map[string]map[string][]string
+
+// Sources of song passage links
+const (
+	scriptureRefManual   = "manual"
+	scriptureRefImported = "index"
+)
+
+// insertScriptureRefs stores all passages of a reference string for a song.
+// Passages the song is already linked to are skipped.
+func insertScriptureRefs(tx *sql.Tx, acronym string, entryText string, reference string, source string) error {
+	refs, err := parseScriptureRefs(reference)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		_, err := tx.Exec(`
+			INSERT INTO song_scripture_refs (songbook_acronym, entry_text, book, start_pos, end_pos, reference, source)
+			SELECT ?1, ?2, ?3, ?4, ?5, ?6, ?7
+			WHERE NOT EXISTS (SELECT 1 FROM song_scripture_refs WHERE songbook_acronym = ?1 AND entry_text = ?2 AND reference = ?6)`,
+			acronym, entryText, ref.Book, ref.startPos(), ref.endPos(), ref.String(), source)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddSongScriptureRef links a song to one or more passages ("Ž 23; J 10,11")
+func (a *App) AddSongScriptureRef(songId int, reference string) error {
+	return a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := insertScriptureRefs(tx, acronym, entryText, reference, scriptureRefManual); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// RemoveSongScriptureRef deletes a single song passage link
+func (a *App) RemoveSongScriptureRef(refId int) error {
+	return a.withDB(func(db *sql.DB) error {
+		_, err := db.Exec(`DELETE FROM song_scripture_refs WHERE id = ?`, refId)
+		return err
+	})
+}
+
+// GetSongScriptureRefs lists the passages linked to a song in biblical order
+func (a *App) GetSongScriptureRefs(songId int) ([]dtoScriptureRef, error) {
+	result := []dtoScriptureRef{}
+	err := a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		rows, err := db.Query(`
+			SELECT id, reference, book
+			FROM song_scripture_refs
+			WHERE songbook_acronym = ? AND entry_text = ?
+			ORDER BY start_pos, id`, acronym, entryText)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error querying scripture references: %s", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var r dtoScriptureRef
+			if err := rows.Scan(&r.Id, &r.Reference, &r.Book); err != nil {
+				return err
+			}
+			if book, ok := lookupBibleBook(r.Book); ok {
+				r.BookName = book.Name
+			}
+			result = append(result, r)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		sortScriptureRefs(result)
+		return nil
+	})
+	return result, err
+}
+
+// sortScriptureRefs orders references by the canonical order of their books
+func sortScriptureRefs(refs []dtoScriptureRef) {
+	sort.SliceStable(refs, func(i, j int) bool {
+		return bibleBookIndex[bibleBookKey(refs[i].Book)] < bibleBookIndex[bibleBookKey(refs[j].Book)]
+	})
+}
+
+// FindSongsForScripture returns songs linked to passages overlapping the
+// reference, e.g. "Žalm 23" or "J 3,16"
+func (a *App) FindSongsForScripture(reference string) ([]dtoScriptureMatch, error) {
+	refs, err := parseScriptureRefs(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	var passages []string
+	var args []interface{}
+	for _, ref := range refs {
+		passages = append(passages, "(sr.book = ? AND sr.start_pos <= ? AND sr.end_pos >= ?)")
+		args = append(args, ref.Book, ref.endPos(), ref.startPos())
+	}
+
+	result := []dtoScriptureMatch{}
+	err = a.withDB(func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT s.id, s.entry, `+songEntryKeyExpr+`, COALESCE(s.title, ''), COALESCE(s.songbook_acronym, ''),
+			       GROUP_CONCAT(sr.reference, '; ')
+			FROM song_scripture_refs sr
+			JOIN songs s ON COALESCE(s.songbook_acronym, '') = sr.songbook_acronym
+			            AND `+songEntryKeyExpr+` = sr.entry_text
+			WHERE `+strings.Join(passages, " OR ")+`
+			GROUP BY s.id
+			ORDER BY s.songbook_acronym, s.entry`, args...)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error querying songs for scripture: %s", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var m dtoScriptureMatch
+			if err := rows.Scan(&m.Id, &m.Entry, &m.EntryText, &m.Title, &m.SongbookAcronym, &m.Reference); err != nil {
+				slog.Error(fmt.Sprintf("Error scanning row: %s", err))
+				return err
+			}
+			result = append(result, m)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
+
+// ImportScriptureIndex replaces the song passage links imported before with
+// the content of the index file and returns the number of stored passages.
+// Links added in the app are kept. An invalid reference aborts the import and
+// keeps the previous links.
+func (a *App) ImportScriptureIndex() (int, error) {
+	data, err := os.ReadFile(filepath.Join(a.appDir, scriptureIndexFile))
+	if err != nil {
+		return 0, err
+	}
+	var index scriptureIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", scriptureIndexFile, err)
+	}
+
+	count := 0
+	err = a.withDB(func(db *sql.DB) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec(`DELETE FROM song_scripture_refs WHERE source = ?`, scriptureRefImported); err != nil {
+			return err
+		}
+		for acronym, entries := range index {
+			for entryText, references := range entries {
+				for _, reference := range references {
+					if err := insertScriptureRefs(tx, acronym, entryText, reference, scriptureRefImported); err != nil {
+						return fmt.Errorf("%s %s: %w", acronym, entryText, err)
+					}
+				}
+			}
+		}
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM song_scripture_refs`).Scan(&count); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	return count, err
+}
+
+// ExportScriptureIndex writes the current passage links to the index file
+// so they can be edited, and returns the file path
+func (a *App) ExportScriptureIndex() (string, error) {
+	index := scriptureIndex{}
+	err := a.withDB(func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT songbook_acronym, entry_text, reference
+			FROM song_scripture_refs
+			ORDER BY songbook_acronym, entry_text, id`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var acronym, entryText, reference string
+			if err := rows.Scan(&acronym, &entryText, &reference); err != nil {
+				return err
+			}
+			if index[acronym] == nil {
+				index[acronym] = map[string][]string{}
+			}
+			index[acronym][entryText] = append(index[acronym][entryText], reference)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return "", err
+	}
+
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return "", err
+	}
+	indexPath := filepath.Join(a.appDir, scriptureIndexFile)
+	if err := os.WriteFile(indexPath, data, 0644); err != nil {
+		slog.Error(fmt.Sprintf("Error writing to file: %s", err))
+		return "", err
+	}
+	return indexPath, nil
+}
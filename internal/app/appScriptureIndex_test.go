@@ -0,0 +1,164 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSongScriptureRefs_AddListRemove(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertUsageSongs(t, app)
+
+	if err := app.AddSongScriptureRef(ids[0], "J 10,11; Ž 23"); err != nil {
+		t.Fatalf("AddSongScriptureRef: %v", err)
+	}
+	if err := app.AddSongScriptureRef(ids[0], "Qq 1"); err == nil {
+		t.Error("expected error for unknown book")
+	}
+
+	refs, err := app.GetSongScriptureRefs(ids[0])
+	if err != nil {
+		t.Fatalf("GetSongScriptureRefs: %v", err)
+	}
+	if len(refs) != 2 || refs[0].Reference != "Ž 23" || refs[0].BookName != "Žalmy" || refs[1].Reference != "J 10,11" {
+		t.Fatalf("unexpected refs: %+v", refs)
+	}
+
+	if err := app.RemoveSongScriptureRef(refs[0].Id); err != nil {
+		t.Fatalf("RemoveSongScriptureRef: %v", err)
+	}
+	refs, _ = app.GetSongScriptureRefs(ids[0])
+	if len(refs) != 1 {
+		t.Errorf("expected 1 ref after removal, got %+v", refs)
+	}
+}
+
+func TestFindSongsForScripture(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertUsageSongs(t, app)
+
+	_ = app.AddSongScriptureRef(ids[0], "Ž 23")
+	_ = app.AddSongScriptureRef(ids[1], "J 3,14-21")
+	_ = app.AddSongScriptureRef(ids[2], "J 3,1-8")
+	_ = app.AddSongScriptureRef(ids[3], "Ž 23,1-4")
+
+	tests := []struct {
+		name      string
+		reference string
+		wantIDs   []int
+	}{
+		{name: "whole psalm", reference: "Žalm 23", wantIDs: []int{ids[0], ids[3]}},
+		{name: "single verse inside psalm", reference: "Ž 23,6", wantIDs: []int{ids[0]}},
+		{name: "verse", reference: "J 3,16", wantIDs: []int{ids[1]}},
+		{name: "whole chapter", reference: "J 3", wantIDs: []int{ids[1], ids[2]}},
+		{name: "no match", reference: "J 4", wantIDs: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := app.FindSongsForScripture(tt.reference)
+			if err != nil {
+				t.Fatalf("FindSongsForScripture: %v", err)
+			}
+			if len(matches) != len(tt.wantIDs) {
+				t.Fatalf("got %+v, want ids %v", matches, tt.wantIDs)
+			}
+			for i, id := range tt.wantIDs {
+				if matches[i].Id != id {
+					t.Errorf("match %d = %d, want %d", i, matches[i].Id, id)
+				}
+			}
+		})
+	}
+
+	if _, err := app.FindSongsForScripture("nonsense"); err == nil {
+		t.Error("expected error for invalid reference")
+	}
+
+	searches := []struct {
+		name    string
+		pattern string
+		wantIDs []int
+	}{
+		{name: "reference", pattern: "ref:J 3,16", wantIDs: []int{ids[1]}},
+		{name: "upper case prefix", pattern: "REF:J 3,16", wantIDs: []int{ids[1]}},
+		{name: "space after prefix", pattern: "ref: J 3,16", wantIDs: []int{ids[1]}},
+		{name: "text before reference", pattern: "blaze ref:J 3", wantIDs: []int{ids[1]}},
+		// lower casing "İ" changes its length in bytes
+		{name: "text changing length when lower cased", pattern: "İ ref:J 3,16", wantIDs: []int{ids[1]}},
+		{name: "prefix inside a word", pattern: "xref:J 3", wantIDs: nil},
+	}
+	for _, tt := range searches {
+		t.Run(tt.name, func(t *testing.T) {
+			songs, err := app.GetSongs("entry", tt.pattern, "")
+			if err != nil {
+				t.Fatalf("GetSongs: %v", err)
+			}
+			var got []int
+			for _, song := range songs {
+				got = append(got, song.Id)
+			}
+			if !reflect.DeepEqual(got, tt.wantIDs) {
+				t.Errorf("GetSongs(%q) = %v, want %v", tt.pattern, got, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestScriptureIndexFile_ExportImport(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	app.appDir = t.TempDir()
+	ids := insertUsageSongs(t, app)
+
+	_ = app.AddSongScriptureRef(ids[0], "Ž 1")
+	indexPath, err := app.ExportScriptureIndex()
+	if err != nil {
+		t.Fatalf("ExportScriptureIndex: %v", err)
+	}
+	data, _ := os.ReadFile(indexPath)
+	if !strings.Contains(string(data), "Ž 1") {
+		t.Errorf("exported index misses reference: %s", data)
+	}
+
+	edited := "EZ:\n  \"1\":\n  - Ž 1\n  \"2\":\n  - Ž 32; Ř 4,7-8\nKK:\n  \"1\": [\"Žd 13,10\"]\n"
+	if err := os.WriteFile(filepath.Join(app.appDir, scriptureIndexFile), []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// links added in the app after the export survive the import
+	_ = app.AddSongScriptureRef(ids[2], "Ž 100")
+	count, err := app.ImportScriptureIndex()
+	if err != nil {
+		t.Fatalf("ImportScriptureIndex: %v", err)
+	}
+	if count != 5 {
+		t.Errorf("expected 5 passages, got %d", count)
+	}
+	refs, _ := app.GetSongScriptureRefs(ids[1])
+	if len(refs) != 2 || refs[0].Reference != "Ž 32" {
+		t.Errorf("unexpected imported refs: %+v", refs)
+	}
+	refs, _ = app.GetSongScriptureRefs(ids[2])
+	if len(refs) != 1 || refs[0].Reference != "Ž 100" {
+		t.Errorf("manual refs should be kept, got %+v", refs)
+	}
+	// importing again replaces the imported passages instead of adding them twice
+	if count, err := app.ImportScriptureIndex(); err != nil || count != 5 {
+		t.Errorf("expected 5 passages after a second import, got %d %v", count, err)
+	}
+
+	// an invalid line keeps the previous index
+	broken := "EZ:\n  \"3\":\n  - Xyz 1\n"
+	_ = os.WriteFile(filepath.Join(app.appDir, scriptureIndexFile), []byte(broken), 0644)
+	if _, err := app.ImportScriptureIndex(); err == nil {
+		t.Fatal("expected error for invalid reference")
+	}
+	refs, _ = app.GetSongScriptureRefs(ids[3])
+	if len(refs) != 1 || refs[0].Reference != "Žd 13,10" {
+		t.Errorf("failed import must keep previous refs, got %+v", refs)
+	}
+}
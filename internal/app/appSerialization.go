@@ -3,6 +3,7 @@ package app
 import (
 	"encoding/xml"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -103,26 +104,130 @@ func parseXmlSong(xmlFilePath string) (*Song, error) {
 		return nil, err
 	}
 
-	// Only collapse multiple spaces/tabs, but preserve newlines for verse formatting.
-	reWhiteSpaces := regexp.MustCompile(`[ \t]{2,}`)
-	reToRemove := regexp.MustCompile(`<lines>|</lines>`)
+	if len(song.Titles) > 0 {
+		song.Title = strings.TrimSpace(song.Titles[0].Value)
+	}
+
 	for i, verse := range song.Lyrics.Verses {
-		// Trim leading and trailing whitespace from each line
-		trimmed := strings.TrimSpace(verse.Lines)
+		lines, chords, comment, err := parseVerseMarkup(verse.Lines)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error parsing verse %s in file %s: %v\n", verse.Name, xmlFilePath, err))
+			return nil, err
+		}
+		song.Lyrics.Verses[i].Lines = lines
+		song.Lyrics.Verses[i].Chords = chords
+		song.Lyrics.Verses[i].Comment = comment
+	}
+	return &song, nil
+}
+
+// parseVerseMarkup converts the inner XML of an OpenLyrics verse to plain
+// lines. Line breaks come from <br/> and from consecutive <lines> elements,
+// other whitespace is collapsed. Chords are returned as a copy of the lines
+// with inline [chord] markers (empty when the verse has none) and the text of
+// <comment> elements is returned separately.
+func parseVerseMarkup(inner string) (string, string, string, error) {
+	decoder := xml.NewDecoder(strings.NewReader("<verse>" + inner + "</verse>"))
+	reWhiteSpaces := regexp.MustCompile(`[ \t\r\n]+`)
+
+	var plain, chords, comment strings.Builder
+	var comments []string
+	hasChords, inComment, linesCount := false, false, 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", "", "", err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "lines":
+				if linesCount > 0 {
+					plain.WriteString("\n")
+					chords.WriteString("\n")
+				}
+				linesCount++
+			case "br":
+				plain.WriteString("\n")
+				chords.WriteString("\n")
+			case "chord":
+				if name := chordName(t); name != "" {
+					chords.WriteString("[" + name + "]")
+					hasChords = true
+				}
+			case "comment":
+				inComment = true
+			}
+		case xml.EndElement:
+			if t.Name.Local == "comment" {
+				if text := strings.TrimSpace(comment.String()); text != "" {
+					comments = append(comments, text)
+				}
+				comment.Reset()
+				inComment = false
+			}
+		case xml.CharData:
+			text := reWhiteSpaces.ReplaceAllString(string(t), " ")
+			if inComment {
+				comment.WriteString(text)
+			} else {
+				plain.WriteString(text)
+				chords.WriteString(text)
+			}
+		}
+	}
 
-		// Replace <br /> and <br/> with actual newlines to preserve line breaks
-		trimmed = strings.ReplaceAll(trimmed, "<br />", "\n")
-		trimmed = strings.ReplaceAll(trimmed, "<br/>", "\n")
+	if !hasChords {
+		return trimVerseLines(plain.String()), "", strings.Join(comments, "\n"), nil
+	}
+	return trimVerseLines(plain.String()), trimVerseLines(chords.String()), strings.Join(comments, "\n"), nil
+}
 
-		// Remove the lines tags
-		trimmed = reToRemove.ReplaceAllString(trimmed, "")
+// chordName returns the chord of an OpenLyrics <chord> element. Version 0.8
+// uses the name attribute, 0.9 root, structure and bass.
+func chordName(element xml.StartElement) string {
+	var name, root, structure, bass string
+	for _, attr := range element.Attr {
+		switch attr.Name.Local {
+		case "name":
+			name = attr.Value
+		case "root":
+			root = attr.Value
+		case "structure":
+			structure = attr.Value
+		case "bass":
+			bass = attr.Value
+		}
+	}
+	if name != "" || root == "" {
+		return name
+	}
+	name = root + structure
+	if bass != "" {
+		name += "/" + bass
+	}
+	return name
+}
 
-		// Replace multiple spaces/tabs with a single space (newlines are preserved)
-		trimmed = reWhiteSpaces.ReplaceAllString(trimmed, " ")
+// String returns the tempo as stored in the database, e.g. "90 bpm" or "pomalu"
+func (t Tempo) String() string {
+	value := strings.TrimSpace(t.Value)
+	if value != "" && t.Type == "bpm" {
+		return value + " bpm"
+	}
+	return value
+}
 
-		song.Lyrics.Verses[i].Lines = trimmed
+// trimVerseLines trims spaces around every line and the whole verse
+func trimVerseLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
 	}
-	return &song, nil
+	return strings.TrimSpace(strings.Join(lines, "\n"))
 }
 
 func parseXmlSongKK(xmlFilePath string) (*SongKK, error) {
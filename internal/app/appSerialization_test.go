@@ -260,3 +260,77 @@ func countNewlines(s string) int {
 	}
 	return count
 }
+
+func TestParseVerseMarkup(t *testing.T) {
+	tests := []struct {
+		name        string
+		inner       string
+		wantLines   string
+		wantChords  string
+		wantComment string
+	}{
+		{
+			name:      "line breaks and wrapped source lines",
+			inner:     "<lines>první\n      řádek<br />druhý  řádek</lines>",
+			wantLines: "první řádek\ndruhý řádek",
+		},
+		{
+			name:       "chords of both OpenLyrics versions",
+			inner:      `<lines><chord name="Em"/>Ať <chord root="A" structure="7" bass="C#"/>zní</lines>`,
+			wantLines:  "Ať zní",
+			wantChords: "[Em]Ať [A7/C#]zní",
+		},
+		{
+			name:        "comments are separated and tags keep their text",
+			inner:       `<lines><comment>ženy</comment>Sláva <tag name="b">Bohu</tag> &amp; čest<comment>opakovat</comment></lines>`,
+			wantLines:   "Sláva Bohu & čest",
+			wantComment: "ženy\nopakovat",
+		},
+		{
+			name:      "several lines elements",
+			inner:     "<lines>jedna</lines><lines part=\"men\">dva</lines>",
+			wantLines: "jedna\ndva",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, chords, comment, err := parseVerseMarkup(tt.inner)
+			if err != nil {
+				t.Fatalf("parseVerseMarkup() error = %v", err)
+			}
+			if lines != tt.wantLines || chords != tt.wantChords || comment != tt.wantComment {
+				t.Errorf("parseVerseMarkup() = %q, %q, %q; want %q, %q, %q",
+					lines, chords, comment, tt.wantLines, tt.wantChords, tt.wantComment)
+			}
+		})
+	}
+}
+
+func TestParseXmlSongOpenLyrics09(t *testing.T) {
+	song, err := parseXmlSong(filepath.Join("testdata", "song-openlyrics-09.xml"))
+	if err != nil {
+		t.Fatalf("parseXmlSong() error = %v", err)
+	}
+	if song.Title != "Pán je můj pastýř" || len(song.Titles) != 2 || !song.Titles[1].Original || song.Titles[1].Lang != "en" {
+		t.Errorf("unexpected titles: %q %+v", song.Title, song.Titles)
+	}
+	if song.Copyright != "Public Domain" || song.CcliNo != "4591236" || song.Key != "D" || song.Tempo.String() != "90 bpm" {
+		t.Errorf("unexpected metadata: %q %q %q %q", song.Copyright, song.CcliNo, song.Key, song.Tempo.String())
+	}
+	if len(song.Themes) != 2 || song.Themes[0].Value != "Důvěra" || song.Themes[1].Lang != "en" {
+		t.Errorf("unexpected themes: %+v", song.Themes)
+	}
+	if len(song.Lyrics.Verses) != 2 {
+		t.Fatalf("expected 2 verses, got %d", len(song.Lyrics.Verses))
+	}
+	v1 := song.Lyrics.Verses[0]
+	if v1.Lines != "Pán je můj pastýř,\nnic mi nechybí & nic\nna zelených pastvách" || v1.Comment != "pomalu" || v1.Lang != "cs" {
+		t.Errorf("unexpected verse v1: %+v", v1)
+	}
+	if v1.Chords != "[D]Pán je můj [G]pastýř,\n[A/C#]nic mi nechybí & nic\nna zelených pastvách" {
+		t.Errorf("unexpected chords of v1: %q", v1.Chords)
+	}
+	if c := song.Lyrics.Verses[1]; c.Lines != "Goodness and mercy" || c.Lang != "en" || c.Translit != "la" || c.Chords != "[Em7]Goodness and mercy" {
+		t.Errorf("unexpected verse c: %+v", c)
+	}
+}
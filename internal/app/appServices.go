@@ -0,0 +1,448 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// serviceDateLayout is the storage format of services.service_date
+const serviceDateLayout = "2006-01-02"
+
+// normalizeServiceDate validates a service date and returns it in YYYY-MM-DD form
+func normalizeServiceDate(raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return "", fmt.Errorf("service date is required")
+	}
+	parsed, err := time.Parse(serviceDateLayout, trimmed)
+	if err != nil {
+		return "", fmt.Errorf("invalid service date %q, expected YYYY-MM-DD", raw)
+	}
+	return parsed.Format(serviceDateLayout), nil
+}
+
+// GetServices lists all stored service plans, newest first, without their items
+func (a *App) GetServices() ([]dtoService, error) {
+	result := []dtoService{}
+	err := a.withDB(func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT s.id, s.service_date, s.title, s.notes,
+			       (SELECT COUNT(*) FROM service_items i WHERE i.service_id = s.id) AS item_count
+			FROM services s
+			ORDER BY s.service_date DESC, s.id DESC`)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error querying services: %s", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var svc dtoService
+			if err := rows.Scan(&svc.Id, &svc.ServiceDate, &svc.Title, &svc.Notes, &svc.ItemCount); err != nil {
+				slog.Error(fmt.Sprintf("Error scanning service row: %s", err))
+				return err
+			}
+			result = append(result, svc)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
+
+// GetService returns a service plan including its ordered items
+func (a *App) GetService(serviceId int) (dtoService, error) {
+	var result dtoService
+	err := a.withDB(func(db *sql.DB) error {
+		var err error
+		result, err = a.loadService(db, serviceId)
+		return err
+	})
+	return result, err
+}
+
+func (a *App) loadService(db *sql.DB, serviceID int) (dtoService, error) {
+	var svc dtoService
+	err := db.QueryRow(`SELECT id, service_date, title, notes FROM services WHERE id = ?`, serviceID).
+		Scan(&svc.Id, &svc.ServiceDate, &svc.Title, &svc.Notes)
+	if err == sql.ErrNoRows {
+		return svc, fmt.Errorf("service %d not found", serviceID)
+	}
+	if err != nil {
+		return svc, err
+	}
+
+	svc.Items, err = a.loadServiceItems(db, serviceID)
+	svc.ItemCount = len(svc.Items)
+	return svc, err
+}
+
+// loadServiceItems reads the items of a service and resolves the song keys of
+// song items. Songs that no longer exist leave the item marked as missing.
+func (a *App) loadServiceItems(db *sql.DB, serviceID int) ([]dtoServiceItem, error) {
+	// With MIN() SQLite takes the other song columns from the same row
+	rows, err := db.Query(`
+		SELECT i.id, i.position, i.item_type, COALESCE(MIN(s.id), 0), i.verse_order, i.notes,
+		       CASE WHEN i.item_type = 'song' THEN COALESCE(s.title, '') ELSE i.title END,
+		       i.content, i.scripture_ref,
+		       COALESCE(s.entry, 0), i.songbook_acronym, i.entry_text
+		FROM service_items i
+		LEFT JOIN songs s ON i.item_type = 'song' AND COALESCE(s.songbook_acronym, '') = i.songbook_acronym
+		                 AND `+songEntryKeyExpr+` = i.entry_text
+		WHERE i.service_id = ?
+		GROUP BY i.id
+		ORDER BY i.position, i.id`, serviceID)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error querying service items: %s", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []dtoServiceItem{}
+	for rows.Next() {
+		var item dtoServiceItem
+		if err := rows.Scan(&item.Id, &item.Position, &item.ItemType, &item.SongId, &item.VerseOrder, &item.Notes,
+			&item.Title, &item.Content, &item.ScriptureRef, &item.Entry, &item.SongbookAcronym, &item.EntryText); err != nil {
+			slog.Error(fmt.Sprintf("Error scanning service item row: %s", err))
+			return nil, err
+		}
+		item.Missing = item.ItemType == SongItem && item.SongId == 0
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// SaveService creates a service plan (Id == 0) or replaces an existing one,
+// including its items, and returns the service id. Items are stored in the
+// order given; verse selections are validated against the song's verses.
+func (a *App) SaveService(service dtoService) (int, error) {
+	serviceDate, err := normalizeServiceDate(service.ServiceDate)
+	if err != nil {
+		return 0, err
+	}
+
+	var serviceID int
+	err = a.withDB(func(db *sql.DB) error {
+		for i, item := range service.Items {
+			normalized, err := a.validateServiceItem(db, item)
+			if err != nil {
+				return fmt.Errorf("item %d: %w", i+1, err)
+			}
+			service.Items[i] = normalized
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if service.Id == 0 {
+			r, err := tx.Exec(`INSERT INTO services (service_date, title, notes) VALUES (?, ?, ?)`,
+				serviceDate, strings.TrimSpace(service.Title), service.Notes)
+			if err != nil {
+				return err
+			}
+			id, err := r.LastInsertId()
+			if err != nil {
+				return err
+			}
+			serviceID = int(id)
+		} else {
+			r, err := tx.Exec(`UPDATE services SET service_date = ?, title = ?, notes = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+				serviceDate, strings.TrimSpace(service.Title), service.Notes, service.Id)
+			if err != nil {
+				return err
+			}
+			if n, _ := r.RowsAffected(); n == 0 {
+				return fmt.Errorf("service %d not found", service.Id)
+			}
+			serviceID = service.Id
+		}
+
+		if err := insertServiceItems(tx, serviceID, service.Items); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return serviceID, nil
+}
+
+// normalizeServiceItemType maps an empty item type to a song item and rejects unknown types
+func normalizeServiceItemType(itemType ServiceItemType) (ServiceItemType, error) {
+	switch ServiceItemType(strings.TrimSpace(string(itemType))) {
+	case "", SongItem:
+		return SongItem, nil
+	case ReadingItem, AnnouncementItem, PrayerItem, LiturgyItem:
+		return ServiceItemType(strings.TrimSpace(string(itemType))), nil
+	default:
+		return "", fmt.Errorf("unknown service item type %q", itemType)
+	}
+}
+
+// validateServiceItem checks that song items reference an existing song with a
+// valid verse selection and that other items carry some text to show. Song
+// items get the key of their song; an item whose song is missing keeps its
+// key, so the plan can still be saved.
+func (a *App) validateServiceItem(db *sql.DB, item dtoServiceItem) (dtoServiceItem, error) {
+	itemType, err := normalizeServiceItemType(item.ItemType)
+	if err != nil {
+		return item, err
+	}
+	item.ItemType = itemType
+	item.Title = strings.TrimSpace(item.Title)
+	item.ScriptureRef = strings.TrimSpace(item.ScriptureRef)
+
+	if itemType != SongItem {
+		item.SongId = 0
+		item.VerseOrder = ""
+		item.SongbookAcronym = ""
+		item.EntryText = ""
+		if itemType == ReadingItem && item.ScriptureRef == "" && strings.TrimSpace(item.Content) == "" {
+			return item, fmt.Errorf("reading needs a scripture reference or text")
+		}
+		if item.Title == "" && item.ScriptureRef == "" && strings.TrimSpace(item.Content) == "" {
+			return item, fmt.Errorf("%s item needs a title or text", itemType)
+		}
+		return item, nil
+	}
+
+	if item.SongId == 0 && item.EntryText == "" {
+		return item, fmt.Errorf("song is required")
+	}
+	if item.SongId != 0 {
+		if item.SongbookAcronym, item.EntryText, err = a.lookupSongKey(db, item.SongId); err != nil {
+			return item, err
+		}
+	}
+
+	if item.SongId != 0 && strings.TrimSpace(item.VerseOrder) != "" {
+		verses, err := a.loadSongVerses(db, item.SongId)
+		if err != nil {
+			return item, err
+		}
+		item.VerseOrder, err = validateVerseOrder(item.VerseOrder, verses)
+		if err != nil {
+			return item, err
+		}
+	}
+	// Song titles always come from the songs table
+	item.Title = ""
+	item.Content = ""
+	item.ScriptureRef = ""
+	return item, nil
+}
+
+// insertServiceItems replaces all items of a service with the given ones
+func insertServiceItems(tx *sql.Tx, serviceID int, items []dtoServiceItem) error {
+	if _, err := tx.Exec(`DELETE FROM service_items WHERE service_id = ?`, serviceID); err != nil {
+		return err
+	}
+	for i, item := range items {
+		if _, err := tx.Exec(`
+			INSERT INTO service_items (service_id, position, item_type, songbook_acronym, entry_text, verse_order, notes, title, content, scripture_ref)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			serviceID, i+1, item.ItemType, item.SongbookAcronym, item.EntryText, item.VerseOrder, item.Notes, item.Title, item.Content, item.ScriptureRef); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteService removes a service plan and its items
+func (a *App) DeleteService(serviceId int) error {
+	return a.withDB(func(db *sql.DB) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec(`DELETE FROM service_items WHERE service_id = ?`, serviceId); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM services WHERE id = ?`, serviceId); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// DuplicateService copies an existing service plan as a template for a new date
+// and returns the id of the copy.
+func (a *App) DuplicateService(serviceId int, serviceDate string) (int, error) {
+	source, err := a.GetService(serviceId)
+	if err != nil {
+		return 0, err
+	}
+
+	source.Id = 0
+	source.ServiceDate = serviceDate
+	for i := range source.Items {
+		source.Items[i].Id = 0
+	}
+	return a.SaveService(source)
+}
+
+// GetServiceItemProjection returns the projection JSON of a service item.
+// Song items apply the item's verse selection on top of the song's arrangement;
+// readings, announcements, prayers and liturgical texts are returned in the same
+// shape with one "verse" per paragraph so the projection controller can step
+// through them like a song.
+func (a *App) GetServiceItemProjection(itemId int) (string, error) {
+	var item dtoServiceItem
+	err := a.withDB(func(db *sql.DB) error {
+		err := db.QueryRow(`
+			SELECT item_type, songbook_acronym, entry_text, verse_order, title, content, scripture_ref
+			FROM service_items WHERE id = ?`, itemId).
+			Scan(&item.ItemType, &item.SongbookAcronym, &item.EntryText, &item.VerseOrder, &item.Title, &item.Content, &item.ScriptureRef)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("service item %d not found", itemId)
+		}
+		if err != nil || item.ItemType != SongItem {
+			return err
+		}
+		item.SongId, err = findSongByKey(db, item.SongbookAcronym, item.EntryText)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if item.ItemType == SongItem {
+		return a.GetSongProjectionWithOrder(item.SongId, item.VerseOrder)
+	}
+	b, err := json.Marshal(textItemProjection(item))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// findSongByKey returns the id of the song with the given songbook acronym
+// and entry text
+func findSongByKey(q sqlQueryExecer, acronym, entryText string) (int, error) {
+	var songID int
+	err := q.QueryRow(`SELECT id FROM songs s WHERE s.songbook_acronym = ? AND `+songEntryKeyExpr+` = ? ORDER BY id LIMIT 1`,
+		acronym, entryText).Scan(&songID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("song %s %s not found", acronym, entryText)
+	}
+	return songID, err
+}
+
+// textItemProjection splits the text of a non-song item into projectable paragraphs
+func textItemProjection(item dtoServiceItem) map[string]interface{} {
+	title := item.Title
+	if title == "" {
+		title = item.ScriptureRef
+	}
+
+	verses := []songVerse{}
+	for _, paragraph := range splitParagraphs(item.Content) {
+		verses = append(verses, songVerse{Name: fmt.Sprintf("p%d", len(verses)+1), Lines: paragraph})
+	}
+	if len(verses) == 0 {
+		verses = append(verses, songVerse{Name: "p1", Lines: title})
+	}
+
+	names := make([]string, len(verses))
+	for i, v := range verses {
+		names[i] = v.Name
+	}
+	return map[string]interface{}{
+		"item_type":     item.ItemType,
+		"title":         title,
+		"scripture_ref": item.ScriptureRef,
+		"verse_order":   strings.Join(names, " "),
+		"verses":        verses,
+	}
+}
+
+// splitParagraphs splits text on blank lines, trimming each paragraph
+func splitParagraphs(text string) []string {
+	normalized := strings.ReplaceAll(text, "\r\n", "\n")
+	var paragraphs []string
+	for _, part := range strings.Split(normalized, "\n\n") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			paragraphs = append(paragraphs, trimmed)
+		}
+	}
+	return paragraphs
+}
+
+// serviceItemLabels are the Czech captions used in the printed order of service
+var serviceItemLabels = map[ServiceItemType]string{
+	SongItem:         "Píseň",
+	ReadingItem:      "Čtení",
+	AnnouncementItem: "Ohlášky",
+	PrayerItem:       "Modlitba",
+	LiturgyItem:      "Liturgie",
+}
+
+// serviceOrderLines builds the printable lines of an order of service
+func serviceOrderLines(svc dtoService) []pdfTextLine {
+	heading := "Pořad bohoslužby " + svc.ServiceDate
+	lines := []pdfTextLine{{Text: heading, Bold: true, Size: 18}}
+	if svc.Title != "" {
+		lines = append(lines, pdfTextLine{Text: svc.Title, Size: 14})
+	}
+	if strings.TrimSpace(svc.Notes) != "" {
+		lines = append(lines, pdfTextLine{Text: svc.Notes, Size: 10})
+	}
+
+	for i, item := range svc.Items {
+		caption := fmt.Sprintf("%d. %s", i+1, serviceItemLabels[item.ItemType])
+		switch item.ItemType {
+		case SongItem:
+			title := item.Title
+			if item.Missing {
+				title = "píseň už ve zpěvníku není"
+			}
+			caption += fmt.Sprintf(": %s %s – %s", item.SongbookAcronym, item.EntryText, title)
+			if item.VerseOrder != "" {
+				caption += " (" + item.VerseOrder + ")"
+			}
+		case ReadingItem:
+			caption += ": " + strings.TrimSpace(item.ScriptureRef+" "+item.Title)
+		default:
+			if item.Title != "" {
+				caption += ": " + item.Title
+			}
+		}
+		lines = append(lines, pdfTextLine{Text: caption, Bold: true, Size: 12, SpaceBefore: 8})
+		if item.ItemType != SongItem && strings.TrimSpace(item.Content) != "" {
+			lines = append(lines, pdfTextLine{Text: strings.TrimSpace(item.Content), Size: 11, Indent: 15})
+		}
+		if strings.TrimSpace(item.Notes) != "" {
+			lines = append(lines, pdfTextLine{Text: item.Notes, Size: 10, Indent: 15})
+		}
+	}
+	return lines
+}
+
+// GetServiceOrderPdf renders the order of service as a PDF data URL
+func (a *App) GetServiceOrderPdf(serviceId int) (string, error) {
+	svc, err := a.GetService(serviceId)
+	if err != nil {
+		return "", err
+	}
+	c, err := renderTextPdf(serviceOrderLines(svc))
+	if err != nil {
+		return "", err
+	}
+	dataURL, err := a.encodeCreatorToPdf(c)
+	if err != nil {
+		return "", err
+	}
+	if err := a.recordServiceUsage(svc, usageExport); err != nil {
+		slog.Warn("Failed to record service export usage", "serviceId", serviceId, "error", err)
+	}
+	return dataURL, nil
+}
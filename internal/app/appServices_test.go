@@ -0,0 +1,372 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeServiceDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{name: "valid date", raw: "2026-03-29", want: "2026-03-29"},
+		{name: "surrounding spaces", raw: " 2026-12-24 ", want: "2026-12-24"},
+		{name: "empty", raw: "", wantErr: true},
+		{name: "wrong format", raw: "29.3.2026", wantErr: true},
+		{name: "impossible date", raw: "2026-02-30", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeServiceDate(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeServiceDate(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("normalizeServiceDate(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveService_CreateAndRead(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := insertArrangementSong(t, app)
+
+	id, err := app.SaveService(dtoService{
+		ServiceDate: "2026-11-29",
+		Title:       "1. neděle adventní",
+		Items: []dtoServiceItem{
+			{SongId: songID, VerseOrder: "V1 c"},
+			{SongId: songID, Notes: "závěrečná"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SaveService: %v", err)
+	}
+
+	svc, err := app.GetService(id)
+	if err != nil {
+		t.Fatalf("GetService: %v", err)
+	}
+	if svc.Title != "1. neděle adventní" || svc.ServiceDate != "2026-11-29" {
+		t.Errorf("unexpected service header: %+v", svc)
+	}
+	if len(svc.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(svc.Items))
+	}
+	if svc.Items[0].VerseOrder != "v1 c" || svc.Items[0].Position != 1 {
+		t.Errorf("unexpected first item: %+v", svc.Items[0])
+	}
+	if svc.Items[1].Title != "Arranged" || svc.Items[1].Entry != 700 || svc.Items[1].Notes != "závěrečná" {
+		t.Errorf("unexpected second item: %+v", svc.Items[1])
+	}
+
+	list, err := app.GetServices()
+	if err != nil {
+		t.Fatalf("GetServices: %v", err)
+	}
+	if len(list) != 1 || list[0].ItemCount != 2 {
+		t.Errorf("unexpected services list: %+v", list)
+	}
+}
+
+func TestSaveService_Validation(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := insertArrangementSong(t, app)
+
+	tests := []struct {
+		name    string
+		service dtoService
+	}{
+		{name: "missing date", service: dtoService{Title: "x"}},
+		{name: "unknown song", service: dtoService{ServiceDate: "2026-01-04", Items: []dtoServiceItem{{SongId: 99999}}}},
+		{name: "missing song", service: dtoService{ServiceDate: "2026-01-04", Items: []dtoServiceItem{{}}}},
+		{name: "unknown verse", service: dtoService{ServiceDate: "2026-01-04", Items: []dtoServiceItem{{SongId: songID, VerseOrder: "v7"}}}},
+		{name: "unknown service id", service: dtoService{Id: 4242, ServiceDate: "2026-01-04"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := app.SaveService(tt.service); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+
+	list, _ := app.GetServices()
+	if len(list) != 0 {
+		t.Errorf("failed saves must not create services, got %d", len(list))
+	}
+}
+
+func TestSaveService_UpdateReplacesItems(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := insertArrangementSong(t, app)
+
+	id, err := app.SaveService(dtoService{ServiceDate: "2026-01-04", Items: []dtoServiceItem{{SongId: songID}, {SongId: songID}}})
+	if err != nil {
+		t.Fatalf("SaveService: %v", err)
+	}
+
+	if _, err := app.SaveService(dtoService{Id: id, ServiceDate: "2026-01-11", Title: "posunuto", Items: []dtoServiceItem{{SongId: songID}}}); err != nil {
+		t.Fatalf("SaveService (update): %v", err)
+	}
+
+	svc, err := app.GetService(id)
+	if err != nil {
+		t.Fatalf("GetService: %v", err)
+	}
+	if svc.ServiceDate != "2026-01-11" || svc.Title != "posunuto" || len(svc.Items) != 1 {
+		t.Errorf("unexpected service after update: %+v", svc)
+	}
+}
+
+func TestDuplicateAndDeleteService(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := insertArrangementSong(t, app)
+
+	id, err := app.SaveService(dtoService{ServiceDate: "2026-01-04", Title: "Bohoslužba", Items: []dtoServiceItem{{SongId: songID, VerseOrder: "v2"}}})
+	if err != nil {
+		t.Fatalf("SaveService: %v", err)
+	}
+
+	copyID, err := app.DuplicateService(id, "2026-01-11")
+	if err != nil {
+		t.Fatalf("DuplicateService: %v", err)
+	}
+	if copyID == id {
+		t.Fatal("duplicate must get a new id")
+	}
+	copySvc, err := app.GetService(copyID)
+	if err != nil {
+		t.Fatalf("GetService: %v", err)
+	}
+	if copySvc.ServiceDate != "2026-01-11" || copySvc.Title != "Bohoslužba" || len(copySvc.Items) != 1 || copySvc.Items[0].VerseOrder != "v2" {
+		t.Errorf("unexpected duplicate: %+v", copySvc)
+	}
+
+	if err := app.DeleteService(id); err != nil {
+		t.Fatalf("DeleteService: %v", err)
+	}
+	if _, err := app.GetService(id); err == nil {
+		t.Error("expected error for deleted service")
+	}
+	list, _ := app.GetServices()
+	if len(list) != 1 || list[0].Id != copyID {
+		t.Errorf("expected only the duplicate to remain, got %+v", list)
+	}
+}
+
+func TestGetServiceItemProjection(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := insertArrangementSong(t, app)
+
+	id, err := app.SaveService(dtoService{ServiceDate: "2026-01-04", Items: []dtoServiceItem{{SongId: songID, VerseOrder: "c v2"}}})
+	if err != nil {
+		t.Fatalf("SaveService: %v", err)
+	}
+	svc, _ := app.GetService(id)
+
+	raw, err := app.GetServiceItemProjection(svc.Items[0].Id)
+	if err != nil {
+		t.Fatalf("GetServiceItemProjection: %v", err)
+	}
+	var payload struct {
+		VerseOrder string `json:"verse_order"`
+	}
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		t.Fatalf("bad JSON: %v", err)
+	}
+	if payload.VerseOrder != "c v2" {
+		t.Errorf("expected item verse selection, got %q", payload.VerseOrder)
+	}
+
+	if _, err := app.GetServiceItemProjection(99999); err == nil {
+		t.Error("expected error for unknown item")
+	}
+}
+
+func TestServiceItems_FollowSongKey(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := insertArrangementSong(t, app)
+
+	id, err := app.SaveService(dtoService{ServiceDate: "2026-01-04", Items: []dtoServiceItem{{SongId: songID, VerseOrder: "c v2"}}})
+	if err != nil {
+		t.Fatalf("SaveService: %v", err)
+	}
+
+	// a refill gives the song a new row id
+	newID := songID + 100
+	err = app.withDB(func(db *sql.DB) error {
+		if _, err := db.Exec(`UPDATE songs SET id = ? WHERE id = ?`, newID, songID); err != nil {
+			return err
+		}
+		_, err := db.Exec(`UPDATE verses SET song_id = ? WHERE song_id = ?`, newID, songID)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc, _ := app.GetService(id)
+	if item := svc.Items[0]; item.SongId != newID || item.Title != "Arranged" || item.EntryText != "700" || item.Missing {
+		t.Errorf("expected the item to follow the song key, got %+v", item)
+	}
+	if _, err := app.GetServiceItemProjection(svc.Items[0].Id); err != nil {
+		t.Errorf("GetServiceItemProjection: %v", err)
+	}
+
+	// a song gone from the songbook leaves a missing item
+	err = app.withDB(func(db *sql.DB) error {
+		_, err := db.Exec(`DELETE FROM songs WHERE id = ?`, newID)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc, _ = app.GetService(id)
+	if item := svc.Items[0]; !item.Missing || item.SongId != 0 || item.SongbookAcronym != "EZ" || item.EntryText != "700" {
+		t.Errorf("expected a missing item, got %+v", item)
+	}
+	if _, err := app.GetServiceItemProjection(svc.Items[0].Id); err == nil {
+		t.Error("expected error projecting a missing song")
+	}
+	if _, err := app.GetServiceOrderPdf(id); err != nil {
+		t.Errorf("GetServiceOrderPdf: %v", err)
+	}
+	if _, err := app.SaveService(svc); err != nil {
+		t.Errorf("a plan with a missing song should still save: %v", err)
+	}
+}
+
+func TestSaveService_NonSongItems(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := insertArrangementSong(t, app)
+
+	id, err := app.SaveService(dtoService{
+		ServiceDate: "2026-04-05",
+		Title:       "Neděle Vzkříšení",
+		Items: []dtoServiceItem{
+			{ItemType: LiturgyItem, Title: "Vstup", Content: "Pokoj vám."},
+			{ItemType: ReadingItem, ScriptureRef: "Mk 16,1-8", Content: "První odstavec.\n\nDruhý odstavec."},
+			{SongId: songID},
+			{ItemType: AnnouncementItem, Title: "Ohlášky", Content: "Sbírka na opravu varhan."},
+			{ItemType: PrayerItem, Title: "Přímluvy"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SaveService: %v", err)
+	}
+
+	svc, err := app.GetService(id)
+	if err != nil {
+		t.Fatalf("GetService: %v", err)
+	}
+	wantTypes := []ServiceItemType{LiturgyItem, ReadingItem, SongItem, AnnouncementItem, PrayerItem}
+	if len(svc.Items) != len(wantTypes) {
+		t.Fatalf("expected %d items, got %d", len(wantTypes), len(svc.Items))
+	}
+	for i, want := range wantTypes {
+		if svc.Items[i].ItemType != want {
+			t.Errorf("item %d type = %q, want %q", i, svc.Items[i].ItemType, want)
+		}
+	}
+	if svc.Items[1].ScriptureRef != "Mk 16,1-8" || svc.Items[2].Title != "Arranged" {
+		t.Errorf("unexpected items: %+v", svc.Items)
+	}
+
+	raw, err := app.GetServiceItemProjection(svc.Items[1].Id)
+	if err != nil {
+		t.Fatalf("GetServiceItemProjection: %v", err)
+	}
+	var payload struct {
+		Title      string `json:"title"`
+		VerseOrder string `json:"verse_order"`
+		Verses     []struct {
+			Name  string `json:"name"`
+			Lines string `json:"lines"`
+		} `json:"verses"`
+	}
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		t.Fatalf("bad JSON: %v", err)
+	}
+	if payload.Title != "Mk 16,1-8" || payload.VerseOrder != "p1 p2" || len(payload.Verses) != 2 {
+		t.Errorf("unexpected reading projection: %+v", payload)
+	}
+	if payload.Verses[1].Lines != "Druhý odstavec." {
+		t.Errorf("unexpected second paragraph: %q", payload.Verses[1].Lines)
+	}
+}
+
+func TestSaveService_NonSongItemValidation(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+
+	tests := []struct {
+		name string
+		item dtoServiceItem
+	}{
+		{name: "unknown type", item: dtoServiceItem{ItemType: "sermon", Title: "x"}},
+		{name: "empty reading", item: dtoServiceItem{ItemType: ReadingItem, Title: "Čtení"}},
+		{name: "empty prayer", item: dtoServiceItem{ItemType: PrayerItem}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := app.SaveService(dtoService{ServiceDate: "2026-04-05", Items: []dtoServiceItem{tt.item}})
+			if err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestGetServiceOrderPdf(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := insertArrangementSong(t, app)
+
+	id, err := app.SaveService(dtoService{
+		ServiceDate: "2026-04-05",
+		Title:       "Neděle Vzkříšení",
+		Items: []dtoServiceItem{
+			{ItemType: ReadingItem, ScriptureRef: "Mk 16,1-8"},
+			{SongId: songID, VerseOrder: "v1 c", Notes: "varhany"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SaveService: %v", err)
+	}
+
+	svc, _ := app.GetService(id)
+	lines := serviceOrderLines(svc)
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 lines, got %d: %+v", len(lines), lines)
+	}
+	if lines[2].Text != "1. Čtení: Mk 16,1-8" {
+		t.Errorf("unexpected reading line: %q", lines[2].Text)
+	}
+	if lines[3].Text != "2. Píseň: EZ 700 – Arranged (v1 c)" {
+		t.Errorf("unexpected song line: %q", lines[3].Text)
+	}
+
+	dataURL, err := app.GetServiceOrderPdf(id)
+	if err != nil {
+		t.Fatalf("GetServiceOrderPdf: %v", err)
+	}
+	if !strings.HasPrefix(dataURL, "data:application/pdf;base64,") {
+		t.Errorf("expected PDF data URL, got %q", dataURL[:min(len(dataURL), 40)])
+	}
+}
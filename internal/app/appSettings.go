@@ -0,0 +1,317 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// settingsFileName is stored in the Lyyyra home folder, outside the data
+// directory it configures
+const settingsFileName = "settings.yaml"
+
+// defaultDownloadTimeout limits a single download unless configured otherwise
+const defaultDownloadTimeout = 2 * time.Minute
+
+// logLevel is the level of the default logger, changed by settings at runtime
+var logLevel = new(slog.LevelVar)
+
+// logLevels maps the log levels accepted in settings
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// settingsEnvOverrides lists environment variables overriding settings.yaml
+var settingsEnvOverrides = []struct {
+	env   string
+	apply func(s *AppSettings, value string) error
+}{
+	{"LYYYRA_XML_URL_EZ", func(s *AppSettings, v string) error { s.XMLUrlEZ = v; return nil }},
+	{"LYYYRA_XML_URL_KK", func(s *AppSettings, v string) error { s.XMLUrlKK = v; return nil }},
+	{"LYYYRA_PDF_URL", func(s *AppSettings, v string) error { s.PDFUrl = v; return nil }},
+	{"LYYYRA_MIRRORS", func(s *AppSettings, v string) error { s.Mirrors = splitSettingsList(v); return nil }},
+	{"LYYYRA_DATA_DIR", func(s *AppSettings, v string) error { s.DataDir = v; return nil }},
+	{"LYYYRA_LOG_LEVEL", func(s *AppSettings, v string) error { s.LogLevel = v; return nil }},
+	{"LYYYRA_PROXY", func(s *AppSettings, v string) error { s.Proxy = v; return nil }},
+	{"LYYYRA_DOWNLOAD_TIMEOUT", func(s *AppSettings, v string) (err error) {
+		s.DownloadTimeoutSeconds, err = strconv.Atoi(strings.TrimSpace(v))
+		return err
+	}},
+	{"LYYYRA_SNAPSHOT_KEEP", func(s *AppSettings, v string) (err error) {
+		s.SnapshotKeep, err = strconv.Atoi(strings.TrimSpace(v))
+		return err
+	}},
+}
+
+// splitSettingsList splits a comma separated list, dropping empty items
+func splitSettingsList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// defaultSettings returns the settings used without settings.yaml
+func defaultSettings() AppSettings {
+	return AppSettings{
+		XMLUrlEZ:               XMLUrl_EZ,
+		XMLUrlKK:               XMLUrl_KK,
+		PDFUrl:                 PDFUrl,
+		SupplementalPDFs:       slices.Clone(defaultSupplementalPDFs),
+		Mirrors:                []string{},
+		LogLevel:               "info",
+		DownloadTimeoutSeconds: int(defaultDownloadTimeout / time.Second),
+		SnapshotKeep:           defaultSnapshotKeep,
+	}
+}
+
+// validateSettingsURL checks a source URL, allowing local file:// sources
+func validateSettingsURL(name, value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil || value == "" {
+		return fmt.Errorf("%s: invalid URL %q", name, value)
+	}
+	switch parsed.Scheme {
+	case "http", "https":
+		if parsed.Host == "" {
+			return fmt.Errorf("%s: URL %q has no host", name, value)
+		}
+	case "file":
+	default:
+		return fmt.Errorf("%s: unsupported URL scheme in %q", name, value)
+	}
+	return nil
+}
+
+// normalizeSettings trims and validates settings
+func normalizeSettings(s AppSettings) (AppSettings, error) {
+	s.XMLUrlEZ = strings.TrimSpace(s.XMLUrlEZ)
+	s.XMLUrlKK = strings.TrimSpace(s.XMLUrlKK)
+	s.PDFUrl = strings.TrimSpace(s.PDFUrl)
+	for _, source := range []struct{ name, value string }{
+		{"xmlUrlEz", s.XMLUrlEZ}, {"xmlUrlKk", s.XMLUrlKK}, {"pdfUrl", s.PDFUrl},
+	} {
+		if err := validateSettingsURL(source.name, source.value); err != nil {
+			return s, err
+		}
+	}
+	if parsed, _ := url.Parse(s.PDFUrl); parsed.Scheme == "file" {
+		// PDF links of the chapter page are resolved against its host
+		return s, fmt.Errorf("pdfUrl: must be a web address")
+	}
+
+	pdfs := make([]SupplementalPDF, 0, len(s.SupplementalPDFs))
+	for _, pdf := range s.SupplementalPDFs {
+		pdf.URL, pdf.FileName = strings.TrimSpace(pdf.URL), strings.TrimSpace(pdf.FileName)
+		if err := validateSettingsURL("supplementalPdfs", pdf.URL); err != nil {
+			return s, err
+		}
+		if pdf.FileName == "" || filepath.Base(pdf.FileName) != pdf.FileName || !strings.EqualFold(filepath.Ext(pdf.FileName), ".pdf") {
+			return s, fmt.Errorf("supplementalPdfs: invalid file name %q", pdf.FileName)
+		}
+		pdfs = append(pdfs, pdf)
+	}
+	s.SupplementalPDFs = pdfs
+
+	mirrors := []string{}
+	for _, mirror := range s.Mirrors {
+		mirror = strings.TrimSuffix(strings.TrimSpace(mirror), "/")
+		if mirror == "" {
+			continue
+		}
+		if err := validateSettingsURL("mirrors", mirror); err != nil {
+			return s, err
+		}
+		mirrors = append(mirrors, mirror)
+	}
+	s.Mirrors = mirrors
+
+	s.DataDir = strings.TrimSpace(s.DataDir)
+	if s.DataDir != "" && !filepath.IsAbs(s.DataDir) {
+		return s, fmt.Errorf("dataDir: %q is not an absolute path", s.DataDir)
+	}
+	s.LogLevel = strings.ToLower(strings.TrimSpace(s.LogLevel))
+	if s.LogLevel == "" {
+		s.LogLevel = "info"
+	}
+	if _, ok := logLevels[s.LogLevel]; !ok {
+		return s, fmt.Errorf("logLevel: unknown level %q, expected debug, info, warn or error", s.LogLevel)
+	}
+	s.Proxy = strings.TrimSpace(s.Proxy)
+	if s.Proxy != "" {
+		parsed, err := url.Parse(s.Proxy)
+		if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https" && parsed.Scheme != "socks5") {
+			return s, fmt.Errorf("proxy: invalid proxy URL %q", s.Proxy)
+		}
+	}
+	if s.DownloadTimeoutSeconds < 0 || s.DownloadTimeoutSeconds > 3600 {
+		return s, fmt.Errorf("downloadTimeoutSeconds: must be between 0 and 3600")
+	}
+	if s.SnapshotKeep < 0 || s.SnapshotKeep > 100 {
+		return s, fmt.Errorf("snapshotKeep: must be between 0 and 100")
+	}
+	return s, nil
+}
+
+// readSettingsFile loads settings.yaml on top of the defaults. A missing file
+// gives the defaults.
+func readSettingsFile(path string) (AppSettings, error) {
+	settings := defaultSettings()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return defaultSettings(), fmt.Errorf("invalid %s: %w", filepath.Base(path), err)
+	}
+	normalized, err := normalizeSettings(settings)
+	if err != nil {
+		return defaultSettings(), fmt.Errorf("invalid %s: %w", filepath.Base(path), err)
+	}
+	return normalized, nil
+}
+
+// writeSettingsFile stores settings as settings.yaml
+func writeSettingsFile(path string, settings AppSettings) error {
+	data, err := yaml.Marshal(&settings)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// applySettingsEnv applies environment overrides one by one. An override
+// making the settings invalid is ignored. It returns the used variables.
+func applySettingsEnv(settings AppSettings) (AppSettings, []string) {
+	overrides := []string{}
+	for _, override := range settingsEnvOverrides {
+		value, ok := os.LookupEnv(override.env)
+		if !ok {
+			continue
+		}
+		candidate := settings
+		err := override.apply(&candidate, value)
+		if err == nil {
+			candidate, err = normalizeSettings(candidate)
+		}
+		if err != nil {
+			slog.Warn("Ignoring invalid settings override", "env", override.env, "error", err)
+			continue
+		}
+		settings = candidate
+		overrides = append(overrides, override.env)
+	}
+	return settings, overrides
+}
+
+// loadSettings reads settings.yaml with environment overrides. Problems are
+// returned together with usable settings, as they are found before logging
+// is set up.
+func loadSettings(path string) (AppSettings, []string, error) {
+	saved, err := readSettingsFile(path)
+	effective, overrides := applySettingsEnv(saved)
+	return effective, overrides, err
+}
+
+// applySettings uses the parts of settings that can change while running.
+// The data directory and the chapter page URL are only read by NewApp.
+func (a *App) applySettings(settings AppSettings) {
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	a.settings = settings
+	a.xmlUrl = settings.XMLUrlEZ
+	a.xmlUrlKK = settings.XMLUrlKK
+	a.snapshotKeep = settings.SnapshotKeep
+	logLevel.Set(logLevels[settings.LogLevel])
+}
+
+// currentSettings returns the effective settings, which background downloads
+// read while SaveSettings may replace them
+func (a *App) currentSettings() AppSettings {
+	a.settingsMu.RLock()
+	defer a.settingsMu.RUnlock()
+	return a.settings
+}
+
+// supplementalPDFs returns the configured PDFs downloaded with the chapters
+func (a *App) supplementalPDFs() []SupplementalPDF {
+	return a.currentSettings().SupplementalPDFs
+}
+
+// downloadTimeout returns the configured time limit of one download
+func (a *App) downloadTimeout() time.Duration {
+	if seconds := a.currentSettings().DownloadTimeoutSeconds; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultDownloadTimeout
+}
+
+// httpClient returns a client using the configured proxy, or the default
+// client honoring the proxy environment variables
+func (a *App) httpClient() *http.Client {
+	proxy := a.currentSettings().Proxy
+	if proxy == "" {
+		return http.DefaultClient
+	}
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return http.DefaultClient
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	return &http.Client{Transport: transport}
+}
+
+// GetSettings returns the saved settings, the effective ones including
+// environment overrides, and the overriding variables
+func (a *App) GetSettings() (dtoSettings, error) {
+	saved, err := readSettingsFile(a.settingsPath)
+	_, overrides := applySettingsEnv(saved)
+	return dtoSettings{Saved: saved, Effective: a.currentSettings(), Overrides: overrides}, err
+}
+
+// GetDefaultSettings returns the built-in settings
+func (a *App) GetDefaultSettings() AppSettings {
+	return defaultSettings()
+}
+
+// SaveSettings validates and stores settings.yaml and applies the new
+// settings. Changes of the data directory or the chapter page URL take
+// effect after a restart, which RestartRequired reports.
+func (a *App) SaveSettings(settings AppSettings) (dtoSettings, error) {
+	normalized, err := normalizeSettings(settings)
+	if err != nil {
+		return dtoSettings{}, err
+	}
+	if err := writeSettingsFile(a.settingsPath, normalized); err != nil {
+		slog.Error(fmt.Sprintf("Saving settings failed: %s", err))
+		return dtoSettings{}, err
+	}
+
+	effective, overrides := applySettingsEnv(normalized)
+	running := a.currentSettings()
+	restart := effective.DataDir != running.DataDir || effective.PDFUrl != running.PDFUrl
+	// keeps the running data folder until the restart
+	effective.DataDir, effective.PDFUrl = running.DataDir, running.PDFUrl
+	a.applySettings(effective)
+	slog.Info(fmt.Sprintf("Settings saved to %s, restart required: %t", a.settingsPath, restart))
+	return dtoSettings{Saved: normalized, Effective: effective, Overrides: overrides, RestartRequired: restart}, nil
+}
@@ -0,0 +1,167 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(s *AppSettings)
+		want   string
+	}{
+		{"defaults", func(s *AppSettings) {}, ""},
+		{"local song archive", func(s *AppSettings) { s.XMLUrlKK = "file:///srv/lyyyra/pisne.zip" }, ""},
+		{"empty EZ URL", func(s *AppSettings) { s.XMLUrlEZ = "" }, "xmlUrlEz"},
+		{"unsupported scheme", func(s *AppSettings) { s.XMLUrlKK = "ftp://example.org/pisne.zip" }, "unsupported URL scheme"},
+		{"local chapter page", func(s *AppSettings) { s.PDFUrl = "file:///srv/INDEX" }, "pdfUrl"},
+		{"invalid mirror", func(s *AppSettings) { s.Mirrors = []string{"zrcadlo"} }, "mirrors"},
+		{"relative data dir", func(s *AppSettings) { s.DataDir = "Lyyyra" }, "dataDir"},
+		{"unknown log level", func(s *AppSettings) { s.LogLevel = "verbose" }, "logLevel"},
+		{"invalid proxy", func(s *AppSettings) { s.Proxy = "ftp://proxy:21" }, "proxy"},
+		{"socks proxy", func(s *AppSettings) { s.Proxy = "socks5://127.0.0.1:1080" }, ""},
+		{"negative timeout", func(s *AppSettings) { s.DownloadTimeoutSeconds = -1 }, "downloadTimeoutSeconds"},
+		{"too many snapshots", func(s *AppSettings) { s.SnapshotKeep = 1000 }, "snapshotKeep"},
+		{"pdf in subfolder", func(s *AppSettings) {
+			s.SupplementalPDFs = []SupplementalPDF{{URL: "https://example.org/a.pdf", FileName: "../kytara.pdf"}}
+		}, "supplementalPdfs"},
+		{"pdf without extension", func(s *AppSettings) {
+			s.SupplementalPDFs = []SupplementalPDF{{URL: "https://example.org/a.pdf", FileName: "kytara"}}
+		}, "supplementalPdfs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := defaultSettings()
+			tt.modify(&settings)
+			_, err := normalizeSettings(settings)
+			if tt.want == "" && err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	normalized, err := normalizeSettings(AppSettings{
+		XMLUrlEZ: " " + XMLUrl_EZ + " ", XMLUrlKK: XMLUrl_KK, PDFUrl: PDFUrl,
+		Mirrors: []string{"https://zrcadlo.example.org/", " "}, LogLevel: "WARN",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if normalized.XMLUrlEZ != XMLUrl_EZ || normalized.LogLevel != "warn" || !reflect.DeepEqual(normalized.Mirrors, []string{"https://zrcadlo.example.org"}) {
+		t.Errorf("unexpected normalized settings %+v", normalized)
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+
+	settings, overrides, err := loadSettings(path)
+	if err != nil || len(overrides) != 0 || !reflect.DeepEqual(settings, defaultSettings()) {
+		t.Fatalf("missing file should give the defaults, got %+v %v %v", settings, overrides, err)
+	}
+
+	// keys missing in the file keep their defaults
+	os.WriteFile(path, []byte("logLevel: debug\nmirrors:\n  - https://zrcadlo.example.org\n"), 0644)
+	settings, _, err = loadSettings(path)
+	if err != nil || settings.LogLevel != "debug" || settings.XMLUrlKK != XMLUrl_KK || len(settings.SupplementalPDFs) != len(defaultSupplementalPDFs) {
+		t.Errorf("unexpected settings %+v, %v", settings, err)
+	}
+
+	t.Setenv("LYYYRA_LOG_LEVEL", "error")
+	t.Setenv("LYYYRA_DOWNLOAD_TIMEOUT", "30")
+	t.Setenv("LYYYRA_SNAPSHOT_KEEP", "mnoho")
+	t.Setenv("LYYYRA_MIRRORS", "https://a.example.org, https://b.example.org")
+	settings, overrides, _ = loadSettings(path)
+	if settings.LogLevel != "error" || settings.DownloadTimeoutSeconds != 30 || settings.SnapshotKeep != defaultSnapshotKeep {
+		t.Errorf("environment should override the file, got %+v", settings)
+	}
+	if !reflect.DeepEqual(settings.Mirrors, []string{"https://a.example.org", "https://b.example.org"}) {
+		t.Errorf("mirrors = %v", settings.Mirrors)
+	}
+	if !reflect.DeepEqual(overrides, []string{"LYYYRA_MIRRORS", "LYYYRA_LOG_LEVEL", "LYYYRA_DOWNLOAD_TIMEOUT"}) {
+		t.Errorf("invalid overrides should be skipped, got %v", overrides)
+	}
+
+	os.WriteFile(path, []byte("logLevel: hlasite\n"), 0644)
+	if settings, _, err := loadSettings(path); err == nil || settings.XMLUrlEZ != XMLUrl_EZ {
+		t.Errorf("invalid file should fall back to the defaults, got %+v %v", settings, err)
+	}
+}
+
+func TestSaveSettings(t *testing.T) {
+	app := &App{settingsPath: filepath.Join(t.TempDir(), settingsFileName)}
+	app.applySettings(defaultSettings())
+
+	settings := defaultSettings()
+	settings.XMLUrlKK = "https://zrcadlo.example.org/pisne.zip"
+	settings.SnapshotKeep = 0
+	settings.SupplementalPDFs = settings.SupplementalPDFs[:1]
+	result, err := app.SaveSettings(settings)
+	if err != nil {
+		t.Fatalf("SaveSettings: %v", err)
+	}
+	if result.RestartRequired || app.xmlUrlKK != settings.XMLUrlKK || app.snapshotKeep != 0 || len(app.supplementalPDFs()) != 1 {
+		t.Errorf("settings should apply immediately, got %+v", result)
+	}
+
+	saved, err := app.GetSettings()
+	if err != nil || !reflect.DeepEqual(saved.Saved, result.Saved) {
+		t.Errorf("saved settings should be read back, got %+v %v", saved, err)
+	}
+
+	settings.DataDir = t.TempDir()
+	result, err = app.SaveSettings(settings)
+	if err != nil || !result.RestartRequired || app.settings.DataDir != "" {
+		t.Errorf("data directory should change after a restart, got %+v %v", result, err)
+	}
+
+	settings.LogLevel = "hlasite"
+	if _, err := app.SaveSettings(settings); err == nil {
+		t.Error("invalid settings should be rejected")
+	}
+}
+
+func TestDataFolderName(t *testing.T) {
+	// the data folder does not follow an edited pdfUrl
+	if got := dataFolderName(); got != "www.evangelickyzpevnik.cz" {
+		t.Errorf("dataFolderName() = %q", got)
+	}
+}
+
+func TestDownloadFile_Mirrors(t *testing.T) {
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("nedostupné"))
+	}))
+	defer broken.Close()
+	var requested string
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.RequestURI()
+		w.Write([]byte("obsah"))
+	}))
+	defer mirror.Close()
+
+	app := &App{appDir: t.TempDir()}
+	app.settings.Mirrors = []string{broken.URL, mirror.URL}
+	path, err := app.downloadFile(broken.URL+"/res/archive/pisne.zip?download", "pisne.zip")
+	if err != nil {
+		t.Fatalf("downloadFile: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "obsah" || requested != "/res/archive/pisne.zip?download" {
+		t.Errorf("expected the file from the mirror, got %q from %q", data, requested)
+	}
+
+	app.settings.Mirrors = nil
+	if _, err := app.downloadFile(broken.URL+"/pisne.zip", "pisne.zip"); err == nil {
+		t.Error("download without working mirrors should fail")
+	}
+}
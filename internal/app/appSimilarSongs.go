@@ -0,0 +1,368 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Similar songs scoring. The text similarity is the cosine of TF-IDF vectors
+// over diacritics-stripped words, shared tags, chapters and authors add bonuses.
+const (
+	similarTextWeight    = 0.7
+	similarTagWeight     = 0.1
+	similarChapterWeight = 0.1
+	similarAuthorWeight  = 0.1
+	similarMinScore      = 0.05
+	similarMinWordLength = 3
+	similarNeighborCount = 10
+)
+
+// similarityDoc is the data of one song compared for similarity
+type similarityDoc struct {
+	acronym   string
+	entryText string
+	vector    map[string]float64
+	tags      map[string]bool
+	chapters  map[string]bool
+	authors   map[string]bool
+}
+
+// similarNeighbor is a scored neighbor of a song, referenced by index
+type similarNeighbor struct {
+	index  int
+	score  float64
+	reason string
+}
+
+// similarityWords returns the words of a text used for similarity, very short words are skipped
+func similarityWords(text string) []string {
+	var words []string
+	for _, word := range strings.Fields(normalizeForMatch(text)) {
+		if len([]rune(word)) >= similarMinWordLength {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// tfidfVectors weights word counts of the documents by inverse document
+// frequency and normalizes every vector to unit length
+func tfidfVectors(docs [][]string) []map[string]float64 {
+	df := map[string]int{}
+	for _, words := range docs {
+		seen := map[string]bool{}
+		for _, word := range words {
+			if !seen[word] {
+				seen[word] = true
+				df[word]++
+			}
+		}
+	}
+
+	vectors := make([]map[string]float64, len(docs))
+	for i, words := range docs {
+		vector := map[string]float64{}
+		for _, word := range words {
+			vector[word]++
+		}
+		norm := 0.0
+		for word, tf := range vector {
+			weight := tf * math.Log(float64(len(docs))/float64(df[word]))
+			vector[word] = weight
+			norm += weight * weight
+		}
+		for word, weight := range vector {
+			if weight == 0 {
+				delete(vector, word)
+			} else {
+				vector[word] = weight / math.Sqrt(norm)
+			}
+		}
+		vectors[i] = vector
+	}
+	return vectors
+}
+
+// jaccard returns the share of common items of two sets
+func jaccard(x, y map[string]bool) float64 {
+	if len(x) == 0 || len(y) == 0 {
+		return 0
+	}
+	common := 0
+	for item := range x {
+		if y[item] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(x)+len(y)-common)
+}
+
+// rankSimilarSongs returns the best scoring neighbors of every document.
+// Text similarity is accumulated over an inverted index so only documents
+// sharing a word are multiplied.
+func rankSimilarSongs(docs []similarityDoc, limit int) [][]similarNeighbor {
+	postings := map[string][]int{}
+	for i, doc := range docs {
+		for word := range doc.vector {
+			postings[word] = append(postings[word], i)
+		}
+	}
+
+	result := make([][]similarNeighbor, len(docs))
+	text := make([]float64, len(docs))
+	for i, doc := range docs {
+		for j := range text {
+			text[j] = 0
+		}
+		for word, weight := range doc.vector {
+			for _, j := range postings[word] {
+				text[j] += weight * docs[j].vector[word]
+			}
+		}
+
+		var neighbors []similarNeighbor
+		for j, other := range docs {
+			if j == i {
+				continue
+			}
+			score := similarTextWeight * text[j]
+			var reasons []string
+			if text[j] > 0 {
+				reasons = append(reasons, "text")
+			}
+			if shared := jaccard(doc.tags, other.tags); shared > 0 {
+				score += similarTagWeight * shared
+				reasons = append(reasons, "tags")
+			}
+			if shared := jaccard(doc.chapters, other.chapters); shared > 0 {
+				score += similarChapterWeight * shared
+				reasons = append(reasons, "chapter")
+			}
+			if shared := jaccard(doc.authors, other.authors); shared > 0 {
+				score += similarAuthorWeight * shared
+				reasons = append(reasons, "author")
+			}
+			if score >= similarMinScore {
+				neighbors = append(neighbors, similarNeighbor{index: j, score: math.Round(score*1000) / 1000, reason: strings.Join(reasons, ", ")})
+			}
+		}
+		sort.SliceStable(neighbors, func(x, y int) bool { return neighbors[x].score > neighbors[y].score })
+		if len(neighbors) > limit {
+			neighbors = neighbors[:limit]
+		}
+		result[i] = neighbors
+	}
+	return result
+}
+
+// loadSimilarityDocs loads verse texts, tags, chapters and authors of all songs
+func (a *App) loadSimilarityDocs(db *sql.DB) ([]similarityDoc, error) {
+	rows, err := db.Query(`
+		SELECT COALESCE(s.songbook_acronym, ''), ` + songEntryKeyExpr + `,
+		       COALESCE((SELECT GROUP_CONCAT(v.lines_d, ' ') FROM verses v WHERE v.song_id = s.id), ''),
+		       COALESCE((SELECT GROUP_CONCAT(author_value_d, ' ') FROM authors WHERE song_id = s.id), ''),
+		       COALESCE((SELECT GROUP_CONCAT(c.id) FROM song_chapters c JOIN song_chapter_ranges r ON r.chapter_id = c.id
+		                 WHERE c.songbook_acronym = s.songbook_acronym AND s.entry BETWEEN r.entry_from AND r.entry_to), '')
+		FROM songs s
+		ORDER BY s.songbook_acronym, s.entry`)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error querying songs for similarity: %s", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []similarityDoc
+	var words [][]string
+	index := map[string]int{}
+	for rows.Next() {
+		var doc similarityDoc
+		var verses, authors, chapters string
+		if err := rows.Scan(&doc.acronym, &doc.entryText, &verses, &authors, &chapters); err != nil {
+			slog.Error(fmt.Sprintf("Error scanning row: %s", err))
+			return nil, err
+		}
+		doc.tags = map[string]bool{}
+		doc.chapters = map[string]bool{}
+		for _, chapter := range strings.Split(chapters, ",") {
+			if chapter != "" {
+				doc.chapters[chapter] = true
+			}
+		}
+		doc.authors = authorWords(authors)
+		index[doc.acronym+"\x00"+doc.entryText] = len(docs)
+		docs = append(docs, doc)
+		words = append(words, similarityWords(verses))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i, vector := range tfidfVectors(words) {
+		docs[i].vector = vector
+	}
+
+	tagRows, err := db.Query(`SELECT songbook_acronym, entry_text, tag FROM song_tags`)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error querying song tags: %s", err))
+		return nil, err
+	}
+	defer tagRows.Close()
+	for tagRows.Next() {
+		var acronym, entryText, tag string
+		if err := tagRows.Scan(&acronym, &entryText, &tag); err != nil {
+			return nil, err
+		}
+		if i, ok := index[acronym+"\x00"+entryText]; ok {
+			docs[i].tags[tag] = true
+		}
+	}
+	return docs, tagRows.Err()
+}
+
+// rebuildSongNeighbors recomputes the most similar songs of every song and
+// replaces the neighbor table. The same hymn in another songbook (a confident
+// concordance pair) is not an alternative and is left out.
+func (a *App) rebuildSongNeighbors(db *sql.DB) (int, error) {
+	docs, err := a.loadSimilarityDocs(db)
+	if err != nil {
+		return 0, err
+	}
+
+	duplicates := map[string]bool{}
+	rows, err := db.Query(`SELECT songbook_acronym_a, entry_text_a, songbook_acronym_b, entry_text_b
+		FROM song_concordance WHERE confidence >= ?`, concordanceCollapseConfidence)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var acronymA, entryA, acronymB, entryB string
+		if err := rows.Scan(&acronymA, &entryA, &acronymB, &entryB); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		x, y := acronymA+"\x00"+entryA, acronymB+"\x00"+entryB
+		duplicates[x+"\x00"+y] = true
+		duplicates[y+"\x00"+x] = true
+	}
+	rows.Close()
+
+	// ask for a few more neighbors so dropped duplicates do not shorten the list
+	ranked := rankSimilarSongs(docs, similarNeighborCount+2)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM song_neighbors`); err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO song_neighbors
+		(songbook_acronym, entry_text, neighbor_acronym, neighbor_entry_text, score, reason)
+		VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	count := 0
+	for i, neighbors := range ranked {
+		doc := docs[i]
+		stored := 0
+		for _, n := range neighbors {
+			other := docs[n.index]
+			if stored == similarNeighborCount || duplicates[doc.acronym+"\x00"+doc.entryText+"\x00"+other.acronym+"\x00"+other.entryText] {
+				continue
+			}
+			if _, err := stmt.Exec(doc.acronym, doc.entryText, other.acronym, other.entryText, n.score, n.reason); err != nil {
+				return 0, err
+			}
+			stored++
+			count++
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	slog.Info(fmt.Sprintf("Similar songs rebuilt with %d neighbors", count))
+	return count, nil
+}
+
+// RebuildSimilarSongs recomputes the similar songs of all songs and returns the number of stored neighbors
+func (a *App) RebuildSimilarSongs() (int, error) {
+	count := 0
+	err := a.withDB(func(db *sql.DB) error {
+		var err error
+		count, err = a.rebuildSongNeighbors(db)
+		return err
+	})
+	if err == nil && a.status.SimilarSongsStale {
+		a.status.SimilarSongsStale = false
+		a.saveStatus()
+	}
+	return count, err
+}
+
+// markSimilarSongsStale records that songs, tags or chapters changed after
+// the similar songs were computed. GetSimilarSongs rebuilds them before the
+// next lookup, so edits in a row pay for one rebuild only.
+func (a *App) markSimilarSongsStale() {
+	if !a.status.SimilarSongsStale {
+		a.status.SimilarSongsStale = true
+		a.saveStatus()
+	}
+}
+
+// refreshSimilarSongs rebuilds the similar songs, e.g. once songs and EZ
+// chapters are imported, as shared chapters add to the similarity
+func (a *App) refreshSimilarSongs() {
+	if _, err := a.RebuildSimilarSongs(); err != nil {
+		slog.Warn("Failed to rebuild similar songs", "error", err)
+	}
+}
+
+// GetSimilarSongs lists up to limit songs most similar to the given song, best first
+func (a *App) GetSimilarSongs(songId int, limit int) ([]dtoSimilarSong, error) {
+	if limit <= 0 || limit > similarNeighborCount {
+		limit = similarNeighborCount
+	}
+	if a.status.SimilarSongsStale {
+		a.refreshSimilarSongs()
+	}
+	result := []dtoSimilarSong{}
+	err := a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		rows, err := db.Query(`
+			SELECT o.id, o.entry, n.neighbor_entry_text, COALESCE(o.title, ''), n.neighbor_acronym, n.score, n.reason
+			FROM song_neighbors n
+			JOIN songs o ON COALESCE(o.songbook_acronym, '') = n.neighbor_acronym
+			            AND COALESCE(NULLIF(o.entry_text, ''), CAST(o.entry AS TEXT)) = n.neighbor_entry_text
+			WHERE n.songbook_acronym = ? AND n.entry_text = ?
+			ORDER BY n.score DESC, o.songbook_acronym, o.entry
+			LIMIT ?`,
+			acronym, entryText, limit)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error querying similar songs: %s", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var s dtoSimilarSong
+			if err := rows.Scan(&s.Id, &s.Entry, &s.EntryText, &s.Title, &s.SongbookAcronym, &s.Score, &s.Reason); err != nil {
+				slog.Error(fmt.Sprintf("Error scanning row: %s", err))
+				return err
+			}
+			result = append(result, s)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+)
+
+func TestTfidfVectors(t *testing.T) {
+	vectors := tfidfVectors([][]string{
+		{"chvala", "pane", "pane"},
+		{"chvala", "duse"},
+		{"chvala"},
+	})
+	// a word in every document carries no weight
+	if _, ok := vectors[0]["chvala"]; ok {
+		t.Errorf("expected word shared by all documents to be dropped, got %v", vectors[0])
+	}
+	if len(vectors[2]) != 0 {
+		t.Errorf("expected empty vector, got %v", vectors[2])
+	}
+	if math.Abs(vectors[1]["duse"]-1) > 1e-9 || math.Abs(vectors[0]["pane"]-1) > 1e-9 {
+		t.Errorf("expected unit vectors, got %v %v", vectors[0], vectors[1])
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	tests := []struct {
+		x, y map[string]bool
+		want float64
+	}{
+		{map[string]bool{"a": true, "b": true}, map[string]bool{"b": true, "c": true}, 1.0 / 3},
+		{map[string]bool{"a": true}, map[string]bool{"a": true}, 1},
+		{map[string]bool{}, map[string]bool{"a": true}, 0},
+	}
+	for _, tt := range tests {
+		if got := jaccard(tt.x, tt.y); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("jaccard(%v, %v) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+// insertSimilarSongs inserts two Easter songs sharing words, a Christmas song
+// sharing only "Kristus" and an unrelated song
+func insertSimilarSongs(t *testing.T, app *App) []int {
+	t.Helper()
+	var ids []int
+	err := app.withDB(func(db *sql.DB) error {
+		songs := []struct {
+			entry        int
+			title, verse string
+		}{
+			{1, "Vstal z mrtvých", "Kristus vstal z mrtvých, radujme se,\nhrob je prázdný, haleluja"},
+			{2, "Velikonoční", "Zpívejme, Kristus z mrtvých vstal,\nprázdný hrob nám zvěstuje"},
+			{3, "Narodil se", "Narodil se Kristus Pán v Betlémě,\nveselme se"},
+			{4, "Večerní", "Den se chýlí k západu, noc přichází tichá"},
+		}
+		for _, s := range songs {
+			r, err := db.Exec(`INSERT INTO songs (songbook_acronym, title, title_d, verse_order, entry, entry_text) VALUES ('EZ', ?, ?, '', ?, ?)`,
+				s.title, removeDiacritics(s.title), s.entry, s.entry)
+			if err != nil {
+				return err
+			}
+			id, _ := r.LastInsertId()
+			if _, err := db.Exec(`INSERT INTO verses (song_id, name, lines, lines_d) VALUES (?, 'v1', ?, ?)`, id, s.verse, removeDiacritics(s.verse)); err != nil {
+				return err
+			}
+			ids = append(ids, int(id))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	return ids
+}
+
+func TestGetSimilarSongs(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertSimilarSongs(t, app)
+	for _, id := range ids[:3] {
+		if err := app.AddSongTag(id, "velikonoce"); err != nil {
+			t.Fatalf("AddSongTag: %v", err)
+		}
+	}
+
+	if _, err := app.RebuildSimilarSongs(); err != nil {
+		t.Fatalf("RebuildSimilarSongs: %v", err)
+	}
+
+	similar, err := app.GetSimilarSongs(ids[0], 0)
+	if err != nil {
+		t.Fatalf("GetSimilarSongs: %v", err)
+	}
+	if len(similar) != 2 {
+		t.Fatalf("expected 2 similar songs, got %+v", similar)
+	}
+	if similar[0].Id != ids[1] || similar[0].Reason != "text, tags" || similar[0].Score <= similar[1].Score {
+		t.Errorf("expected the other Easter song first, got %+v", similar)
+	}
+	if similar[1].Id != ids[2] || similar[1].Reason != "text, tags" {
+		t.Errorf("expected the tagged song second, got %+v", similar[1])
+	}
+
+	similar, _ = app.GetSimilarSongs(ids[0], 1)
+	if len(similar) != 1 {
+		t.Errorf("expected limit to apply, got %d songs", len(similar))
+	}
+	similar, _ = app.GetSimilarSongs(ids[3], 5)
+	if len(similar) != 0 {
+		t.Errorf("expected no similar songs for the unrelated song, got %+v", similar)
+	}
+	if _, err := app.GetSimilarSongs(99999, 5); err == nil {
+		t.Error("expected error for unknown song")
+	}
+
+	// a new tag makes the table stale, it is rebuilt on the next lookup
+	if err := app.AddSongTag(ids[3], "velikonoce"); err != nil {
+		t.Fatal(err)
+	}
+	similar, _ = app.GetSimilarSongs(ids[3], 5)
+	if len(similar) != 3 || app.status.SimilarSongsStale {
+		t.Errorf("expected the tagged songs after the rebuild, got %+v", similar)
+	}
+}
+
+func TestGetSimilarSongs_AfterSaveSong(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertSimilarSongs(t, app)
+	if _, err := app.RebuildSimilarSongs(); err != nil {
+		t.Fatalf("RebuildSimilarSongs: %v", err)
+	}
+	if err := app.CreateSongbook("SBOR", "Zpěvník sboru"); err != nil {
+		t.Fatal(err)
+	}
+
+	verses, _ := app.GetSongVerses(ids[0])
+	id, err := app.SaveSong(dtoSongDraft{SongbookAcronym: "SBOR", Title: "Nová píseň", Verses: []dtoVerseDetail{{Name: "v1", Lines: verses}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	similar, _ := app.GetSimilarSongs(id, 0)
+	if len(similar) == 0 || similar[0].Id != ids[0] {
+		t.Errorf("a new song should get neighbors, got %+v", similar)
+	}
+}
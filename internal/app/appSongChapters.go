@@ -0,0 +1,214 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+)
+
+// chapterPageFileName is the downloaded page listing the EZ chapters
+const chapterPageFileName = "INDEX"
+
+// entryRange is an inclusive range of songbook entry numbers
+type entryRange struct {
+	From int
+	To   int
+}
+
+// songChapter is a chapter heading of the printed songbook
+type songChapter struct {
+	Section string
+	Name    string
+	Ranges  []entryRange
+}
+
+var reEntryRange = regexp.MustCompile(`(\d+)(?:\s*[-–—]\s*(\d+))?`)
+
+// parseChapterHeading splits a heading like "Advent (271–288)" into the
+// chapter name and its entry ranges. Headings without numbers return no ranges.
+func parseChapterHeading(text string) (string, []entryRange) {
+	text = strings.Join(strings.Fields(text), " ")
+	loc := reEntryRange.FindStringIndex(text)
+	if loc == nil {
+		return text, nil
+	}
+
+	name := strings.TrimRight(text[:loc[0]], " (,:–—-")
+	var ranges []entryRange
+	for _, m := range reEntryRange.FindAllStringSubmatch(text[loc[0]:], -1) {
+		from, _ := strconv.Atoi(m[1])
+		to := from
+		if m[2] != "" {
+			to, _ = strconv.Atoi(m[2])
+		}
+		if from <= 0 || to < from {
+			continue
+		}
+		ranges = append(ranges, entryRange{From: from, To: to})
+	}
+	if name == "" {
+		// "1–150 Žalmy": the numbers come first
+		name = strings.TrimLeft(reEntryRange.ReplaceAllString(text, ""), " (),:–—-")
+		name = strings.TrimRight(name, " ()")
+	}
+	return name, ranges
+}
+
+// parseChapterHtml reads the chapter structure from the "kapitoly-a-pisne"
+// page. Headings with song numbers become chapters; headings without numbers
+// are sections grouping the chapters that follow.
+func (a *App) parseChapterHtml(fileName string) []songChapter {
+	file, err := os.Open(fileName)
+	if err != nil {
+		slog.Error(err.Error())
+		return nil
+	}
+	defer file.Close()
+
+	doc, err := htmlquery.Parse(file)
+	if err != nil {
+		slog.Error(err.Error())
+		return nil
+	}
+
+	nodes, err := htmlquery.QueryAll(doc, "/html/body/section[1]//*[self::h2 or self::h3 or self::h4]")
+	if err != nil {
+		slog.Error(err.Error())
+		return nil
+	}
+
+	var chapters []songChapter
+	section := ""
+	for _, node := range nodes {
+		text := strings.TrimSpace(htmlquery.InnerText(node))
+		if text == "" {
+			if link := htmlquery.FindOne(node, "./a"); link != nil {
+				text = htmlquery.SelectAttr(link, "title")
+			}
+		}
+		name, ranges := parseChapterHeading(text)
+		if name == "" {
+			continue
+		}
+		if len(ranges) == 0 {
+			section = name
+			continue
+		}
+		chapters = append(chapters, songChapter{Section: section, Name: name, Ranges: ranges})
+	}
+	return chapters
+}
+
+// saveSongChapters replaces the stored chapter structure of a songbook
+func (a *App) saveSongChapters(acronym string, chapters []songChapter) error {
+	return a.withDB(func(db *sql.DB) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec(`DELETE FROM song_chapter_ranges WHERE chapter_id IN (SELECT id FROM song_chapters WHERE songbook_acronym = ?)`, acronym); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM song_chapters WHERE songbook_acronym = ?`, acronym); err != nil {
+			return err
+		}
+
+		for i, chapter := range chapters {
+			r, err := tx.Exec(`INSERT INTO song_chapters (songbook_acronym, position, section, name, name_d) VALUES (?, ?, ?, ?, ?)`,
+				acronym, i+1, chapter.Section, chapter.Name, removeDiacritics(chapter.Name))
+			if err != nil {
+				return err
+			}
+			chapterID, _ := r.LastInsertId()
+			for _, rng := range chapter.Ranges {
+				if _, err := tx.Exec(`INSERT INTO song_chapter_ranges (chapter_id, entry_from, entry_to) VALUES (?, ?, ?)`,
+					chapterID, rng.From, rng.To); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Commit()
+	})
+}
+
+// importSongChapters parses the downloaded chapter page and stores it for EZ
+func (a *App) importSongChapters(fileName string) error {
+	chapters := a.parseChapterHtml(fileName)
+	if len(chapters) == 0 {
+		return fmt.Errorf("no chapters found in %s", fileName)
+	}
+	slog.Info(fmt.Sprintf("Importing %d EZ chapters", len(chapters)))
+	return a.saveSongChapters("EZ", chapters)
+}
+
+// ImportSongChapters downloads the chapter page and refreshes the EZ chapters
+func (a *App) ImportSongChapters() error {
+	fileName, err := a.downloadFile(a.pdfFiles.Url, chapterPageFileName)
+	if err != nil {
+		slog.Error(err.Error())
+		return err
+	}
+	return a.importSongChapters(fileName)
+}
+
+// importStoredSongChapters imports the EZ chapters from the chapter page
+// already stored in the app directory. The page is downloaded only when it
+// is missing, so a database refill works offline.
+func (a *App) importStoredSongChapters() error {
+	fileName := filepath.Join(a.appDir, chapterPageFileName)
+	if _, err := os.Stat(fileName); err != nil {
+		return a.ImportSongChapters()
+	}
+	return a.importSongChapters(fileName)
+}
+
+// GetSongChapters lists chapters of a songbook ("" for all) in printed order
+func (a *App) GetSongChapters(sourceFilter string) ([]dtoSongChapter, error) {
+	result := []dtoSongChapter{}
+	err := a.withDB(func(db *sql.DB) error {
+		query := `
+			SELECT c.id, c.songbook_acronym, c.position, c.section, c.name,
+			       COALESCE((SELECT GROUP_CONCAT(CASE WHEN r.entry_from = r.entry_to
+			                                          THEN r.entry_from
+			                                          ELSE r.entry_from || '-' || r.entry_to END, ', ')
+			                 FROM song_chapter_ranges r WHERE r.chapter_id = c.id), ''),
+			       (SELECT COUNT(*) FROM songs s
+			        WHERE s.songbook_acronym = c.songbook_acronym
+			          AND EXISTS (SELECT 1 FROM song_chapter_ranges r
+			                      WHERE r.chapter_id = c.id AND s.entry BETWEEN r.entry_from AND r.entry_to))
+			FROM song_chapters c`
+		var args []interface{}
+		if strings.TrimSpace(sourceFilter) != "" {
+			query += ` WHERE c.songbook_acronym = ?`
+			args = append(args, strings.TrimSpace(sourceFilter))
+		}
+		query += ` ORDER BY c.songbook_acronym, c.position`
+
+		rows, err := db.Query(query, args...)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error querying chapters: %s", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var c dtoSongChapter
+			if err := rows.Scan(&c.Id, &c.SongbookAcronym, &c.Position, &c.Section, &c.Name, &c.Ranges, &c.SongCount); err != nil {
+				slog.Error(fmt.Sprintf("Error scanning row: %s", err))
+				return err
+			}
+			result = append(result, c)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseChapterHeading(t *testing.T) {
+	tests := []struct {
+		text       string
+		wantName   string
+		wantRanges []entryRange
+	}{
+		{"Žalmy 1–150", "Žalmy", []entryRange{{1, 150}}},
+		{"Advent (271 - 288)", "Advent", []entryRange{{271, 288}}},
+		{"Večer: 547–560, 563", "Večer", []entryRange{{547, 560}, {563, 563}}},
+		{"151–170 Písně o Božím slově", "Písně o Božím slově", []entryRange{{151, 170}}},
+		{"  Církevní rok  ", "Církevní rok", nil},
+		{"Chyba 20–10", "Chyba", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			name, ranges := parseChapterHeading(tt.text)
+			if name != tt.wantName || !reflect.DeepEqual(ranges, tt.wantRanges) {
+				t.Errorf("parseChapterHeading(%q) = %q %v, want %q %v", tt.text, name, ranges, tt.wantName, tt.wantRanges)
+			}
+		})
+	}
+}
+
+const chapterPageHtml = `<html><body><section><div><div><div>
+<h3>Žalmy</h3>
+<h4><a href="/z1.pdf" title="Žalmy 1–2" download>Žalmy 1–2</a></h4>
+<h3>Církevní rok</h3>
+<h4><a href="/advent.pdf" title="Advent 3" download>Advent 3</a></h4>
+<h4><a href="/kk.pdf" title="Ostatní 4" download></a></h4>
+</div></div></div></section></body></html>`
+
+func writeChapterPage(t *testing.T) string {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "INDEX")
+	if err := os.WriteFile(fileName, []byte(chapterPageHtml), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return fileName
+}
+
+func TestParseChapterHtml(t *testing.T) {
+	app := &App{}
+	chapters := app.parseChapterHtml(writeChapterPage(t))
+	want := []songChapter{
+		{Section: "Žalmy", Name: "Žalmy", Ranges: []entryRange{{1, 2}}},
+		{Section: "Církevní rok", Name: "Advent", Ranges: []entryRange{{3, 3}}},
+		{Section: "Církevní rok", Name: "Ostatní", Ranges: []entryRange{{4, 4}}},
+	}
+	if !reflect.DeepEqual(chapters, want) {
+		t.Errorf("parseChapterHtml = %+v, want %+v", chapters, want)
+	}
+
+	// the existing PDF link parser still sees every chapter link
+	if items := app.parseHtml(writeChapterPage(t)); len(items) != 3 {
+		t.Errorf("expected 3 file items, got %d", len(items))
+	}
+}
+
+func TestSongChapters_ImportAndFilter(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	insertUsageSongs(t, app)
+
+	if err := app.importSongChapters(writeChapterPage(t)); err != nil {
+		t.Fatalf("importSongChapters: %v", err)
+	}
+	// a second import replaces the chapters instead of duplicating them
+	if err := app.importSongChapters(writeChapterPage(t)); err != nil {
+		t.Fatalf("importSongChapters (again): %v", err)
+	}
+
+	chapters, err := app.GetSongChapters("EZ")
+	if err != nil {
+		t.Fatalf("GetSongChapters: %v", err)
+	}
+	if len(chapters) != 3 {
+		t.Fatalf("expected 3 chapters, got %+v", chapters)
+	}
+	if chapters[0].Name != "Žalmy" || chapters[0].Ranges != "1-2" || chapters[0].SongCount != 2 {
+		t.Errorf("unexpected first chapter: %+v", chapters[0])
+	}
+	if chapters[1].Ranges != "3" || chapters[1].SongCount != 1 || chapters[1].Section != "Církevní rok" {
+		t.Errorf("unexpected second chapter: %+v", chapters[1])
+	}
+
+	tests := []struct {
+		name       string
+		pattern    string
+		wantTitles []string
+	}{
+		{name: "by name without diacritics", pattern: "chapter:zalmy", wantTitles: []string{"Kdo se vzdává", "Blaze tomu"}},
+		{name: "by id", pattern: "chapter:" + strconv.Itoa(chapters[1].Id), wantTitles: []string{"Proč se bouří"}},
+		{name: "with text", pattern: "chapter:Žalmy blaze", wantTitles: []string{"Blaze tomu"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			songs, err := app.GetSongs("entry", tt.pattern, "")
+			if err != nil {
+				t.Fatalf("GetSongs: %v", err)
+			}
+			var titles []string
+			for _, s := range songs {
+				titles = append(titles, s.Title)
+			}
+			if !reflect.DeepEqual(titles, tt.wantTitles) {
+				t.Errorf("got %v, want %v", titles, tt.wantTitles)
+			}
+		})
+	}
+
+	songs, err := app.GetSongs(string(Chapter), "", "")
+	if err != nil {
+		t.Fatalf("GetSongs: %v", err)
+	}
+	// KK songs are not covered by EZ chapters and go last
+	if len(songs) != 4 || songs[0].Chapter != "Žalmy" || songs[2].Chapter != "Advent" || songs[3].Chapter != "" {
+		t.Errorf("unexpected chapter grouping: %+v", songs)
+	}
+}
+
+func TestImportStoredSongChapters(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	insertUsageSongs(t, app)
+
+	// the chapter page downloaded with the songbook is reused without network
+	app.appDir = filepath.Dir(writeChapterPage(t))
+	if err := app.importStoredSongChapters(); err != nil {
+		t.Fatalf("importStoredSongChapters: %v", err)
+	}
+	chapters, err := app.GetSongChapters("EZ")
+	if err != nil {
+		t.Fatalf("GetSongChapters: %v", err)
+	}
+	if len(chapters) != 3 {
+		t.Errorf("expected 3 chapters, got %+v", chapters)
+	}
+}
@@ -9,12 +9,13 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"unicode"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 // Current database schema version
-const CurrentDBVersion = 2
+const CurrentDBVersion = 18
 
 // InitializeDatabase checks schema version and applies migrations.
 // This is called on every app startup to ensure the database schema is up-to-date.
@@ -31,7 +32,8 @@ func (a *App) InitializeDatabase() {
 
 		slog.Info(fmt.Sprintf("Current schema version: %d, expected: %d", currentVersion, CurrentDBVersion))
 
-		// Apply migrations if needed
+		// Apply migrations if needed, a fresh database has nothing to snapshot
+		existing := currentVersion > 0
 		if currentVersion == 0 {
 			// Fresh database - create v1 schema
 			slog.Info("Creating new database with schema v1")
@@ -43,6 +45,12 @@ func (a *App) InitializeDatabase() {
 		}
 
 		if currentVersion < CurrentDBVersion {
+			if existing {
+				if _, err := a.snapshotDatabase(fmt.Sprintf("%s-v%d", snapshotMigration, currentVersion)); err != nil {
+					slog.Error(fmt.Sprintf("Error creating database snapshot before migration: %s", err))
+					return err
+				}
+			}
 			for v := currentVersion + 1; v <= CurrentDBVersion; v++ {
 				slog.Info(fmt.Sprintf("Applying migration to version %d", v))
 				if err := a.applyMigration(db, v); err != nil {
@@ -102,6 +110,38 @@ func (a *App) applyMigration(db *sql.DB, version int) error {
 	switch version {
 	case 2:
 		return a.migrateToV2(db)
+	case 3:
+		return a.migrateToV3(db)
+	case 4:
+		return a.migrateToV4(db)
+	case 5:
+		return a.migrateToV5(db)
+	case 6:
+		return a.migrateToV6(db)
+	case 7:
+		return a.migrateToV7(db)
+	case 8:
+		return a.migrateToV8(db)
+	case 9:
+		return a.migrateToV9(db)
+	case 10:
+		return a.migrateToV10(db)
+	case 11:
+		return a.migrateToV11(db)
+	case 12:
+		return a.migrateToV12(db)
+	case 13:
+		return a.migrateToV13(db)
+	case 14:
+		return a.migrateToV14(db)
+	case 15:
+		return a.migrateToV15(db)
+	case 16:
+		return a.migrateToV16(db)
+	case 17:
+		return a.migrateToV17(db)
+	case 18:
+		return a.migrateToV18(db)
 	default:
 		return fmt.Errorf("unknown migration version: %d", version)
 	}
@@ -232,6 +272,474 @@ func (a *App) migrateToV2(db *sql.DB) error {
 	return err
 }
 
+// ============ SCHEMA V3 (Migration) ============
+// migrateToV3 upgrades from v2 to v3
+// Changes:
+// - Adds song_arrangements table holding user-defined verse orders
+func (a *App) migrateToV3(db *sql.DB) error {
+	slog.Info("Migrating to schema v3")
+
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS song_arrangements (
+			songbook_acronym TEXT NOT NULL,
+			entry_text TEXT NOT NULL,
+			verse_order TEXT NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (songbook_acronym, entry_text)
+		);
+	`); err != nil {
+		return fmt.Errorf("error creating song_arrangements table: %w", err)
+	}
+
+	_, err := db.Exec(`INSERT INTO schema_version (version) VALUES (3)`)
+	return err
+}
+
+// ============ SCHEMA V4 (Migration) ============
+// migrateToV4 upgrades from v3 to v4
+// Changes:
+// - Adds services table for persistent service plans (setlists)
+// - Adds service_items table with the ordered songs of each service
+func (a *App) migrateToV4(db *sql.DB) error {
+	slog.Info("Migrating to schema v4")
+
+	tableScripts := []string{
+		`CREATE TABLE IF NOT EXISTS services (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service_date TEXT NOT NULL,
+			title TEXT NOT NULL DEFAULT '',
+			notes TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS service_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service_id INTEGER NOT NULL,
+			position INTEGER NOT NULL,
+			song_id INTEGER,
+			verse_order TEXT NOT NULL DEFAULT '',
+			notes TEXT NOT NULL DEFAULT '',
+			FOREIGN KEY(service_id) REFERENCES services(id)
+			ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_services_date ON services(service_date);`,
+		`CREATE INDEX IF NOT EXISTS idx_service_items_service_id ON service_items(service_id, position);`,
+	}
+	for _, script := range tableScripts {
+		if _, err := db.Exec(script); err != nil {
+			return fmt.Errorf("error creating v4 schema: %w", err)
+		}
+	}
+
+	_, err := db.Exec(`INSERT INTO schema_version (version) VALUES (4)`)
+	return err
+}
+
+// ============ SCHEMA V5 (Migration) ============
+// migrateToV5 upgrades from v4 to v5
+// Changes:
+// - Adds item_type, title, content and scripture_ref columns to service_items
+// - Existing service items become song items
+func (a *App) migrateToV5(db *sql.DB) error {
+	slog.Info("Migrating to schema v5")
+
+	columns := []struct{ name, def string }{
+		{"item_type", "TEXT NOT NULL DEFAULT 'song'"},
+		{"title", "TEXT NOT NULL DEFAULT ''"},
+		{"content", "TEXT NOT NULL DEFAULT ''"},
+		{"scripture_ref", "TEXT NOT NULL DEFAULT ''"},
+	}
+	for _, col := range columns {
+		if err := a.addColumnIfNotExists(db, "service_items", col.name, col.def); err != nil {
+			return fmt.Errorf("error adding %s column: %w", col.name, err)
+		}
+	}
+
+	_, err := db.Exec(`INSERT INTO schema_version (version) VALUES (5)`)
+	return err
+}
+
+// ============ SCHEMA V6 (Migration) ============
+// migrateToV6 upgrades from v5 to v6
+// Changes:
+// - Adds song_usage table recording when songs were projected or exported
+func (a *App) migrateToV6(db *sql.DB) error {
+	slog.Info("Migrating to schema v6")
+
+	tableScripts := []string{
+		`CREATE TABLE IF NOT EXISTS song_usage (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			songbook_acronym TEXT NOT NULL,
+			entry_text TEXT NOT NULL,
+			used_on TEXT NOT NULL,
+			service_name TEXT NOT NULL DEFAULT '',
+			usage_type TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (songbook_acronym, entry_text, used_on, service_name)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_song_usage_song ON song_usage(songbook_acronym, entry_text);`,
+		`CREATE INDEX IF NOT EXISTS idx_song_usage_used_on ON song_usage(used_on);`,
+	}
+	for _, script := range tableScripts {
+		if _, err := db.Exec(script); err != nil {
+			return fmt.Errorf("error creating v6 schema: %w", err)
+		}
+	}
+
+	_, err := db.Exec(`INSERT INTO schema_version (version) VALUES (6)`)
+	return err
+}
+
+// ============ SCHEMA V7 (Migration) ============
+// migrateToV7 upgrades from v6 to v7
+// Changes:
+// - Adds song_favorites and song_tags tables for user markings of songs
+func (a *App) migrateToV7(db *sql.DB) error {
+	slog.Info("Migrating to schema v7")
+
+	tableScripts := []string{
+		`CREATE TABLE IF NOT EXISTS song_favorites (
+			songbook_acronym TEXT NOT NULL,
+			entry_text TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (songbook_acronym, entry_text)
+		);`,
+		`CREATE TABLE IF NOT EXISTS song_tags (
+			songbook_acronym TEXT NOT NULL,
+			entry_text TEXT NOT NULL,
+			tag TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (songbook_acronym, entry_text, tag)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_song_tags_tag ON song_tags(tag);`,
+	}
+	for _, script := range tableScripts {
+		if _, err := db.Exec(script); err != nil {
+			return fmt.Errorf("error creating v7 schema: %w", err)
+		}
+	}
+
+	_, err := db.Exec(`INSERT INTO schema_version (version) VALUES (7)`)
+	return err
+}
+
+// ============ SCHEMA V8 (Migration) ============
+// migrateToV8 upgrades from v7 to v8
+// Changes:
+// - Adds liturgical_song_rules mapping seasons and feasts to tags or songbook entry ranges
+func (a *App) migrateToV8(db *sql.DB) error {
+	slog.Info("Migrating to schema v8")
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS liturgical_song_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		occasion TEXT NOT NULL,
+		tag TEXT NOT NULL DEFAULT '',
+		songbook_acronym TEXT NOT NULL DEFAULT '',
+		entry_from INTEGER NOT NULL DEFAULT 0,
+		entry_to INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`)
+	if err != nil {
+		return fmt.Errorf("error creating v8 schema: %w", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO schema_version (version) VALUES (8)`)
+	return err
+}
+
+// ============ SCHEMA V9 (Migration) ============
+// migrateToV9 upgrades from v8 to v9
+// Changes:
+// - Adds song_chapters and song_chapter_ranges with the printed chapter structure of a songbook
+func (a *App) migrateToV9(db *sql.DB) error {
+	slog.Info("Migrating to schema v9")
+
+	tableScripts := []string{
+		`CREATE TABLE IF NOT EXISTS song_chapters (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			songbook_acronym TEXT NOT NULL,
+			position INTEGER NOT NULL,
+			section TEXT NOT NULL DEFAULT '',
+			name TEXT NOT NULL,
+			name_d TEXT NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS song_chapter_ranges (
+			chapter_id INTEGER NOT NULL,
+			entry_from INTEGER NOT NULL,
+			entry_to INTEGER NOT NULL,
+			FOREIGN KEY (chapter_id) REFERENCES song_chapters(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_song_chapter_ranges ON song_chapter_ranges(entry_from, entry_to);`,
+	}
+	for _, script := range tableScripts {
+		if _, err := db.Exec(script); err != nil {
+			return fmt.Errorf("error creating v9 schema: %w", err)
+		}
+	}
+
+	_, err := db.Exec(`INSERT INTO schema_version (version) VALUES (9)`)
+	return err
+}
+
+// ============ SCHEMA V10 (Migration) ============
+// migrateToV10 upgrades from v9 to v10
+// Changes:
+// - Adds song_scripture_refs linking songs to bible passages
+// - Passages are stored as book plus start/end verse positions (chapter*1000+verse)
+func (a *App) migrateToV10(db *sql.DB) error {
+	slog.Info("Migrating to schema v10")
+
+	tableScripts := []string{
+		`CREATE TABLE IF NOT EXISTS song_scripture_refs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			songbook_acronym TEXT NOT NULL,
+			entry_text TEXT NOT NULL,
+			book TEXT NOT NULL,
+			start_pos INTEGER NOT NULL,
+			end_pos INTEGER NOT NULL,
+			reference TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_song_scripture_refs_song ON song_scripture_refs(songbook_acronym, entry_text);`,
+		`CREATE INDEX IF NOT EXISTS idx_song_scripture_refs_book ON song_scripture_refs(book, start_pos, end_pos);`,
+	}
+	for _, script := range tableScripts {
+		if _, err := db.Exec(script); err != nil {
+			return fmt.Errorf("error creating v10 schema: %w", err)
+		}
+	}
+
+	_, err := db.Exec(`INSERT INTO schema_version (version) VALUES (10)`)
+	return err
+}
+
+// ============ SCHEMA V11 (Migration) ============
+// migrateToV11 upgrades from v10 to v11
+// Changes:
+// - Adds song_tunes with user-entered tune names and meter overrides
+func (a *App) migrateToV11(db *sql.DB) error {
+	slog.Info("Migrating to schema v11")
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS song_tunes (
+		songbook_acronym TEXT NOT NULL,
+		entry_text TEXT NOT NULL,
+		tune_name TEXT NOT NULL DEFAULT '',
+		meter TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (songbook_acronym, entry_text)
+	);`)
+	if err != nil {
+		return fmt.Errorf("error creating v11 schema: %w", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO schema_version (version) VALUES (11)`)
+	return err
+}
+
+// ============ SCHEMA V12 (Migration) ============
+// migrateToV12 upgrades from v11 to v12
+// Changes:
+// - Adds song_concordance with matching songs across songbooks (side a has the lower acronym)
+func (a *App) migrateToV12(db *sql.DB) error {
+	slog.Info("Migrating to schema v12")
+
+	tableScripts := []string{
+		`CREATE TABLE IF NOT EXISTS song_concordance (
+			songbook_acronym_a TEXT NOT NULL,
+			entry_text_a TEXT NOT NULL,
+			songbook_acronym_b TEXT NOT NULL,
+			entry_text_b TEXT NOT NULL,
+			confidence REAL NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (songbook_acronym_a, entry_text_a, songbook_acronym_b, entry_text_b)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_song_concordance_b ON song_concordance(songbook_acronym_b, entry_text_b);`,
+	}
+	for _, script := range tableScripts {
+		if _, err := db.Exec(script); err != nil {
+			return fmt.Errorf("error creating v12 schema: %w", err)
+		}
+	}
+
+	_, err := db.Exec(`INSERT INTO schema_version (version) VALUES (12)`)
+	return err
+}
+
+// ============ SCHEMA V13 (Migration) ============
+// migrateToV13 upgrades from v12 to v13
+// Changes:
+// - Adds song_neighbors with the precomputed most similar songs of every song
+func (a *App) migrateToV13(db *sql.DB) error {
+	slog.Info("Migrating to schema v13")
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS song_neighbors (
+		songbook_acronym TEXT NOT NULL,
+		entry_text TEXT NOT NULL,
+		neighbor_acronym TEXT NOT NULL,
+		neighbor_entry_text TEXT NOT NULL,
+		score REAL NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (songbook_acronym, entry_text, neighbor_acronym, neighbor_entry_text)
+	);`)
+	if err != nil {
+		return fmt.Errorf("error creating v13 schema: %w", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO schema_version (version) VALUES (13)`)
+	return err
+}
+
+// ============ SCHEMA V14 (Migration) ============
+// migrateToV14 upgrades from v13 to v14
+// Changes:
+// - Adds copyright, ccli_no, song_key and tempo columns to songs
+// - Adds lang, translit, lines_chords and comment columns to verses
+// - Adds song_titles with all OpenLyrics titles and song_themes with themes
+func (a *App) migrateToV14(db *sql.DB) error {
+	slog.Info("Migrating to schema v14")
+
+	columns := []struct{ table, name, def string }{
+		{"songs", "copyright", "TEXT NOT NULL DEFAULT ''"},
+		{"songs", "ccli_no", "TEXT NOT NULL DEFAULT ''"},
+		{"songs", "song_key", "TEXT NOT NULL DEFAULT ''"},
+		{"songs", "tempo", "TEXT NOT NULL DEFAULT ''"},
+		{"verses", "lang", "TEXT NOT NULL DEFAULT ''"},
+		{"verses", "translit", "TEXT NOT NULL DEFAULT ''"},
+		{"verses", "lines_chords", "TEXT NOT NULL DEFAULT ''"},
+		{"verses", "comment", "TEXT NOT NULL DEFAULT ''"},
+	}
+	for _, col := range columns {
+		if err := a.addColumnIfNotExists(db, col.table, col.name, col.def); err != nil {
+			return fmt.Errorf("error adding %s column: %w", col.name, err)
+		}
+	}
+
+	tableScripts := []string{
+		`CREATE TABLE IF NOT EXISTS song_titles (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			song_id INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			title_d TEXT NOT NULL,
+			lang TEXT NOT NULL DEFAULT '',
+			original INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY(song_id) REFERENCES songs(id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS song_themes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			song_id INTEGER NOT NULL,
+			theme TEXT NOT NULL,
+			lang TEXT NOT NULL DEFAULT '',
+			FOREIGN KEY(song_id) REFERENCES songs(id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_song_titles_song_id ON song_titles(song_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_song_themes_song_id ON song_themes(song_id);`,
+	}
+	for _, script := range tableScripts {
+		if _, err := db.Exec(script); err != nil {
+			return fmt.Errorf("error creating v14 schema: %w", err)
+		}
+	}
+
+	_, err := db.Exec(`INSERT INTO schema_version (version) VALUES (14)`)
+	return err
+}
+
+// ============ SCHEMA V15 (Migration) ============
+// migrateToV15 upgrades from v14 to v15
+// Changes:
+// - Adds song_overlays with local edits of imported songs
+func (a *App) migrateToV15(db *sql.DB) error {
+	slog.Info("Migrating to schema v15")
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS song_overlays (
+		songbook_acronym TEXT NOT NULL,
+		entry_text TEXT NOT NULL,
+		field TEXT NOT NULL,
+		verse_name TEXT NOT NULL DEFAULT '',
+		value TEXT NOT NULL,
+		original TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (songbook_acronym, entry_text, field, verse_name)
+	);`)
+	if err != nil {
+		return fmt.Errorf("error creating v15 schema: %w", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO schema_version (version) VALUES (15)`)
+	return err
+}
+
+// ============ SCHEMA V16 (Migration) ============
+// migrateToV16 upgrades from v15 to v16
+// Changes:
+// - Adds song_revisions with a JSON snapshot of every edit of user songs and local edits
+func (a *App) migrateToV16(db *sql.DB) error {
+	slog.Info("Migrating to schema v16")
+
+	tableScripts := []string{
+		`CREATE TABLE IF NOT EXISTS song_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			songbook_acronym TEXT NOT NULL,
+			entry_text TEXT NOT NULL,
+			edited_by TEXT NOT NULL DEFAULT '',
+			snapshot TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_song_revisions_song ON song_revisions(songbook_acronym, entry_text);`,
+	}
+	for _, script := range tableScripts {
+		if _, err := db.Exec(script); err != nil {
+			return fmt.Errorf("error creating v16 schema: %w", err)
+		}
+	}
+
+	_, err := db.Exec(`INSERT INTO schema_version (version) VALUES (16)`)
+	return err
+}
+
+// ============ SCHEMA V17 (Migration) ============
+// migrateToV17 upgrades from v16 to v17
+// Changes:
+// - Adds songbook_acronym and entry_text columns to service_items filled from the songs
+// - song_id of service items is no longer written
+func (a *App) migrateToV17(db *sql.DB) error {
+	slog.Info("Migrating to schema v17")
+
+	for _, column := range []string{"songbook_acronym", "entry_text"} {
+		if err := a.addColumnIfNotExists(db, "service_items", column, "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return fmt.Errorf("error adding %s column: %w", column, err)
+		}
+	}
+	_, err := db.Exec(`
+		UPDATE service_items SET
+			songbook_acronym = (SELECT COALESCE(s.songbook_acronym, '') FROM songs s WHERE s.id = service_items.song_id),
+			entry_text = (SELECT ` + songEntryKeyExpr + ` FROM songs s WHERE s.id = service_items.song_id),
+			song_id = NULL
+		WHERE song_id IN (SELECT id FROM songs)`)
+	if err != nil {
+		return fmt.Errorf("error filling service item song keys: %w", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO schema_version (version) VALUES (17)`)
+	return err
+}
+
+// ============ SCHEMA V18 (Migration) ============
+// migrateToV18 upgrades from v17 to v18
+// Changes:
+// - Adds source column to song_scripture_refs telling imported passages from manual ones
+// - Existing passages count as manual
+func (a *App) migrateToV18(db *sql.DB) error {
+	slog.Info("Migrating to schema v18")
+
+	if err := a.addColumnIfNotExists(db, "song_scripture_refs", "source", "TEXT NOT NULL DEFAULT '"+scriptureRefManual+"'"); err != nil {
+		return fmt.Errorf("error adding source column: %w", err)
+	}
+
+	_, err := db.Exec(`INSERT INTO schema_version (version) VALUES (18)`)
+	return err
+}
+
 // ============ HELPER FUNCTIONS ============
 // columnExists checks if a column exists in a table
 func (a *App) columnExists(db *sql.DB, table, column string) (bool, error) {
@@ -262,9 +770,36 @@ func (a *App) addColumnIfNotExists(db *sql.DB, table, column, columnDef string)
 	return err
 }
 
+// songEntryKeyExpr is the SQL expression matching lookupSongKey's entry text for songs aliased as s
+const songEntryKeyExpr = "COALESCE(NULLIF(s.entry_text, ''), CAST(s.entry AS TEXT))"
+
+// lookupSongKey returns the songbook acronym and entry text identifying a song
+// independently of its row id, which changes whenever the database is refilled.
+func (a *App) lookupSongKey(db *sql.DB, songID int) (string, string, error) {
+	var acronym, entryText string
+	err := db.QueryRow(`
+		SELECT COALESCE(songbook_acronym, ''),
+		       COALESCE(NULLIF(entry_text, ''), CAST(entry AS TEXT))
+		FROM songs WHERE id = ?`, songID).Scan(&acronym, &entryText)
+	if err == sql.ErrNoRows {
+		return "", "", fmt.Errorf("song %d not found", songID)
+	}
+	return acronym, entryText, err
+}
+
 func (a *App) FillDatabase() {
 	a.updateProgress("Plním databázi...", 0)
 
+	var songCount int
+	_ = a.withDB(func(db *sql.DB) error {
+		return db.QueryRow(`SELECT COUNT(*) FROM songs`).Scan(&songCount)
+	})
+	if songCount > 0 {
+		if _, err := a.snapshotDatabase(snapshotReimport); err != nil {
+			slog.Warn("Failed to snapshot database before re-import", "error", err)
+		}
+	}
+
 	_ = a.withDB(func(db *sql.DB) error {
 		// Process EZ songbook
 		if err := a.fillEZSongs(db); err != nil {
@@ -278,8 +813,16 @@ func (a *App) FillDatabase() {
 			return err
 		}
 
+		if _, err := a.applySongOverlays(db); err != nil {
+			slog.Warn("Failed to apply local song edits", "error", err)
+		}
+		if _, err := a.rebuildConcordance(db); err != nil {
+			slog.Warn("Failed to rebuild songbook concordance", "error", err)
+		}
 		return nil
 	})
+	// rebuilt once the chapters are imported as well
+	a.markSimilarSongsStale()
 }
 
 // fillEZSongs processes Evangelický zpěvník songs
@@ -397,6 +940,10 @@ func (a *App) processEZSongFile(db *sql.DB, xmlFile os.DirEntry, songbookAcronym
 		return fmt.Errorf("failed to insert EZ verses: %w", err)
 	}
 
+	if err := a.insertSongMetadata(db, songID, song, xmlFile.Name()); err != nil {
+		return fmt.Errorf("failed to insert EZ metadata: %w", err)
+	}
+
 	slog.Debug("EZ data inserted", "entry", song.Songbook.Entry, "title", song.Title, "file", xmlFile.Name())
 	return nil
 }
@@ -410,15 +957,10 @@ func (a *App) processKKSongFile(db *sql.DB, xmlFile os.DirEntry, songbookAcronym
 		return fmt.Errorf("failed to parse KK XML: %w", err)
 	}
 
-	songID, err := a.insertSongKK(db, song, songbookAcronym)
-	if err != nil {
+	if _, err := a.insertOpenSong(db, song, songbookAcronym, xmlFile.Name()); err != nil {
 		return fmt.Errorf("failed to insert KK song: %w", err)
 	}
 
-	if err := a.insertVersesKK(db, songID, song.Lyrics, xmlFile.Name()); err != nil {
-		return fmt.Errorf("failed to insert KK verses: %w", err)
-	}
-
 	slog.Debug("KK data inserted", "entry", song.HymnNumber, "title", song.Title, "file", xmlFile.Name())
 	return nil
 }
@@ -451,7 +993,7 @@ func parseHymnNumber(hymnStr string) int {
 }
 
 // insertSongKK inserts a KK song record and returns the song ID
-func (a *App) insertSongKK(db *sql.DB, song *SongKK, songbookAcronym string) (int64, error) {
+func (a *App) insertSongKK(db sqlExecer, song *SongKK, songbookAcronym string) (int64, error) {
 	// Remove song number prefix from title (e.g., "065 Litanie..." -> "Litanie...")
 	title := song.Title
 	if idx := strings.Index(title, " "); idx > 0 {
@@ -469,8 +1011,10 @@ func (a *App) insertSongKK(db *sql.DB, song *SongKK, songbookAcronym string) (in
 	entryNum := parseHymnNumber(song.HymnNumber)
 
 	// V3+ - insert with original entry_text (supports "511A", "067b", etc.)
-	result, err := db.Exec(`INSERT INTO songs (songbook_acronym, title, title_d, verse_order, entry, entry_text) VALUES (?, ?, ?, ?, ?, ?)`,
-		songbookAcronym, title, title_d, "", entryNum, song.HymnNumber)
+	result, err := db.Exec(`INSERT INTO songs (songbook_acronym, title, title_d, verse_order, entry, entry_text, copyright, ccli_no, song_key, tempo)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		songbookAcronym, title, title_d, openSongVerseOrder(song.Presentation), entryNum, song.HymnNumber,
+		strings.TrimSpace(song.Copyright), strings.TrimSpace(song.Ccli), strings.TrimSpace(song.Key), strings.TrimSpace(song.Tempo))
 
 	if err != nil {
 		return 0, err
@@ -478,58 +1022,13 @@ func (a *App) insertSongKK(db *sql.DB, song *SongKK, songbookAcronym string) (in
 	return result.LastInsertId()
 }
 
-// insertVersesKK parses and inserts KK verses from plain text with [V1], [V2] markers
-func (a *App) insertVersesKK(db *sql.DB, songID int64, lyrics string, filename string) error {
-	// Split lyrics by verse markers like [V1], [V2], etc.
-	lines := strings.Split(lyrics, "\n")
-	var currentVerseName string
-	var currentVerseLines []string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Check if line is a verse marker
-		if strings.HasPrefix(line, "[V") && strings.Contains(line, "]") {
-			// Save previous verse if exists
-			if currentVerseName != "" && len(currentVerseLines) > 0 {
-				verseText := strings.Join(currentVerseLines, "\n")
-				lines_d := removeDiacritics(verseText)
-				_, err := db.Exec(`INSERT INTO verses (song_id, name, lines, lines_d) VALUES (?, ?, ?, ?)`,
-					songID, currentVerseName, verseText, lines_d)
-				if err != nil {
-					slog.Error("Error inserting KK verse", "file", filename, "verse", currentVerseName, "error", err)
-				}
-			}
-
-			// Start new verse
-			endIdx := strings.Index(line, "]")
-			currentVerseName = strings.ToLower(line[1:endIdx])
-			currentVerseLines = []string{}
-		} else if currentVerseName != "" {
-			// Add line to current verse
-			currentVerseLines = append(currentVerseLines, line)
-		}
-	}
-
-	// Insert last verse
-	if currentVerseName != "" && len(currentVerseLines) > 0 {
-		verseText := strings.Join(currentVerseLines, "\n")
-		lines_d := removeDiacritics(verseText)
-		_, err := db.Exec(`INSERT INTO verses (song_id, name, lines, lines_d) VALUES (?, ?, ?, ?)`,
-			songID, currentVerseName, verseText, lines_d)
-		if err != nil {
-			slog.Error("Error inserting KK verse (last)", "file", filename, "verse", currentVerseName, "error", err)
-		}
-	}
-
-	return nil
+// insertVersesKK parses and inserts verses of OpenSong lyrics with [V1], [C] and similar markers
+func (a *App) insertVersesKK(db sqlExecer, songID int64, lyrics string, filename string) error {
+	return a.insertVerses(db, songID, parseOpenSongLyrics(lyrics), filename)
 }
 
 // insertSong inserts a song record and returns the song ID
-func (a *App) insertSong(db *sql.DB, song *Song, songbookAcronym string) (int64, error) {
+func (a *App) insertSong(db sqlExecer, song *Song, songbookAcronym string) (int64, error) {
 	title_d := removeDiacritics(song.Title)
 
 	// Check if songbook_acronym column exists (V2+ schema)
@@ -537,8 +1036,10 @@ func (a *App) insertSong(db *sql.DB, song *Song, songbookAcronym string) (int64,
 	// Convert entry string to integer for storage in entry column
 	entryNum, _ := strconv.Atoi(song.Songbook.Entry)
 
-	result, err := db.Exec(`INSERT INTO songs (songbook_acronym, title, title_d, verse_order, entry, entry_text) VALUES (?, ?, ?, ?, ?, ?)`,
-		songbookAcronym, song.Title, title_d, song.VerseOrder, entryNum, song.Songbook.Entry)
+	result, err := db.Exec(`INSERT INTO songs (songbook_acronym, title, title_d, verse_order, entry, entry_text, copyright, ccli_no, song_key, tempo)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		songbookAcronym, song.Title, title_d, song.VerseOrder, entryNum, song.Songbook.Entry,
+		strings.TrimSpace(song.Copyright), strings.TrimSpace(song.CcliNo), strings.TrimSpace(song.Key), song.Tempo.String())
 
 	if err != nil {
 		return 0, err
@@ -546,36 +1047,80 @@ func (a *App) insertSong(db *sql.DB, song *Song, songbookAcronym string) (int64,
 	return result.LastInsertId()
 }
 
+// insertFullSong inserts a song with its authors, verses, titles and themes
+// and returns the song ID
+func (a *App) insertFullSong(db sqlExecer, song *Song, songbookAcronym string, filename string) (int64, error) {
+	songID, err := a.insertSong(db, song, songbookAcronym)
+	if err != nil {
+		return 0, err
+	}
+	if err := a.insertAuthors(db, songID, song.Authors, filename); err != nil {
+		return 0, err
+	}
+	if err := a.insertVerses(db, songID, song.Lyrics.Verses, filename); err != nil {
+		return 0, err
+	}
+	return songID, a.insertSongMetadata(db, songID, song, filename)
+}
+
+// insertSongMetadata inserts all titles and themes of a song
+func (a *App) insertSongMetadata(db sqlExecer, songID int64, song *Song, filename string) error {
+	for _, title := range song.Titles {
+		value := strings.TrimSpace(title.Value)
+		if value == "" {
+			continue
+		}
+		_, err := db.Exec(`INSERT INTO song_titles (song_id, title, title_d, lang, original) VALUES (?, ?, ?, ?, ?)`,
+			songID, value, removeDiacritics(value), title.Lang, title.Original)
+		if err != nil {
+			slog.Error("Error inserting title", "file", filename, "error", err)
+			return err
+		}
+	}
+	for _, theme := range song.Themes {
+		value := strings.TrimSpace(theme.Value)
+		if value == "" {
+			continue
+		}
+		_, err := db.Exec(`INSERT INTO song_themes (song_id, theme, lang) VALUES (?, ?, ?)`, songID, value, theme.Lang)
+		if err != nil {
+			slog.Error("Error inserting theme", "file", filename, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
 // insertAuthors inserts all author records for a song
-func (a *App) insertAuthors(db *sql.DB, songID int64, authors []Author, filename string) error {
+func (a *App) insertAuthors(db sqlExecer, songID int64, authors []Author, filename string) error {
 	for _, author := range authors {
 		author_d := removeDiacritics(author.Value)
 		_, err := db.Exec(`INSERT INTO authors (song_id, author_type, author_value, author_value_d) VALUES (?, ?, ?, ?)`,
 			songID, author.Type, author.Value, author_d)
 		if err != nil {
 			slog.Error("Error inserting author", "file", filename, "error", err)
-			continue
+			return err
 		}
 	}
 	return nil
 }
 
 // insertVerses inserts all verse records for a song
-func (a *App) insertVerses(db *sql.DB, songID int64, verses []Verse, filename string) error {
+func (a *App) insertVerses(db sqlExecer, songID int64, verses []Verse, filename string) error {
 	for _, verse := range verses {
 		lines_d := removeDiacritics(verse.Lines)
-		_, err := db.Exec(`INSERT INTO verses (song_id, name, lines, lines_d) VALUES (?, ?, ?, ?)`,
-			songID, verse.Name, verse.Lines, lines_d)
+		_, err := db.Exec(`INSERT INTO verses (song_id, name, lines, lines_d, lang, translit, lines_chords, comment) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			songID, verse.Name, verse.Lines, lines_d, verse.Lang, verse.Translit, verse.Chords, verse.Comment)
 		if err != nil {
 			slog.Error("Error inserting verse", "file", filename, "error", err)
-			continue
+			return err
 		}
 	}
 	return nil
 }
 
 // getOrCreateSongbook retrieves or creates a songbook by acronym (max 10 chars)
-func (a *App) getOrCreateSongbook(db *sql.DB, acronym string, name string) (string, error) {
+func (a *App) getOrCreateSongbook(db sqlQueryExecer, acronym string, name string) (string, error) {
 	if len(acronym) > 10 {
 		return "", fmt.Errorf("songbook acronym must be 10 characters or less")
 	}
@@ -604,12 +1149,50 @@ type songSearchFilter struct {
 	isNumeric      bool
 	hasTextSearch  bool
 	searchLike     string
-	sourceFilter   string // "EZ", "KK", or "" for both
+	sourceFilter   string         // "EZ", "KK", or "" for both
+	tags           []string       // from "tag:<name>" tokens
+	favoritesOnly  bool           // from a "fav:" token
+	chapters       []string       // from "chapter:<id or name>" tokens
+	scripture      []scriptureRef // from a trailing "ref:<reference>"
+	collapse       bool           // hide confident duplicates of songs in another songbook
 }
 
+// Search tokens that filter by user markings instead of searching text
+const (
+	tagSearchPrefix       = "tag:"
+	favoriteSearchPrefix  = "fav:"
+	chapterSearchPrefix   = "chapter:"
+	scriptureSearchPrefix = "ref:"
+)
+
 func newSongSearchFilter(searchPattern string, sourceFilter string) songSearchFilter {
 	f := songSearchFilter{sourceFilter: strings.TrimSpace(sourceFilter)}
-	trimmedPattern := strings.TrimSpace(searchPattern)
+
+	var remaining []string
+	tokens := splitSearchTokens(searchPattern)
+	for i, token := range tokens {
+		lower := strings.ToLower(token)
+		if strings.HasPrefix(lower, scriptureSearchPrefix) {
+			// "ref:" takes the remaining tokens because references contain spaces
+			reference := strings.Join(append([]string{token[len(scriptureSearchPrefix):]}, tokens[i+1:]...), " ")
+			if refs, err := parseScriptureRefs(reference); err == nil {
+				f.scripture = refs
+				break
+			}
+		}
+		switch {
+		case strings.HasPrefix(lower, tagSearchPrefix) && len(lower) > len(tagSearchPrefix):
+			f.tags = append(f.tags, normalizeTag(token[len(tagSearchPrefix):]))
+		case lower == favoriteSearchPrefix:
+			f.favoritesOnly = true
+		case strings.HasPrefix(lower, chapterSearchPrefix) && len(lower) > len(chapterSearchPrefix):
+			f.chapters = append(f.chapters, token[len(chapterSearchPrefix):])
+		default:
+			remaining = append(remaining, token)
+		}
+	}
+
+	trimmedPattern := strings.Join(remaining, " ")
 	if trimmedPattern == "" {
 		return f
 	}
@@ -637,6 +1220,31 @@ func newSongSearchFilter(searchPattern string, sourceFilter string) songSearchFi
 	return f
 }
 
+// splitSearchTokens splits a search pattern at spaces. Double quotes group
+// words into one token, so tag:"první svaté přijímání" keeps its spaces.
+func splitSearchTokens(pattern string) []string {
+	var tokens []string
+	var token strings.Builder
+	quoted := false
+	for _, ch := range pattern {
+		switch {
+		case ch == '"':
+			quoted = !quoted
+		case unicode.IsSpace(ch) && !quoted:
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+		default:
+			token.WriteRune(ch)
+		}
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return tokens
+}
+
 func (f songSearchFilter) whereClause(verseCondition string) string {
 	var conditions []string
 
@@ -645,7 +1253,7 @@ func (f songSearchFilter) whereClause(verseCondition string) string {
 			conditions = append(conditions, "CAST(s.entry AS TEXT) = ?")
 		} else if f.hasTextSearch {
 			conditions = append(conditions, fmt.Sprintf(
-				"(s.title_d LIKE ?\n   OR EXISTS (SELECT 1 FROM authors a WHERE a.song_id = s.id AND a.author_value_d LIKE ?)\n   OR %s\n   OR CAST(s.entry AS TEXT) = ?)",
+				"(s.title_d LIKE ?\n   OR EXISTS (SELECT 1 FROM song_titles st WHERE st.song_id = s.id AND st.title_d LIKE ?)\n   OR EXISTS (SELECT 1 FROM authors a WHERE a.song_id = s.id AND a.author_value_d LIKE ?)\n   OR %s\n   OR CAST(s.entry AS TEXT) = ?)",
 				verseCondition))
 		}
 	}
@@ -654,6 +1262,33 @@ func (f songSearchFilter) whereClause(verseCondition string) string {
 		conditions = append(conditions, "s.songbook_acronym = ?")
 	}
 
+	for range f.tags {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM song_tags t WHERE t.songbook_acronym = COALESCE(s.songbook_acronym, '') AND t.entry_text = "+songEntryKeyExpr+" AND t.tag = ?)")
+	}
+
+	if f.favoritesOnly {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM song_favorites fv WHERE fv.songbook_acronym = COALESCE(s.songbook_acronym, '') AND fv.entry_text = "+songEntryKeyExpr+")")
+	}
+
+	for range f.chapters {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM song_chapters c JOIN song_chapter_ranges r ON r.chapter_id = c.id
+   WHERE c.songbook_acronym = s.songbook_acronym AND s.entry BETWEEN r.entry_from AND r.entry_to
+     AND (CAST(c.id AS TEXT) = ? OR c.name_d LIKE ?))`)
+	}
+
+	if len(f.scripture) > 0 {
+		passages := strings.TrimSuffix(strings.Repeat("(sr.book = ? AND sr.start_pos <= ? AND sr.end_pos >= ?) OR ", len(f.scripture)), " OR ")
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM song_scripture_refs sr WHERE sr.songbook_acronym = COALESCE(s.songbook_acronym, '') AND sr.entry_text = "+songEntryKeyExpr+" AND ("+passages+"))")
+	}
+
+	if f.collapse {
+		conditions = append(conditions, fmt.Sprintf(`NOT EXISTS (SELECT 1 FROM song_concordance c
+   JOIN songs o ON o.songbook_acronym = c.songbook_acronym_a
+               AND COALESCE(NULLIF(o.entry_text, ''), CAST(o.entry AS TEXT)) = c.entry_text_a
+   WHERE c.songbook_acronym_b = COALESCE(s.songbook_acronym, '') AND c.entry_text_b = %s
+     AND c.confidence >= %g)`, songEntryKeyExpr, concordanceCollapseConfidence))
+	}
+
 	if len(conditions) == 0 {
 		return ""
 	}
@@ -666,12 +1301,21 @@ func (f songSearchFilter) queryRows(db *sql.DB, fullQuery string) (*sql.Rows, er
 		if f.isNumeric {
 			args = append(args, f.trimmedPattern)
 		} else if f.hasTextSearch {
-			args = append(args, f.searchLike, f.searchLike, f.searchLike, f.trimmedPattern)
+			args = append(args, f.searchLike, f.searchLike, f.searchLike, f.searchLike, f.trimmedPattern)
 		}
 	}
 	if f.sourceFilter != "" {
 		args = append(args, f.sourceFilter)
 	}
+	for _, tag := range f.tags {
+		args = append(args, tag)
+	}
+	for _, chapter := range f.chapters {
+		args = append(args, chapter, removeDiacritics(chapter)+"%")
+	}
+	for _, ref := range f.scripture {
+		args = append(args, ref.Book, ref.endPos(), ref.startPos())
+	}
 	return db.Query(fullQuery, args...)
 }
 
@@ -693,12 +1337,42 @@ func (a *App) GetSongs(orderBy string, searchPattern string, sourceFilter string
             WHERE song_id = s.id AND author_type = 'words'
             ORDER BY id LIMIT 1),'') AS authorLyric,
     COALESCE(kytara_file, '') AS kytara_file,
-    COALESCE(s.songbook_acronym, '') AS songbook_acronym
+    COALESCE(s.songbook_acronym, '') AS songbook_acronym,
+    COALESCE((SELECT MAX(u.used_on)
+            FROM song_usage u
+            WHERE u.songbook_acronym = COALESCE(s.songbook_acronym, '')
+              AND u.entry_text = ` + songEntryKeyExpr + `),'') AS lastUsed,
+    EXISTS (SELECT 1
+            FROM song_favorites fv
+            WHERE fv.songbook_acronym = COALESCE(s.songbook_acronym, '')
+              AND fv.entry_text = ` + songEntryKeyExpr + `) AS isFavorite,
+    COALESCE((SELECT c.name
+            FROM song_chapters c JOIN song_chapter_ranges r ON r.chapter_id = c.id
+            WHERE c.songbook_acronym = s.songbook_acronym
+              AND s.entry BETWEEN r.entry_from AND r.entry_to
+            ORDER BY c.position LIMIT 1),'') AS chapter,
+    COALESCE((SELECT MIN(c.position)
+            FROM song_chapters c JOIN song_chapter_ranges r ON r.chapter_id = c.id
+            WHERE c.songbook_acronym = s.songbook_acronym
+              AND s.entry BETWEEN r.entry_from AND r.entry_to), 999999) AS chapterPosition,
+    COALESCE((SELECT GROUP_CONCAT(c.songbook_acronym_b || ' ' || c.entry_text_b, ', ')
+            FROM song_concordance c
+            WHERE c.songbook_acronym_a = COALESCE(s.songbook_acronym, '')
+              AND c.entry_text_a = ` + songEntryKeyExpr + `),'') AS alsoInB,
+    COALESCE((SELECT GROUP_CONCAT(c.songbook_acronym_a || ' ' || c.entry_text_a, ', ')
+            FROM song_concordance c
+            WHERE c.songbook_acronym_b = COALESCE(s.songbook_acronym, '')
+              AND c.entry_text_b = ` + songEntryKeyExpr + `),'') AS alsoInA,
+    EXISTS (SELECT 1
+            FROM song_overlays o
+            WHERE o.songbook_acronym = COALESCE(s.songbook_acronym, '')
+              AND o.entry_text = ` + songEntryKeyExpr + `) AS locallyModified
   FROM songs s
   JOIN verses v ON s.id = v.song_id
 `
 
 		filter := newSongSearchFilter(searchPattern, sourceFilter)
+		filter.collapse = a.status.CollapseDuplicates && filter.sourceFilter == ""
 		query_where := filter.whereClause("v.lines_d LIKE ?")
 
 		sortOption := normalizeSortingOption(orderBy)
@@ -721,16 +1395,18 @@ order by ` + orderColumn + `, v.name`
 
 		for rows.Next() {
 			var (
-				title, allVerses, authorMusic, authorLyric, kytaraFile, songbookAcronym string
-				id, entry                                                               int
+				title, allVerses, authorMusic, authorLyric, kytaraFile, songbookAcronym, lastUsed, chapter string
+				alsoInB, alsoInA                                                                           string
+				id, entry, chapterPosition                                                                 int
+				isFavorite, locallyModified                                                                bool
 			)
-			err := rows.Scan(&id, &entry, &title, &allVerses, &authorMusic, &authorLyric, &kytaraFile, &songbookAcronym)
+			err := rows.Scan(&id, &entry, &title, &allVerses, &authorMusic, &authorLyric, &kytaraFile, &songbookAcronym, &lastUsed, &isFavorite, &chapter, &chapterPosition, &alsoInB, &alsoInA, &locallyModified)
 			if err != nil {
 				slog.Error(fmt.Sprintf("Error scanning row: %s", err))
 				return err
 			}
 
-			result = append(result, dtoSong{Id: id, Entry: entry, Title: title, Verses: allVerses, AuthorMusic: authorMusic, AuthorLyric: authorLyric, KytaraFile: kytaraFile, SongbookAcronym: songbookAcronym})
+			result = append(result, dtoSong{Id: id, Entry: entry, Title: title, Verses: allVerses, AuthorMusic: authorMusic, AuthorLyric: authorLyric, KytaraFile: kytaraFile, SongbookAcronym: songbookAcronym, LastUsed: lastUsed, IsFavorite: isFavorite, Chapter: chapter, AlsoIn: joinNonEmpty(", ", alsoInA, alsoInB), LocallyModified: locallyModified})
 		}
 		return nil
 	})
@@ -829,23 +1505,21 @@ func (a *App) GetSongAuthors(songId int) ([]Author, error) {
 
 // GetSongVerses returns the concatenated verses (lines) for a given song id.
 // Verses are concatenated using '===' as separator to match frontend expectations.
+// A custom arrangement stored for the song determines the verse sequence.
 func (a *App) GetSongVerses(songId int) (string, error) {
 	var verses []string
 	err := a.withDB(func(db *sql.DB) error {
-		rows, err := db.Query(`SELECT lines FROM verses WHERE song_id = ? ORDER BY id`, songId)
+		stored, err := a.loadSongVerses(db, songId)
+		if err != nil {
+			return err
+		}
+		_, custom, err := a.loadSongVerseOrders(db, songId)
 		if err != nil {
-			slog.Error(fmt.Sprintf("Error querying verses: %s", err))
 			return err
 		}
-		defer rows.Close()
 
-		for rows.Next() {
-			var lines string
-			if err := rows.Scan(&lines); err != nil {
-				slog.Error(fmt.Sprintf("Error scanning verse row: %s", err))
-				return err
-			}
-			verses = append(verses, lines)
+		for _, v := range arrangeVerses(stored, custom) {
+			verses = append(verses, v.Lines)
 		}
 		return nil
 	})
@@ -857,41 +1531,41 @@ func (a *App) GetSongVerses(songId int) (string, error) {
 
 // GetSongProjection returns JSON containing verse_order and verses (name + lines)
 // Example: { "verse_order": "c v1 c v2", "verses": [{"name":"v1","lines":"..."}, ...] }
+// verse_order is the custom arrangement when one is stored, otherwise the imported order.
 func (a *App) GetSongProjection(songId int) (string, error) {
-	var verseOrder string
-	// read verse_order from songs table
+	return a.GetSongProjectionWithOrder(songId, "")
+}
+
+// GetSongProjectionWithOrder works like GetSongProjection but lets the caller
+// override the verse order for a single service. The override is validated
+// against the verse names of the song; an empty override falls back to the
+// stored arrangement.
+func (a *App) GetSongProjectionWithOrder(songId int, verseOrder string) (string, error) {
+	var (
+		original, effective string
+		verses              []songVerse
+	)
 	err := a.withDB(func(db *sql.DB) error {
-		row := db.QueryRow(`SELECT verse_order FROM songs WHERE id = ?`, songId)
-		if err := row.Scan(&verseOrder); err != nil {
-			if err == sql.ErrNoRows {
-				verseOrder = ""
-				return nil
-			}
-			slog.Error(fmt.Sprintf("Error reading verse_order: %s", err))
+		var custom string
+		var err error
+		original, custom, err = a.loadSongVerseOrders(db, songId)
+		if err != nil {
 			return err
 		}
-		return nil
-	})
-	if err != nil {
-		return "", err
-	}
-
-	verses := []map[string]string{}
-	err = a.withDB(func(db *sql.DB) error {
-		rows, err := db.Query(`SELECT name, lines FROM verses WHERE song_id = ? ORDER BY id`, songId)
+		verses, err = a.loadSongVerses(db, songId)
 		if err != nil {
-			slog.Error(fmt.Sprintf("Error querying verses: %s", err))
 			return err
 		}
-		defer rows.Close()
 
-		for rows.Next() {
-			var name, lines string
-			if err := rows.Scan(&name, &lines); err != nil {
-				slog.Error(fmt.Sprintf("Error scanning verse row: %s", err))
+		effective = original
+		if custom != "" {
+			effective = custom
+		}
+		if strings.TrimSpace(verseOrder) != "" {
+			effective, err = validateVerseOrder(verseOrder, verses)
+			if err != nil {
 				return err
 			}
-			verses = append(verses, map[string]string{"name": name, "lines": lines})
 		}
 		return nil
 	})
@@ -900,8 +1574,9 @@ func (a *App) GetSongProjection(songId int) (string, error) {
 	}
 
 	payload := map[string]interface{}{
-		"verse_order": verseOrder,
-		"verses":      verses,
+		"verse_order":          effective,
+		"original_verse_order": original,
+		"verses":               verses,
 	}
 	b, err := json.Marshal(payload)
 	if err != nil {
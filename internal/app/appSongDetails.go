@@ -0,0 +1,133 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// exportSong is a song loaded with everything needed to write it to another format
+type exportSong struct {
+	dtoSongDetails
+	Title           string
+	SongbookAcronym string
+	SongbookName    string
+	EntryText       string
+	VerseOrder      string // the custom arrangement when stored, otherwise the imported order
+	Authors         []Author
+}
+
+// GetSongDetails returns the imported metadata of a song: all titles, themes,
+// copyright, CCLI number, key, tempo and verses with language and chords
+func (a *App) GetSongDetails(songId int) (dtoSongDetails, error) {
+	var result dtoSongDetails
+	err := a.withDB(func(db *sql.DB) error {
+		var err error
+		result, err = a.loadSongDetails(db, songId)
+		return err
+	})
+	return result, err
+}
+
+// loadSongDetails reads the metadata and detailed verses of a song
+func (a *App) loadSongDetails(db *sql.DB, songID int) (dtoSongDetails, error) {
+	result := dtoSongDetails{Titles: []string{}, Themes: []string{}, Verses: []dtoVerseDetail{}}
+	var title string
+	err := db.QueryRow(`SELECT COALESCE(title, ''), copyright, ccli_no, song_key, tempo FROM songs WHERE id = ?`, songID).
+		Scan(&title, &result.Copyright, &result.CcliNo, &result.Key, &result.Tempo)
+	if err == sql.ErrNoRows {
+		return result, fmt.Errorf("song %d not found", songID)
+	}
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error querying song details: %s", err))
+		return result, err
+	}
+
+	result.Titles = append(result.Titles, title)
+	titles, err := a.querySongStrings(db, `SELECT title FROM song_titles WHERE song_id = ? AND title <> ? ORDER BY id`, songID, title)
+	if err != nil {
+		return result, err
+	}
+	result.Titles = append(result.Titles, titles...)
+	if result.Themes, err = a.querySongStrings(db, `SELECT theme FROM song_themes WHERE song_id = ? ORDER BY id`, songID); err != nil {
+		return result, err
+	}
+
+	rows, err := db.Query(`SELECT COALESCE(name, ''), COALESCE(lines, ''), lang, translit, lines_chords, comment
+		FROM verses WHERE song_id = ? ORDER BY id`, songID)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error querying verses: %s", err))
+		return result, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v dtoVerseDetail
+		if err := rows.Scan(&v.Name, &v.Lines, &v.Lang, &v.Translit, &v.Chords, &v.Comment); err != nil {
+			slog.Error(fmt.Sprintf("Error scanning verse row: %s", err))
+			return result, err
+		}
+		result.Verses = append(result.Verses, v)
+	}
+	return result, rows.Err()
+}
+
+// loadExportSong reads a song with its songbook, authors and effective verse order
+func (a *App) loadExportSong(db *sql.DB, songID int) (*exportSong, error) {
+	details, err := a.loadSongDetails(db, songID)
+	if err != nil {
+		return nil, err
+	}
+	song := &exportSong{dtoSongDetails: details, Title: details.Titles[0]}
+	err = db.QueryRow(`
+		SELECT COALESCE(s.songbook_acronym, ''), COALESCE(b.name, ''), `+songEntryKeyExpr+`
+		FROM songs s LEFT JOIN songbooks b ON b.songbook_acronym = s.songbook_acronym
+		WHERE s.id = ?`, songID).Scan(&song.SongbookAcronym, &song.SongbookName, &song.EntryText)
+	if err != nil {
+		return nil, err
+	}
+
+	original, custom, err := a.loadSongVerseOrders(db, songID)
+	if err != nil {
+		return nil, err
+	}
+	song.VerseOrder = original
+	if custom != "" {
+		song.VerseOrder = custom
+	}
+
+	rows, err := db.Query(`SELECT author_type, author_value FROM authors WHERE song_id = ? ORDER BY id`, songID)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error querying authors: %s", err))
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var author Author
+		if err := rows.Scan(&author.Type, &author.Value); err != nil {
+			return nil, err
+		}
+		song.Authors = append(song.Authors, author)
+	}
+	return song, rows.Err()
+}
+
+// querySongStrings returns the single string column of all rows of a query
+func (a *App) querySongStrings(db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error querying data: %s", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			slog.Error(fmt.Sprintf("Error scanning row: %s", err))
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
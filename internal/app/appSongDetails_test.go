@@ -0,0 +1,63 @@
+package app
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGetSongDetails(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+
+	app.songBookDir = t.TempDir()
+	ezDir := filepath.Join(app.songBookDir, "EZ")
+	if err := os.MkdirAll(ezDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", "song-openlyrics-09.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ezDir, "song.xml"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(ezDir)
+	if err := app.withDB(func(db *sql.DB) error { return app.processEZSongFile(db, entries[0], "EZ") }); err != nil {
+		t.Fatalf("processEZSongFile: %v", err)
+	}
+
+	songs, err := app.GetSongs("title", "", "")
+	if err != nil || len(songs) != 1 {
+		t.Fatalf("GetSongs: %v %+v", err, songs)
+	}
+	details, err := app.GetSongDetails(songs[0].Id)
+	if err != nil {
+		t.Fatalf("GetSongDetails: %v", err)
+	}
+	if !reflect.DeepEqual(details.Titles, []string{"Pán je můj pastýř", "The Lord's My Shepherd"}) {
+		t.Errorf("unexpected titles: %v", details.Titles)
+	}
+	if !reflect.DeepEqual(details.Themes, []string{"Důvěra", "Trust"}) {
+		t.Errorf("unexpected themes: %v", details.Themes)
+	}
+	if details.Copyright != "Public Domain" || details.CcliNo != "4591236" || details.Key != "D" || details.Tempo != "90 bpm" {
+		t.Errorf("unexpected metadata: %+v", details)
+	}
+	if len(details.Verses) != 2 || details.Verses[0].Comment != "pomalu" || details.Verses[1].Translit != "la" ||
+		details.Verses[1].Chords != "[Em7]Goodness and mercy" {
+		t.Errorf("unexpected verses: %+v", details.Verses)
+	}
+
+	// alternate titles are searchable
+	songs, _ = app.GetSongs("title", "shepherd", "")
+	if len(songs) != 1 {
+		t.Errorf("expected song found by its original title, got %d songs", len(songs))
+	}
+
+	if _, err := app.GetSongDetails(99999); err == nil {
+		t.Error("expected error for unknown song")
+	}
+}
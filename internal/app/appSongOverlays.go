@@ -0,0 +1,334 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Fields of imported songs that can be edited locally
+const (
+	overlayTitle     = "title"
+	overlayCopyright = "copyright"
+	overlayLines     = "lines"
+	overlayChords    = "chords"
+)
+
+// songOverlayFields maps an overlay field to the queries reading and writing
+// it. Verse fields take the verse name as the last argument, folded fields
+// also store the value without diacritics for searching.
+var songOverlayFields = map[string]struct {
+	read, write   string
+	verse, folded bool
+}{
+	overlayTitle: {
+		read:   `SELECT COALESCE(title, '') FROM songs WHERE id = ?`,
+		write:  `UPDATE songs SET title = ?, title_d = ? WHERE id = ?`,
+		folded: true,
+	},
+	overlayCopyright: {
+		read:  `SELECT copyright FROM songs WHERE id = ?`,
+		write: `UPDATE songs SET copyright = ? WHERE id = ?`,
+	},
+	overlayLines: {
+		read:   `SELECT COALESCE(lines, '') FROM verses WHERE song_id = ? AND name = ? ORDER BY id LIMIT 1`,
+		write:  `UPDATE verses SET lines = ?, lines_d = ? WHERE song_id = ? AND name = ?`,
+		verse:  true,
+		folded: true,
+	},
+	overlayChords: {
+		read:  `SELECT lines_chords FROM verses WHERE song_id = ? AND name = ? ORDER BY id LIMIT 1`,
+		write: `UPDATE verses SET lines_chords = ? WHERE song_id = ? AND name = ?`,
+		verse: true,
+	},
+}
+
+// songOverlay is one locally edited field of an imported song
+type songOverlay struct {
+	SongbookAcronym string
+	EntryText       string
+	Field           string
+	VerseName       string
+	Value           string
+	Original        string
+}
+
+// readSongField returns the current value of an overlay field of a song
+func readSongField(tx *sql.Tx, songID int, field, verseName string) (string, error) {
+	spec, ok := songOverlayFields[field]
+	if !ok {
+		return "", fmt.Errorf("unknown song field %q", field)
+	}
+	args := []interface{}{songID}
+	if spec.verse {
+		args = append(args, verseName)
+	}
+	var value string
+	err := tx.QueryRow(spec.read, args...).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("verse %s not found", verseName)
+	}
+	return value, err
+}
+
+// writeSongField stores a value of an overlay field in the song tables
+func writeSongField(tx *sql.Tx, songID int, field, verseName, value string) error {
+	spec, ok := songOverlayFields[field]
+	if !ok {
+		return fmt.Errorf("unknown song field %q", field)
+	}
+	args := []interface{}{value}
+	if spec.folded {
+		args = append(args, removeDiacritics(value))
+	}
+	args = append(args, songID)
+	if spec.verse {
+		args = append(args, verseName)
+	}
+	_, err := tx.Exec(spec.write, args...)
+	return err
+}
+
+// loadSongOverlays returns the overlays of one song, or of all songs when
+// acronym is empty
+func loadSongOverlays(db *sql.DB, acronym, entryText string) ([]songOverlay, error) {
+	query := `SELECT songbook_acronym, entry_text, field, verse_name, value, original FROM song_overlays`
+	var args []interface{}
+	if acronym != "" {
+		query += ` WHERE songbook_acronym = ? AND entry_text = ?`
+		args = append(args, acronym, entryText)
+	}
+	rows, err := db.Query(query+` ORDER BY songbook_acronym, entry_text, verse_name, field`, args...)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error querying song overlays: %s", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	overlays := []songOverlay{}
+	for rows.Next() {
+		var o songOverlay
+		if err := rows.Scan(&o.SongbookAcronym, &o.EntryText, &o.Field, &o.VerseName, &o.Value, &o.Original); err != nil {
+			return nil, err
+		}
+		overlays = append(overlays, o)
+	}
+	return overlays, rows.Err()
+}
+
+// applySongOverlays writes all local edits on top of freshly imported songs.
+// The imported value is remembered as the original for reverting; fields
+// that already carry the edited value keep their original. It returns the
+// number of applied fields.
+func (a *App) applySongOverlays(db *sql.DB) (int, error) {
+	overlays, err := loadSongOverlays(db, "", "")
+	if err != nil || len(overlays) == 0 {
+		return 0, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	applied := 0
+	for _, o := range overlays {
+		var songIDs []int
+		rows, err := tx.Query(`SELECT id FROM songs s WHERE s.songbook_acronym = ? AND `+songEntryKeyExpr+` = ?`, o.SongbookAcronym, o.EntryText)
+		if err != nil {
+			return applied, err
+		}
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return applied, err
+			}
+			songIDs = append(songIDs, id)
+		}
+		rows.Close()
+
+		for _, songID := range songIDs {
+			current, err := readSongField(tx, songID, o.Field, o.VerseName)
+			if err != nil {
+				slog.Warn("Skipping local edit of a missing song field", "song", o.SongbookAcronym+" "+o.EntryText, "field", o.Field, "verse", o.VerseName)
+				continue
+			}
+			if current != o.Value {
+				if _, err := tx.Exec(`UPDATE song_overlays SET original = ? WHERE songbook_acronym = ? AND entry_text = ? AND field = ? AND verse_name = ?`,
+					current, o.SongbookAcronym, o.EntryText, o.Field, o.VerseName); err != nil {
+					return applied, err
+				}
+				if err := writeSongField(tx, songID, o.Field, o.VerseName, o.Value); err != nil {
+					return applied, err
+				}
+			}
+			applied++
+		}
+	}
+	return applied, tx.Commit()
+}
+
+// saveSongOverlayField stores value as a local edit of a song field. A value
+// equal to the imported one removes the edit instead.
+func saveSongOverlayField(tx *sql.Tx, songID int, acronym, entryText, field, verseName, value string) error {
+	var original string
+	err := tx.QueryRow(`SELECT original FROM song_overlays WHERE songbook_acronym = ? AND entry_text = ? AND field = ? AND verse_name = ?`,
+		acronym, entryText, field, verseName).Scan(&original)
+	hasOverlay := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if !hasOverlay {
+		if original, err = readSongField(tx, songID, field, verseName); err != nil {
+			return err
+		}
+	}
+
+	if value == original {
+		if hasOverlay {
+			if _, err := tx.Exec(`DELETE FROM song_overlays WHERE songbook_acronym = ? AND entry_text = ? AND field = ? AND verse_name = ?`,
+				acronym, entryText, field, verseName); err != nil {
+				return err
+			}
+		}
+		return writeSongField(tx, songID, field, verseName, original)
+	}
+
+	_, err = tx.Exec(`INSERT OR REPLACE INTO song_overlays (songbook_acronym, entry_text, field, verse_name, value, original, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`, acronym, entryText, field, verseName, value, original)
+	if err != nil {
+		return err
+	}
+	return writeSongField(tx, songID, field, verseName, value)
+}
+
+// SaveSongLocalEdits stores corrections of an imported EZ or KK song as local
+// edits that survive refilling the database. The title, copyright and the
+// lines and chords of the given verses are compared with the imported data;
+// verses cannot be added or removed. Chords left unchanged while the lines of
+// a verse change are dropped as they no longer match. It returns the number
+// of locally modified fields of the song.
+func (a *App) SaveSongLocalEdits(song dtoSongDraft) (int, error) {
+	title := strings.TrimSpace(song.Title)
+	if title == "" {
+		return 0, fmt.Errorf("song title is required")
+	}
+
+	modified := 0
+	err := a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, song.Id)
+		if err != nil {
+			return err
+		}
+		if !isBuiltinSongbook(acronym) {
+			return fmt.Errorf("songbook %s is editable, save the song instead", acronym)
+		}
+		if err := a.ensureSongBaseline(db, song.Id); err != nil {
+			return err
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		type fieldValue struct{ field, verse, value string }
+		values := []fieldValue{{overlayTitle, "", title}, {overlayCopyright, "", strings.TrimSpace(song.Copyright)}}
+		for _, verse := range song.Verses {
+			name := strings.ToLower(strings.TrimSpace(verse.Name))
+			lines, chords := trimVerseLines(verse.Lines), trimVerseLines(verse.Chords)
+			if lines == "" {
+				return fmt.Errorf("verse %s is empty", name)
+			}
+			currentLines, err := readSongField(tx, song.Id, overlayLines, name)
+			if err != nil {
+				return err
+			}
+			currentChords, err := readSongField(tx, song.Id, overlayChords, name)
+			if err != nil {
+				return err
+			}
+			if lines != currentLines && chords == currentChords {
+				chords = ""
+			}
+			values = append(values, fieldValue{overlayLines, name, lines}, fieldValue{overlayChords, name, chords})
+		}
+
+		for _, v := range values {
+			if err := saveSongOverlayField(tx, song.Id, acronym, entryText, v.field, v.verse, v.value); err != nil {
+				return err
+			}
+		}
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM song_overlays WHERE songbook_acronym = ? AND entry_text = ?`,
+			acronym, entryText).Scan(&modified); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		a.markSimilarSongsStale()
+		return a.recordSongRevision(db, song.Id, song.EditedBy)
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Saving local edits of song %d failed: %s", song.Id, err))
+		return 0, err
+	}
+	return modified, nil
+}
+
+// GetSongLocalEdits lists the locally modified fields of a song
+func (a *App) GetSongLocalEdits(songId int) ([]dtoSongOverlay, error) {
+	result := []dtoSongOverlay{}
+	err := a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		overlays, err := loadSongOverlays(db, acronym, entryText)
+		if err != nil {
+			return err
+		}
+		for _, o := range overlays {
+			result = append(result, dtoSongOverlay{Field: o.Field, VerseName: o.VerseName, Value: o.Value, Original: o.Original})
+		}
+		return nil
+	})
+	return result, err
+}
+
+// RevertSongLocalEdits restores the imported data of a song and drops its local edits
+func (a *App) RevertSongLocalEdits(songId int) error {
+	return a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		overlays, err := loadSongOverlays(db, acronym, entryText)
+		if err != nil {
+			return err
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		for _, o := range overlays {
+			if err := writeSongField(tx, songId, o.Field, o.VerseName, o.Original); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(`DELETE FROM song_overlays WHERE songbook_acronym = ? AND entry_text = ?`, acronym, entryText); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		a.markSimilarSongsStale()
+		return a.recordSongRevision(db, songId, "")
+	})
+}
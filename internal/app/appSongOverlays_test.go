@@ -0,0 +1,135 @@
+package app
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// findSong returns the only song of a songbook listing
+func findSong(t *testing.T, app *App, acronym string) dtoSong {
+	t.Helper()
+	songs, err := app.GetSongs("entry", "", acronym)
+	if err != nil || len(songs) != 1 {
+		t.Fatalf("GetSongs(%s): %v %+v", acronym, err, songs)
+	}
+	return songs[0]
+}
+
+func TestSongLocalEdits_SurviveRefill(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := importOpenLyricsSample(t, app)
+
+	draft, err := app.GetSongDraft(songID)
+	if err != nil {
+		t.Fatalf("GetSongDraft: %v", err)
+	}
+	originalChords := draft.Verses[0].Chords
+	draft.Title = "Hospodin je můj pastýř"
+	draft.Verses[0].Lines = strings.Replace(draft.Verses[0].Lines, "nechybí", "nebude chybět", 1)
+	modified, err := app.SaveSongLocalEdits(draft)
+	if err != nil {
+		t.Fatalf("SaveSongLocalEdits: %v", err)
+	}
+	// title, lines of v1 and its chords, which no longer match the lines
+	if modified != 3 {
+		t.Errorf("modified = %d, want 3", modified)
+	}
+
+	song := findSong(t, app, "EZ")
+	if !song.LocallyModified || song.Title != "Hospodin je můj pastýř" || !strings.Contains(song.Verses, "nebude chybět") {
+		t.Errorf("unexpected song after edit %+v", song)
+	}
+
+	// a refill imports the upstream song again and reapplies the edits
+	err = app.withDB(func(db *sql.DB) error {
+		for _, table := range []string{"verses", "authors", "song_titles", "song_themes", "songs"} {
+			if _, err := db.Exec(`DELETE FROM ` + table); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.FillDatabase()
+	song = findSong(t, app, "EZ")
+	if !song.LocallyModified || song.Title != "Hospodin je můj pastýř" || !strings.Contains(song.Verses, "nebude chybět") {
+		t.Errorf("edits should be reapplied after refill, got %+v", song)
+	}
+	details, err := app.GetSongDetails(song.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Verses[0].Chords != "" {
+		t.Errorf("stale chords should be dropped, got %q", details.Verses[0].Chords)
+	}
+
+	edits, err := app.GetSongLocalEdits(song.Id)
+	if err != nil || len(edits) != 3 {
+		t.Fatalf("GetSongLocalEdits: %v %+v", err, edits)
+	}
+	for _, edit := range edits {
+		if edit.Field == overlayTitle && edit.Original != "Pán je můj pastýř" {
+			t.Errorf("original title = %q", edit.Original)
+		}
+	}
+
+	if err := app.RevertSongLocalEdits(song.Id); err != nil {
+		t.Fatalf("RevertSongLocalEdits: %v", err)
+	}
+	song = findSong(t, app, "EZ")
+	if song.LocallyModified || song.Title != "Pán je můj pastýř" || strings.Contains(song.Verses, "nebude chybět") {
+		t.Errorf("revert should restore the imported song, got %+v", song)
+	}
+	details, _ = app.GetSongDetails(song.Id)
+	if details.Verses[0].Chords != originalChords {
+		t.Errorf("chords after revert = %q, want %q", details.Verses[0].Chords, originalChords)
+	}
+}
+
+func TestSaveSongLocalEdits_UnchangedAndInvalid(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := importOpenLyricsSample(t, app)
+
+	draft, err := app.GetSongDraft(songID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	draft.Copyright = "Upraveno"
+	if modified, err := app.SaveSongLocalEdits(draft); err != nil || modified != 1 {
+		t.Fatalf("SaveSongLocalEdits: %d, %v", modified, err)
+	}
+	// saving the imported value again drops the edit
+	draft.Copyright = "Public Domain"
+	if modified, err := app.SaveSongLocalEdits(draft); err != nil || modified != 0 {
+		t.Errorf("edit equal to upstream should be removed, got %d, %v", modified, err)
+	}
+	if findSong(t, app, "EZ").LocallyModified {
+		t.Error("song without edits must not be marked as modified")
+	}
+
+	invalid := draft
+	invalid.Verses = []dtoVerseDetail{{Name: "v9", Lines: "neexistuje"}}
+	if _, err := app.SaveSongLocalEdits(invalid); err == nil {
+		t.Error("unknown verse should fail")
+	}
+	invalid.Verses = []dtoVerseDetail{{Name: "v1", Lines: " "}}
+	if _, err := app.SaveSongLocalEdits(invalid); err == nil {
+		t.Error("empty verse should fail")
+	}
+
+	if err := app.CreateSongbook("SBOR", "Zpěvník sboru"); err != nil {
+		t.Fatal(err)
+	}
+	ownID, err := app.SaveSong(dtoSongDraft{SongbookAcronym: "SBOR", Title: "Píseň", Verses: []dtoVerseDetail{{Name: "v1", Lines: "Text"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.SaveSongLocalEdits(dtoSongDraft{Id: ownID, Title: "Jiná"}); err == nil {
+		t.Error("songs of user songbooks are edited directly, not as overlays")
+	}
+}
@@ -0,0 +1,311 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Kinds of differences between two song revisions
+const (
+	revisionChanged = "changed"
+	revisionAdded   = "added"
+	revisionRemoved = "removed"
+)
+
+// recordSongRevision stores the current state of a song as a new revision.
+// Nothing is stored when the song equals its latest revision.
+func (a *App) recordSongRevision(db *sql.DB, songID int, editedBy string) error {
+	acronym, entryText, err := a.lookupSongKey(db, songID)
+	if err != nil {
+		return err
+	}
+	draft, err := a.loadSongDraft(db, songID)
+	if err != nil {
+		return err
+	}
+	draft.Id, draft.EditedBy = 0, ""
+	snapshot, err := json.Marshal(draft)
+	if err != nil {
+		return err
+	}
+
+	var last string
+	err = db.QueryRow(`SELECT snapshot FROM song_revisions WHERE songbook_acronym = ? AND entry_text = ? ORDER BY id DESC LIMIT 1`,
+		acronym, entryText).Scan(&last)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if last == string(snapshot) {
+		return nil
+	}
+	_, err = db.Exec(`INSERT INTO song_revisions (songbook_acronym, entry_text, edited_by, snapshot) VALUES (?, ?, ?, ?)`,
+		acronym, entryText, strings.TrimSpace(editedBy), string(snapshot))
+	return err
+}
+
+// ensureSongBaseline records the state of a song before its first edit, so
+// the history always contains the version the edits started from
+func (a *App) ensureSongBaseline(db *sql.DB, songID int) error {
+	acronym, entryText, err := a.lookupSongKey(db, songID)
+	if err != nil {
+		return err
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM song_revisions WHERE songbook_acronym = ? AND entry_text = ?`,
+		acronym, entryText).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return a.recordSongRevision(db, songID, "")
+}
+
+// loadSongRevision returns the key and the snapshot of a revision
+func loadSongRevision(db *sql.DB, revisionID int) (acronym, entryText string, draft dtoSongDraft, err error) {
+	var snapshot string
+	err = db.QueryRow(`SELECT songbook_acronym, entry_text, snapshot FROM song_revisions WHERE id = ?`, revisionID).
+		Scan(&acronym, &entryText, &snapshot)
+	if err == sql.ErrNoRows {
+		return "", "", draft, fmt.Errorf("revision %d not found", revisionID)
+	}
+	if err != nil {
+		return "", "", draft, err
+	}
+	err = json.Unmarshal([]byte(snapshot), &draft)
+	return acronym, entryText, draft, err
+}
+
+// GetSongRevisions lists the stored revisions of a song, the newest first
+func (a *App) GetSongRevisions(songId int) ([]dtoSongRevision, error) {
+	revisions := []dtoSongRevision{}
+	err := a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		rows, err := db.Query(`SELECT id, COALESCE(created_at, ''), edited_by, snapshot FROM song_revisions
+			WHERE songbook_acronym = ? AND entry_text = ? ORDER BY id DESC`, acronym, entryText)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var r dtoSongRevision
+			var snapshot string
+			if err := rows.Scan(&r.Id, &r.CreatedAt, &r.EditedBy, &snapshot); err != nil {
+				return err
+			}
+			var draft dtoSongDraft
+			if err := json.Unmarshal([]byte(snapshot), &draft); err != nil {
+				return err
+			}
+			r.Title = draft.Title
+			revisions = append(revisions, r)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error loading revisions of song %d: %s", songId, err))
+	}
+	return revisions, err
+}
+
+// GetDeletedSongs lists the deleted songs of a songbook that still have a
+// revision history, each with its latest revision for RestoreSongRevision
+func (a *App) GetDeletedSongs(songbookAcronym string) ([]dtoDeletedSong, error) {
+	deleted := []dtoDeletedSong{}
+	err := a.withDB(func(db *sql.DB) error {
+		rows, err := db.Query(`SELECT r.id, r.entry_text, COALESCE(r.created_at, ''), r.snapshot FROM song_revisions r
+			WHERE r.songbook_acronym = ?1
+			  AND r.id = (SELECT MAX(id) FROM song_revisions WHERE songbook_acronym = r.songbook_acronym AND entry_text = r.entry_text)
+			  AND NOT EXISTS (SELECT 1 FROM songs s WHERE s.songbook_acronym = ?1 AND `+songEntryKeyExpr+` = r.entry_text)
+			ORDER BY r.id DESC`, songbookAcronym)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var d dtoDeletedSong
+			var snapshot string
+			if err := rows.Scan(&d.RevisionId, &d.EntryText, &d.DeletedAfter, &snapshot); err != nil {
+				return err
+			}
+			var draft dtoSongDraft
+			if err := json.Unmarshal([]byte(snapshot), &draft); err != nil {
+				return err
+			}
+			d.Title = draft.Title
+			deleted = append(deleted, d)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error loading deleted songs of %s: %s", songbookAcronym, err))
+	}
+	return deleted, err
+}
+
+// formatRevisionAuthors renders authors one per line for comparing revisions
+func formatRevisionAuthors(authors []Author) string {
+	lines := make([]string, 0, len(authors))
+	for _, author := range authors {
+		if author.Type != "" {
+			lines = append(lines, author.Type+": "+author.Value)
+		} else {
+			lines = append(lines, author.Value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// verseText returns the chord text of a verse, or its lines without chords
+func verseText(verse dtoVerseDetail) string {
+	if verse.Chords != "" {
+		return verse.Chords
+	}
+	return verse.Lines
+}
+
+// diffSongDrafts compares two snapshots field by field and verse by verse.
+// Verses are matched by name and listed in the order of the newer snapshot,
+// removed verses last. With localEdits only the fields RestoreSongRevision
+// can restore on EZ and KK songs are compared: the title, the copyright and
+// the verses found in both snapshots.
+func diffSongDrafts(from, to dtoSongDraft, localEdits bool) []dtoRevisionChange {
+	changes := []dtoRevisionChange{}
+	for _, field := range []struct {
+		name, before, after string
+		localEdit           bool
+	}{
+		{"title", from.Title, to.Title, true},
+		{"authors", formatRevisionAuthors(from.Authors), formatRevisionAuthors(to.Authors), false},
+		{"copyright", from.Copyright, to.Copyright, true},
+		{"verse_order", from.VerseOrder, to.VerseOrder, false},
+	} {
+		if localEdits && !field.localEdit {
+			continue
+		}
+		if field.before != field.after {
+			changes = append(changes, dtoRevisionChange{Field: field.name, Change: revisionChanged, Before: field.before, After: field.after})
+		}
+	}
+
+	before := make(map[string]dtoVerseDetail, len(from.Verses))
+	for _, verse := range from.Verses {
+		before[verse.Name] = verse
+	}
+	for _, verse := range to.Verses {
+		old, ok := before[verse.Name]
+		delete(before, verse.Name)
+		switch {
+		case !ok && localEdits:
+		case !ok:
+			changes = append(changes, dtoRevisionChange{Field: "verse", VerseName: verse.Name, Change: revisionAdded, After: verseText(verse)})
+		case old.Lines != verse.Lines || old.Chords != verse.Chords:
+			changes = append(changes, dtoRevisionChange{Field: "verse", VerseName: verse.Name, Change: revisionChanged,
+				Before: verseText(old), After: verseText(verse)})
+		}
+	}
+	for _, verse := range from.Verses {
+		if _, ok := before[verse.Name]; ok && !localEdits {
+			changes = append(changes, dtoRevisionChange{Field: "verse", VerseName: verse.Name, Change: revisionRemoved, Before: verseText(verse)})
+		}
+	}
+	return changes
+}
+
+// DiffSongRevisions lists the differences between two revisions of the same song
+func (a *App) DiffSongRevisions(fromRevisionId, toRevisionId int) ([]dtoRevisionChange, error) {
+	var changes []dtoRevisionChange
+	err := a.withDB(func(db *sql.DB) error {
+		fromAcronym, fromEntry, from, err := loadSongRevision(db, fromRevisionId)
+		if err != nil {
+			return err
+		}
+		toAcronym, toEntry, to, err := loadSongRevision(db, toRevisionId)
+		if err != nil {
+			return err
+		}
+		if fromAcronym != toAcronym || fromEntry != toEntry {
+			return fmt.Errorf("revisions %d and %d belong to different songs", fromRevisionId, toRevisionId)
+		}
+		changes = diffSongDrafts(from, to, isBuiltinSongbook(fromAcronym))
+		return nil
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error comparing song revisions: %s", err))
+		return nil, err
+	}
+	return changes, nil
+}
+
+// RestoreSongRevision saves an older revision as the current state of the
+// song, recorded as a new revision by editedBy. Songs of user songbooks are
+// replaced. EZ and KK songs get the title, copyright and verse texts of the
+// revision as local edits; verses the song no longer has are skipped. A
+// deleted song of a user songbook is created again under its number. It
+// returns the song ID.
+func (a *App) RestoreSongRevision(revisionId int, editedBy string) (int, error) {
+	var draft dtoSongDraft
+	var acronym, entryText string
+	err := a.withDB(func(db *sql.DB) error {
+		var err error
+		acronym, entryText, draft, err = loadSongRevision(db, revisionId)
+		if err != nil {
+			return err
+		}
+		err = db.QueryRow(`SELECT id FROM songs s WHERE s.songbook_acronym = ? AND `+songEntryKeyExpr+` = ? ORDER BY id LIMIT 1`,
+			acronym, entryText).Scan(&draft.Id)
+		if err == sql.ErrNoRows {
+			if isBuiltinSongbook(acronym) {
+				return fmt.Errorf("song %s %s no longer exists", acronym, entryText)
+			}
+			draft.Id = 0
+			return nil
+		}
+		if err != nil || !isBuiltinSongbook(acronym) {
+			return err
+		}
+		verses, err := a.loadSongVerses(db, draft.Id)
+		if err != nil {
+			return err
+		}
+		names := make(map[string]bool, len(verses))
+		for _, verse := range verses {
+			names[strings.ToLower(verse.Name)] = true
+		}
+		restorable := []dtoVerseDetail{}
+		for _, verse := range draft.Verses {
+			if names[strings.ToLower(verse.Name)] {
+				restorable = append(restorable, verse)
+			}
+		}
+		draft.Verses = restorable
+		return nil
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Restoring song revision %d failed: %s", revisionId, err))
+		return 0, err
+	}
+
+	draft.EditedBy = editedBy
+	if isBuiltinSongbook(acronym) {
+		_, err = a.SaveSongLocalEdits(draft)
+		return draft.Id, err
+	}
+	if draft.Id == 0 {
+		// a deleted song gets its old number and entry text
+		draft.SongbookAcronym = acronym
+		if draft.Entry == 0 {
+			draft.Entry = parseHymnNumber(entryText)
+		}
+		return a.saveSong(draft, entryText)
+	}
+	// keeps the current number of the song
+	draft.Entry = 0
+	return a.SaveSong(draft)
+}
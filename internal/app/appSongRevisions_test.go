@@ -0,0 +1,260 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiffSongDrafts(t *testing.T) {
+	from := dtoSongDraft{
+		Title:      "Ranní píseň",
+		Authors:    []Author{{Type: "words", Value: "Marie Svobodová"}},
+		Verses:     []dtoVerseDetail{{Name: "v1", Lines: "Ráno vstávám"}, {Name: "v2", Lines: "Den začíná"}},
+		VerseOrder: "v1 v2",
+	}
+	to := dtoSongDraft{
+		Title:      "Ranní píseň",
+		Authors:    []Author{{Type: "words", Value: "Marie Svobodová"}, {Type: "music", Value: "Petr Dvořák"}},
+		Verses:     []dtoVerseDetail{{Name: "v1", Lines: "Ráno vstávám", Chords: "[G]Ráno vstávám"}, {Name: "c", Lines: "Haleluja"}},
+		VerseOrder: "v1 c",
+	}
+
+	got := diffSongDrafts(from, to, false)
+	want := []dtoRevisionChange{
+		{Field: "authors", Change: revisionChanged, Before: "words: Marie Svobodová", After: "words: Marie Svobodová\nmusic: Petr Dvořák"},
+		{Field: "verse_order", Change: revisionChanged, Before: "v1 v2", After: "v1 c"},
+		{Field: "verse", VerseName: "v1", Change: revisionChanged, Before: "Ráno vstávám", After: "[G]Ráno vstávám"},
+		{Field: "verse", VerseName: "c", Change: revisionAdded, After: "Haleluja"},
+		{Field: "verse", VerseName: "v2", Change: revisionRemoved, Before: "Den začíná"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffSongDrafts =\n%+v\nwant\n%+v", got, want)
+	}
+	if changes := diffSongDrafts(from, from, false); len(changes) != 0 {
+		t.Errorf("identical revisions should have no changes, got %+v", changes)
+	}
+
+	// local edits of EZ and KK songs cannot restore authors, verse order or verses
+	got = diffSongDrafts(from, to, true)
+	if len(got) != 1 || got[0] != want[2] {
+		t.Errorf("diffSongDrafts with local edits = %+v, want only the changed verse", got)
+	}
+}
+
+func TestSongRevisions_UserSongbook(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	if err := app.CreateSongbook("SBOR", "Zpěvník sboru"); err != nil {
+		t.Fatal(err)
+	}
+
+	songID, err := app.SaveSong(dtoSongDraft{SongbookAcronym: "SBOR", Title: "Ranní píseň", EditedBy: "Marie",
+		Verses: []dtoVerseDetail{{Name: "v1", Lines: "Ráno vstávám"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	draft, _ := app.GetSongDraft(songID)
+	draft.Title = "Večerní píseň"
+	draft.Entry = 5
+	draft.EditedBy = "Petr"
+	if _, err := app.SaveSong(draft); err != nil {
+		t.Fatal(err)
+	}
+	// saving without changes adds no revision
+	if _, err := app.SaveSong(draft); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := app.GetSongRevisions(songID)
+	if err != nil {
+		t.Fatalf("GetSongRevisions: %v", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("expected 2 revisions, got %+v", revisions)
+	}
+	if revisions[0].EditedBy != "Petr" || revisions[0].Title != "Večerní píseň" || revisions[1].EditedBy != "Marie" {
+		t.Errorf("unexpected revisions %+v", revisions)
+	}
+
+	changes, err := app.DiffSongRevisions(revisions[1].Id, revisions[0].Id)
+	if err != nil {
+		t.Fatalf("DiffSongRevisions: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Field != "title" || changes[0].Before != "Ranní píseň" {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+
+	if _, err := app.RestoreSongRevision(revisions[1].Id, "Jana"); err != nil {
+		t.Fatalf("RestoreSongRevision: %v", err)
+	}
+	song := mustLoadExportSong(t, app, songID)
+	if song.Title != "Ranní píseň" || song.EntryText != "5" {
+		t.Errorf("restore should bring back the title and keep the number, got %+v", song)
+	}
+	revisions, _ = app.GetSongRevisions(songID)
+	if len(revisions) != 3 || revisions[0].EditedBy != "Jana" {
+		t.Errorf("restore should be recorded as a new revision, got %+v", revisions)
+	}
+
+	other, err := app.SaveSong(dtoSongDraft{SongbookAcronym: "SBOR", Title: "Jiná", Verses: []dtoVerseDetail{{Name: "v1", Lines: "Text"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRevisions, _ := app.GetSongRevisions(other)
+	if _, err := app.DiffSongRevisions(revisions[0].Id, otherRevisions[0].Id); err == nil {
+		t.Error("comparing revisions of different songs should fail")
+	}
+}
+
+func TestSongRevisions_LocalEdits(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := importOpenLyricsSample(t, app)
+
+	draft, err := app.GetSongDraft(songID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	draft.Title = "Hospodin je můj pastýř"
+	draft.EditedBy = "Marie"
+	if _, err := app.SaveSongLocalEdits(draft); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := app.GetSongRevisions(songID)
+	if err != nil || len(revisions) != 2 {
+		t.Fatalf("expected the imported baseline and the edit, got %v %+v", err, revisions)
+	}
+	if revisions[1].Title != "Pán je můj pastýř" || revisions[1].EditedBy != "" {
+		t.Errorf("baseline should hold the imported song, got %+v", revisions[1])
+	}
+
+	if _, err := app.RestoreSongRevision(revisions[1].Id, "Petr"); err != nil {
+		t.Fatalf("RestoreSongRevision: %v", err)
+	}
+	song := findSong(t, app, "EZ")
+	if song.LocallyModified || song.Title != "Pán je můj pastýř" {
+		t.Errorf("restoring the baseline should drop the local edits, got %+v", song)
+	}
+
+	// a revision with a verse the imported song no longer has
+	baseline := draft
+	baseline.Title = "Pán je můj pastýř"
+	baseline.Verses = append(baseline.Verses, dtoVerseDetail{Name: "v9", Lines: "Zrušená sloka"})
+	snapshot, _ := json.Marshal(baseline)
+	var revisionID int64
+	err = app.withDB(func(db *sql.DB) error {
+		r, err := db.Exec(`INSERT INTO song_revisions (songbook_acronym, entry_text, snapshot) SELECT songbook_acronym, entry_text, ? FROM song_revisions WHERE id = ?`,
+			string(snapshot), revisions[1].Id)
+		if err != nil {
+			return err
+		}
+		revisionID, err = r.LastInsertId()
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := app.DiffSongRevisions(revisions[1].Id, int(revisionID))
+	if err != nil || len(changes) != 0 {
+		t.Errorf("a verse that cannot be restored should not be listed, got %v %+v", err, changes)
+	}
+	if _, err := app.RestoreSongRevision(int(revisionID), "Petr"); err != nil {
+		t.Errorf("RestoreSongRevision should skip the missing verse: %v", err)
+	}
+}
+
+func TestSongRevisions_DeletedSong(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	if err := app.CreateSongbook("SBOR", "Zpěvník sboru"); err != nil {
+		t.Fatal(err)
+	}
+	songID, err := app.SaveSong(dtoSongDraft{SongbookAcronym: "SBOR", Entry: 7, Title: "Ranní píseň", EditedBy: "Marie",
+		Verses: []dtoVerseDetail{{Name: "v1", Lines: "Ráno vstávám"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted, _ := app.GetDeletedSongs("SBOR"); len(deleted) != 0 {
+		t.Errorf("existing songs should not be listed, got %+v", deleted)
+	}
+	if err := app.DeleteSong(songID); err != nil {
+		t.Fatalf("DeleteSong: %v", err)
+	}
+
+	deleted, err := app.GetDeletedSongs("SBOR")
+	if err != nil {
+		t.Fatalf("GetDeletedSongs: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].EntryText != "7" || deleted[0].Title != "Ranní píseň" {
+		t.Fatalf("expected the deleted song, got %+v", deleted)
+	}
+	restoredID, err := app.RestoreSongRevision(deleted[0].RevisionId, "Petr")
+	if err != nil {
+		t.Fatalf("RestoreSongRevision: %v", err)
+	}
+	song := findSong(t, app, "SBOR")
+	if song.Id != restoredID || song.Entry != 7 || song.Title != "Ranní píseň" {
+		t.Errorf("expected the song restored under its number, got %+v", song)
+	}
+	if revisions, _ := app.GetSongRevisions(restoredID); len(revisions) != 1 || revisions[0].EditedBy != "Marie" {
+		t.Errorf("the history should be kept, got %+v", revisions)
+	}
+	if deleted, _ := app.GetDeletedSongs("SBOR"); len(deleted) != 0 {
+		t.Errorf("restored song should not be listed, got %+v", deleted)
+	}
+}
+
+func TestSongRevisions_DeletedSongKeepsEntryText(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	if err := app.CreateSongbook("SBOR", "Zpěvník sboru"); err != nil {
+		t.Fatal(err)
+	}
+	// imported songs may carry numbers like "23a"
+	var songID int64
+	err := app.withDB(func(db *sql.DB) error {
+		r, err := db.Exec(`INSERT INTO songs (songbook_acronym, title, title_d, verse_order, entry, entry_text) VALUES ('SBOR', 'Večerní', 'Vecerni', '', 23, '23a')`)
+		if err != nil {
+			return err
+		}
+		songID, err = r.LastInsertId()
+		if err != nil {
+			return err
+		}
+		_, err = db.Exec(`INSERT INTO verses (song_id, name, lines, lines_d) VALUES (?, 'v1', 'Den končí', 'Den konci')`, songID)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	draft, err := app.GetSongDraft(int(songID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.SaveSong(draft); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.DeleteSong(int(songID)); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, _ := app.GetDeletedSongs("SBOR")
+	if len(deleted) != 1 || deleted[0].EntryText != "23a" {
+		t.Fatalf("expected the deleted song 23a, got %+v", deleted)
+	}
+	restoredID, err := app.RestoreSongRevision(deleted[0].RevisionId, "")
+	if err != nil {
+		t.Fatalf("RestoreSongRevision: %v", err)
+	}
+	var entry int
+	var entryText string
+	app.withDB(func(db *sql.DB) error {
+		return db.QueryRow(`SELECT entry, entry_text FROM songs WHERE id = ?`, restoredID).Scan(&entry, &entryText)
+	})
+	if entry != 23 || entryText != "23a" {
+		t.Errorf("expected the song restored as 23a, got %d %q", entry, entryText)
+	}
+}
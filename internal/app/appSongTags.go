@@ -0,0 +1,148 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// normalizeTag trims and lowercases a tag so "Advent " and "advent" are the same tag
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// AddSongTag attaches a free-form tag to a song
+func (a *App) AddSongTag(songId int, tag string) error {
+	tag = normalizeTag(tag)
+	if tag == "" {
+		return fmt.Errorf("tag must not be empty")
+	}
+	return a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		_, err = db.Exec(`INSERT OR IGNORE INTO song_tags (songbook_acronym, entry_text, tag) VALUES (?, ?, ?)`,
+			acronym, entryText, tag)
+		if err == nil {
+			// shared tags add to the similarity of songs
+			a.markSimilarSongsStale()
+		}
+		return err
+	})
+}
+
+// RemoveSongTag detaches a tag from a song
+func (a *App) RemoveSongTag(songId int, tag string) error {
+	return a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		_, err = db.Exec(`DELETE FROM song_tags WHERE songbook_acronym = ? AND entry_text = ? AND tag = ?`,
+			acronym, entryText, normalizeTag(tag))
+		if err == nil {
+			a.markSimilarSongsStale()
+		}
+		return err
+	})
+}
+
+// GetSongTags returns the tags of a song in alphabetical order
+func (a *App) GetSongTags(songId int) ([]string, error) {
+	result := []string{}
+	err := a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		rows, err := db.Query(`SELECT tag FROM song_tags WHERE songbook_acronym = ? AND entry_text = ? ORDER BY tag`,
+			acronym, entryText)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error querying song tags: %s", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var tag string
+			if err := rows.Scan(&tag); err != nil {
+				return err
+			}
+			result = append(result, tag)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
+
+// GetAllTags returns every tag in use with the number of songs carrying it
+func (a *App) GetAllTags() ([]dtoTag, error) {
+	result := []dtoTag{}
+	err := a.withDB(func(db *sql.DB) error {
+		rows, err := db.Query(`SELECT tag, COUNT(*) FROM song_tags GROUP BY tag ORDER BY tag`)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error querying tags: %s", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var t dtoTag
+			if err := rows.Scan(&t.Name, &t.SongCount); err != nil {
+				return err
+			}
+			result = append(result, t)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
+
+// SetSongFavorite marks or unmarks a song as favorite
+func (a *App) SetSongFavorite(songId int, favorite bool) error {
+	return a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		if favorite {
+			_, err = db.Exec(`INSERT OR IGNORE INTO song_favorites (songbook_acronym, entry_text) VALUES (?, ?)`,
+				acronym, entryText)
+		} else {
+			_, err = db.Exec(`DELETE FROM song_favorites WHERE songbook_acronym = ? AND entry_text = ?`,
+				acronym, entryText)
+		}
+		return err
+	})
+}
+
+// GetFavoriteSongs lists favorite songs that exist in the current database
+func (a *App) GetFavoriteSongs() ([]dtoSongHeader, error) {
+	result := []dtoSongHeader{}
+	err := a.withDB(func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT s.id, s.entry, COALESCE(s.title, ''), COALESCE(s.title_d, ''), COALESCE(s.kytara_file, '')
+			FROM songs s
+			JOIN song_favorites fv ON fv.songbook_acronym = COALESCE(s.songbook_acronym, '')
+			                      AND fv.entry_text = ` + songEntryKeyExpr + `
+			ORDER BY s.songbook_acronym, s.entry`)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error querying favorite songs: %s", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var h dtoSongHeader
+			if err := rows.Scan(&h.Id, &h.Entry, &h.Title, &h.TitleD, &h.KytaraFile); err != nil {
+				slog.Error(fmt.Sprintf("Error scanning row: %s", err))
+				return err
+			}
+			result = append(result, h)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
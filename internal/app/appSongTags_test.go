@@ -0,0 +1,198 @@
+package app
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{"Advent", "advent"},
+		{"  Velikonoce ", "velikonoce"},
+		{"dětská   píseň", "dětská píseň"},
+		{"   ", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeTag(tt.raw); got != tt.want {
+			t.Errorf("normalizeTag(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestSongTags_AddRemoveList(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertUsageSongs(t, app)
+
+	for _, tag := range []string{"Advent", "advent ", "Děti"} {
+		if err := app.AddSongTag(ids[0], tag); err != nil {
+			t.Fatalf("AddSongTag(%q): %v", tag, err)
+		}
+	}
+	if err := app.AddSongTag(ids[1], "advent"); err != nil {
+		t.Fatalf("AddSongTag: %v", err)
+	}
+	if err := app.AddSongTag(ids[1], " "); err == nil {
+		t.Error("expected error for empty tag")
+	}
+	if err := app.AddSongTag(99999, "advent"); err == nil {
+		t.Error("expected error for unknown song")
+	}
+
+	tags, err := app.GetSongTags(ids[0])
+	if err != nil {
+		t.Fatalf("GetSongTags: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != "advent" || tags[1] != "děti" {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+
+	all, err := app.GetAllTags()
+	if err != nil {
+		t.Fatalf("GetAllTags: %v", err)
+	}
+	if len(all) != 2 || all[0].Name != "advent" || all[0].SongCount != 2 {
+		t.Errorf("unexpected tag list: %+v", all)
+	}
+
+	if err := app.RemoveSongTag(ids[0], "ADVENT"); err != nil {
+		t.Fatalf("RemoveSongTag: %v", err)
+	}
+	tags, _ = app.GetSongTags(ids[0])
+	if len(tags) != 1 || tags[0] != "děti" {
+		t.Errorf("unexpected tags after removal: %v", tags)
+	}
+}
+
+func TestSongFavorites(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertUsageSongs(t, app)
+
+	if err := app.SetSongFavorite(ids[2], true); err != nil {
+		t.Fatalf("SetSongFavorite: %v", err)
+	}
+	if err := app.SetSongFavorite(ids[2], true); err != nil {
+		t.Fatalf("SetSongFavorite (repeated): %v", err)
+	}
+	if err := app.SetSongFavorite(ids[3], true); err != nil {
+		t.Fatalf("SetSongFavorite: %v", err)
+	}
+
+	favorites, err := app.GetFavoriteSongs()
+	if err != nil {
+		t.Fatalf("GetFavoriteSongs: %v", err)
+	}
+	if len(favorites) != 2 || favorites[0].Id != ids[2] || favorites[1].Id != ids[3] {
+		t.Errorf("unexpected favorites: %+v", favorites)
+	}
+
+	if err := app.SetSongFavorite(ids[3], false); err != nil {
+		t.Fatalf("SetSongFavorite(false): %v", err)
+	}
+	favorites, _ = app.GetFavoriteSongs()
+	if len(favorites) != 1 {
+		t.Errorf("expected 1 favorite after unmarking, got %d", len(favorites))
+	}
+}
+
+func TestGetSongs_TagAndFavoriteFilters(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertUsageSongs(t, app)
+
+	_ = app.AddSongTag(ids[0], "advent")
+	_ = app.AddSongTag(ids[1], "advent")
+	_ = app.AddSongTag(ids[1], "děti")
+	_ = app.AddSongTag(ids[3], "advent")
+	_ = app.AddSongTag(ids[1], "první svaté přijímání")
+	_ = app.SetSongFavorite(ids[1], true)
+	_ = app.SetSongFavorite(ids[3], true)
+
+	tests := []struct {
+		name       string
+		pattern    string
+		source     string
+		wantTitles []string
+	}{
+		{name: "single tag", pattern: "tag:advent", wantTitles: []string{"Kdo se vzdává", "Ejhle oltář", "Blaze tomu"}},
+		{name: "tag is case insensitive", pattern: "TAG:Advent", source: "EZ", wantTitles: []string{"Kdo se vzdává", "Blaze tomu"}},
+		{name: "two tags", pattern: "tag:advent tag:děti", wantTitles: []string{"Blaze tomu"}},
+		{name: "favorites", pattern: "fav:", wantTitles: []string{"Ejhle oltář", "Blaze tomu"}},
+		{name: "tag with text", pattern: "tag:advent blaze", wantTitles: []string{"Blaze tomu"}},
+		{name: "tag with number", pattern: "1 tag:advent", wantTitles: []string{"Kdo se vzdává", "Ejhle oltář"}},
+		{name: "unknown tag", pattern: "tag:pohřeb", wantTitles: nil},
+		{name: "quoted tag with spaces", pattern: `tag:"První  svaté přijímání" tag:advent`, wantTitles: []string{"Blaze tomu"}},
+		{name: "unquoted tag stops at space", pattern: "tag:první svaté", wantTitles: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			songs, err := app.GetSongs("entry", tt.pattern, tt.source)
+			if err != nil {
+				t.Fatalf("GetSongs: %v", err)
+			}
+			var titles []string
+			for _, s := range songs {
+				titles = append(titles, s.Title)
+			}
+			if len(titles) != len(tt.wantTitles) {
+				t.Fatalf("got %v, want %v", titles, tt.wantTitles)
+			}
+			for i := range titles {
+				if titles[i] != tt.wantTitles[i] {
+					t.Errorf("song %d = %q, want %q", i, titles[i], tt.wantTitles[i])
+				}
+			}
+		})
+	}
+
+	songs, _ := app.GetSongs("entry", "fav:", "EZ")
+	if len(songs) != 1 || !songs[0].IsFavorite {
+		t.Errorf("expected favorite flag on result, got %+v", songs)
+	}
+}
+
+func TestSongTags_SurviveRefill(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertUsageSongs(t, app)
+
+	_ = app.AddSongTag(ids[0], "advent")
+	_ = app.SetSongFavorite(ids[0], true)
+
+	// Simulate a re-import: the song gets a new id but keeps its songbook entry
+	var newID int
+	err := app.withDB(func(db *sql.DB) error {
+		if _, err := db.Exec(`DELETE FROM verses WHERE song_id = ?`, ids[0]); err != nil {
+			return err
+		}
+		if _, err := db.Exec(`DELETE FROM songs WHERE id = ?`, ids[0]); err != nil {
+			return err
+		}
+		r, err := db.Exec(`INSERT INTO songs (songbook_acronym, title, title_d, verse_order, entry, entry_text) VALUES ('EZ', 'Kdo se vzdává', 'Kdo se vzdava', '', 1, '1')`)
+		if err != nil {
+			return err
+		}
+		id, _ := r.LastInsertId()
+		newID = int(id)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("refill: %v", err)
+	}
+
+	tags, err := app.GetSongTags(newID)
+	if err != nil {
+		t.Fatalf("GetSongTags: %v", err)
+	}
+	if len(tags) != 1 || tags[0] != "advent" {
+		t.Errorf("tags lost after refill: %v", tags)
+	}
+	favorites, _ := app.GetFavoriteSongs()
+	if len(favorites) != 1 || favorites[0].Id != newID {
+		t.Errorf("favorite lost after refill: %+v", favorites)
+	}
+}
@@ -0,0 +1,181 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// songTuneInfo is the tune-related data of one song used for matching
+type songTuneInfo struct {
+	dtoTuneMatch
+	MusicAuthor string
+}
+
+// loadSongTuneInfos loads tune names, effective meters and music attribution
+// of all songs. The meter is computed from the first verse unless overridden.
+func (a *App) loadSongTuneInfos(db *sql.DB) ([]songTuneInfo, error) {
+	rows, err := db.Query(`
+		SELECT s.id, s.entry, ` + songEntryKeyExpr + `, COALESCE(s.title, ''), COALESCE(s.songbook_acronym, ''),
+		       COALESCE((SELECT v.lines FROM verses v WHERE v.song_id = s.id ORDER BY v.id LIMIT 1), ''),
+		       COALESCE(t.tune_name, ''), COALESCE(t.meter, ''),
+		       COALESCE((SELECT author_value FROM authors WHERE song_id = s.id AND author_type = 'music' ORDER BY id LIMIT 1), '')
+		FROM songs s
+		LEFT JOIN song_tunes t ON t.songbook_acronym = COALESCE(s.songbook_acronym, '')
+		                      AND t.entry_text = ` + songEntryKeyExpr + `
+		ORDER BY s.songbook_acronym, s.entry`)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error querying song tunes: %s", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var infos []songTuneInfo
+	for rows.Next() {
+		var info songTuneInfo
+		var firstVerse, meterOverride string
+		if err := rows.Scan(&info.Id, &info.Entry, &info.EntryText, &info.Title, &info.SongbookAcronym,
+			&firstVerse, &info.TuneName, &meterOverride, &info.MusicAuthor); err != nil {
+			slog.Error(fmt.Sprintf("Error scanning row: %s", err))
+			return nil, err
+		}
+		info.Meter = meterOverride
+		if info.Meter == "" {
+			info.Meter = computeMeter(firstVerse)
+		}
+		infos = append(infos, info)
+	}
+	return infos, rows.Err()
+}
+
+// findTuneInfo returns the entry of songID from infos
+func findTuneInfo(infos []songTuneInfo, songID int) (songTuneInfo, error) {
+	for _, info := range infos {
+		if info.Id == songID {
+			return info, nil
+		}
+	}
+	return songTuneInfo{}, fmt.Errorf("song %d not found", songID)
+}
+
+// GetSongTune returns the tune name and meter of a song
+func (a *App) GetSongTune(songId int) (dtoSongTune, error) {
+	var result dtoSongTune
+	err := a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		verses, err := a.loadSongVerses(db, songId)
+		if err != nil {
+			return err
+		}
+		if len(verses) > 0 {
+			result.ComputedMeter = computeMeter(verses[0].Lines)
+		}
+
+		var meterOverride string
+		err = db.QueryRow(`SELECT tune_name, meter FROM song_tunes WHERE songbook_acronym = ? AND entry_text = ?`,
+			acronym, entryText).Scan(&result.TuneName, &meterOverride)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		result.MeterOverridden = meterOverride != ""
+		result.Meter = result.ComputedMeter
+		if result.MeterOverridden {
+			result.Meter = meterOverride
+		}
+		return nil
+	})
+	return result, err
+}
+
+// SaveSongTune stores the tune name and an optional meter override of a song.
+// Empty values remove the stored record.
+func (a *App) SaveSongTune(songId int, tuneName string, meter string) error {
+	tuneName = strings.Join(strings.Fields(tuneName), " ")
+	normalized, ok := normalizeMeter(meter)
+	if !ok {
+		return fmt.Errorf("invalid meter %q, expected e.g. 8.7.8.7", meter)
+	}
+	meter = normalized
+	return a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		if tuneName == "" && meter == "" {
+			_, err = db.Exec(`DELETE FROM song_tunes WHERE songbook_acronym = ? AND entry_text = ?`, acronym, entryText)
+			return err
+		}
+		_, err = db.Exec(`
+			INSERT INTO song_tunes (songbook_acronym, entry_text, tune_name, meter, updated_at)
+			VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(songbook_acronym, entry_text) DO UPDATE SET
+				tune_name = excluded.tune_name,
+				meter = excluded.meter,
+				updated_at = CURRENT_TIMESTAMP`,
+			acronym, entryText, tuneName, meter)
+		return err
+	})
+}
+
+// GetSongsWithSameMeter lists other texts with the same metrical pattern,
+// i.e. texts that can be sung to the song's melody
+func (a *App) GetSongsWithSameMeter(songId int) ([]dtoTuneMatch, error) {
+	result := []dtoTuneMatch{}
+	err := a.withDB(func(db *sql.DB) error {
+		infos, err := a.loadSongTuneInfos(db)
+		if err != nil {
+			return err
+		}
+		song, err := findTuneInfo(infos, songId)
+		if err != nil {
+			return err
+		}
+		if song.Meter == "" {
+			return nil
+		}
+		for _, info := range infos {
+			if info.Id != songId && info.Meter == song.Meter {
+				result = append(result, info.dtoTuneMatch)
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// GetSongsSharingTune lists songs with the same tune name. Songs without a
+// tune name whose music attribution and meter are identical are included as
+// inferred matches.
+func (a *App) GetSongsSharingTune(songId int) ([]dtoTuneMatch, error) {
+	result := []dtoTuneMatch{}
+	err := a.withDB(func(db *sql.DB) error {
+		infos, err := a.loadSongTuneInfos(db)
+		if err != nil {
+			return err
+		}
+		song, err := findTuneInfo(infos, songId)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			if info.Id == songId {
+				continue
+			}
+			switch {
+			case song.TuneName != "" && strings.EqualFold(info.TuneName, song.TuneName):
+				result = append(result, info.dtoTuneMatch)
+			case song.MusicAuthor != "" && info.MusicAuthor == song.MusicAuthor && info.Meter == song.Meter &&
+				(song.TuneName == "" || info.TuneName == ""):
+				match := info.dtoTuneMatch
+				match.Inferred = true
+				result = append(result, match)
+			}
+		}
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// insertTuneSongs inserts songs with known meters and music authors:
+// two 8.7 texts by the same composer, one 8.7 text by another and one 6.6 text
+func insertTuneSongs(t *testing.T, app *App) []int {
+	t.Helper()
+	var ids []int
+	err := app.withDB(func(db *sql.DB) error {
+		songs := []struct {
+			entry  int
+			title  string
+			verse  string
+			author string
+		}{
+			{1, "První", "Pane, slyš můj hlas (8)\nkaždý den a noc", "Loys Bourgeois"},
+			{2, "Druhá", "Zpívej duše má teď\nHospodinu chválu", "Loys Bourgeois"},
+			{3, "Třetí", "Bůh je naše síla\ntvrz a pomoc v nouzi", "Jan Blahoslav"},
+			{4, "Čtvrtá", "Amen, amen\nhaleluja", "Loys Bourgeois"},
+		}
+		for _, s := range songs {
+			r, err := db.Exec(`INSERT INTO songs (songbook_acronym, title, title_d, verse_order, entry, entry_text) VALUES ('EZ', ?, ?, '', ?, ?)`,
+				s.title, removeDiacritics(s.title), s.entry, s.entry)
+			if err != nil {
+				return err
+			}
+			id, _ := r.LastInsertId()
+			if _, err := db.Exec(`INSERT INTO verses (song_id, name, lines, lines_d) VALUES (?, 'v1', ?, ?)`, id, s.verse, removeDiacritics(s.verse)); err != nil {
+				return err
+			}
+			if err := app.insertAuthors(db, id, []Author{{Type: "music", Value: s.author}}, "test"); err != nil {
+				return err
+			}
+			ids = append(ids, int(id))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	return ids
+}
+
+func TestSongTune_GetAndSave(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertTuneSongs(t, app)
+
+	tune, err := app.GetSongTune(ids[1])
+	if err != nil {
+		t.Fatalf("GetSongTune: %v", err)
+	}
+	if tune.Meter != "6.6" || tune.ComputedMeter != "6.6" || tune.MeterOverridden || tune.TuneName != "" {
+		t.Errorf("unexpected tune: %+v", tune)
+	}
+
+	if err := app.SaveSongTune(ids[1], "  Ženevský   žalm 42 ", "8 7"); err != nil {
+		t.Fatalf("SaveSongTune: %v", err)
+	}
+	tune, _ = app.GetSongTune(ids[1])
+	if tune.TuneName != "Ženevský žalm 42" || tune.Meter != "8.7" || !tune.MeterOverridden {
+		t.Errorf("unexpected tune after save: %+v", tune)
+	}
+
+	if err := app.SaveSongTune(ids[1], "", "osm"); err == nil {
+		t.Error("expected error for invalid meter")
+	}
+	if err := app.SaveSongTune(ids[1], "", ""); err != nil {
+		t.Fatalf("SaveSongTune (clear): %v", err)
+	}
+	tune, _ = app.GetSongTune(ids[1])
+	if tune.TuneName != "" || tune.MeterOverridden {
+		t.Errorf("expected cleared tune, got %+v", tune)
+	}
+}
+
+func TestGetSongsWithSameMeter(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertTuneSongs(t, app)
+
+	matches, err := app.GetSongsWithSameMeter(ids[0])
+	if err != nil {
+		t.Fatalf("GetSongsWithSameMeter: %v", err)
+	}
+	// "Pane, slyš můj hlas (8)" has 5 syllables, the number is not counted
+	if len(matches) != 0 {
+		t.Errorf("expected no 5.5 matches, got %+v", matches)
+	}
+
+	matches, _ = app.GetSongsWithSameMeter(ids[1])
+	if len(matches) != 1 || matches[0].Id != ids[2] || matches[0].Meter != "6.6" {
+		t.Errorf("unexpected meter matches: %+v", matches)
+	}
+
+	if _, err := app.GetSongsWithSameMeter(99999); err == nil {
+		t.Error("expected error for unknown song")
+	}
+}
+
+func TestGetSongsSharingTune(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertTuneSongs(t, app)
+
+	// same meter but different composers: no inferred match
+	matches, err := app.GetSongsSharingTune(ids[1])
+	if err != nil {
+		t.Fatalf("GetSongsSharingTune: %v", err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("expected no matches, got %+v", matches)
+	}
+
+	// same composer and meter: inferred match
+	_ = app.SaveSongTune(ids[3], "", "6.6")
+	matches, _ = app.GetSongsSharingTune(ids[1])
+	if len(matches) != 1 || matches[0].Id != ids[3] || !matches[0].Inferred {
+		t.Errorf("expected inferred match, got %+v", matches)
+	}
+
+	// explicit tune names match regardless of meter and composer
+	_ = app.SaveSongTune(ids[0], "Old Hundredth", "")
+	_ = app.SaveSongTune(ids[2], "old hundredth", "")
+	matches, _ = app.GetSongsSharingTune(ids[0])
+	if len(matches) != 1 || matches[0].Id != ids[2] || matches[0].Inferred || matches[0].TuneName != "old hundredth" {
+		t.Errorf("expected named tune match, got %+v", matches)
+	}
+}
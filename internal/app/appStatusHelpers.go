@@ -96,7 +96,8 @@ func (a *App) hasDatabaseContent() bool {
 }
 
 func (a *App) hasPdfSources() bool {
-	if len(SupplementalPDFs) == 0 {
+	pdfs := a.supplementalPDFs()
+	if len(pdfs) == 0 {
 		return true
 	}
 
@@ -104,7 +105,7 @@ func (a *App) hasPdfSources() bool {
 		return false
 	}
 
-	for _, pdf := range SupplementalPDFs {
+	for _, pdf := range pdfs {
 		fileName := pdf.FileName
 		if fileName == "" {
 			fileName = path.Base(pdf.URL)
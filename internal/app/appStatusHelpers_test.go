@@ -18,6 +18,7 @@ func setupStatusApp(t *testing.T) *App {
 		songBookDir: filepath.Join(tmpDir, "SongBook"),
 		pdfDir:      filepath.Join(tmpDir, "PdfSources"),
 		testRun:     true,
+		settings:    defaultSettings(),
 	}
 	return app
 }
@@ -142,8 +143,8 @@ func TestHasPdfSources_AllPDFsPresent(t *testing.T) {
 	if err := os.MkdirAll(app.pdfDir, 0755); err != nil {
 		t.Fatal(err)
 	}
-	// Create dummy PDFs matching every entry in SupplementalPDFs
-	for _, pdf := range SupplementalPDFs {
+	// Create dummy PDFs matching every configured supplemental PDF
+	for _, pdf := range app.supplementalPDFs() {
 		name := pdf.FileName
 		if name == "" {
 			name = filepath.Base(pdf.URL)
@@ -158,15 +159,15 @@ func TestHasPdfSources_AllPDFsPresent(t *testing.T) {
 }
 
 func TestHasPdfSources_MissingOnePDF(t *testing.T) {
-	if len(SupplementalPDFs) < 2 {
+	app := setupStatusApp(t)
+	if len(app.supplementalPDFs()) < 2 {
 		t.Skip("need at least 2 supplemental PDFs for this test")
 	}
-	app := setupStatusApp(t)
 	if err := os.MkdirAll(app.pdfDir, 0755); err != nil {
 		t.Fatal(err)
 	}
 	// Only write the first PDF, not all of them
-	pdf := SupplementalPDFs[0]
+	pdf := app.supplementalPDFs()[0]
 	name := pdf.FileName
 	if name == "" {
 		name = filepath.Base(pdf.URL)
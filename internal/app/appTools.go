@@ -2,9 +2,11 @@ package app
 
 import (
 	"archive/zip"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"golang.org/x/text/encoding/charmap"
 )
@@ -26,6 +28,10 @@ func unzip(zipFile, destination string) error {
 		}
 
 		filePath := filepath.Join(destination, name)
+		// Refuse entries like "../x" escaping the destination
+		if rel, err := filepath.Rel(destination, filePath); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid file path in archive: %s", file.Name)
+		}
 
 		// If the entry is a directory, create it
 		if file.FileInfo().IsDir() {
@@ -110,3 +116,33 @@ func copyDir(src string, dst string) error {
 
 	return nil
 }
+
+// zipDirectory writes all files below src as a zip archive to w, with paths
+// relative to src
+func zipDirectory(src string, w io.Writer) error {
+	zipWriter := zip.NewWriter(w)
+	err := filepath.WalkDir(src, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		fw, err := zipWriter.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(fw, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zipWriter.Close()
+}
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Usage types stored in song_usage.usage_type
+const (
+	usageProjection = "projection"
+	usageExport     = "export"
+)
+
+// recordSongUsage stores one use of a song. Repeated uses of the same song on
+// the same day for the same service are counted once.
+func (a *App) recordSongUsage(db *sql.DB, songID int, usedOn string, serviceName string, usageType string) error {
+	acronym, entryText, err := a.lookupSongKey(db, songID)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		INSERT OR IGNORE INTO song_usage (songbook_acronym, entry_text, used_on, service_name, usage_type)
+		VALUES (?, ?, ?, ?, ?)`,
+		acronym, entryText, usedOn, strings.TrimSpace(serviceName), usageType)
+	return err
+}
+
+// RecordSongProjection records that a song was shown on the screen today as
+// part of serviceName. The frontend calls it once the song is projected, the
+// projection getters do not record anything. Without a service name the song
+// is recorded under today's service plan that contains it, if there is one.
+func (a *App) RecordSongProjection(songId int, serviceName string) error {
+	today := time.Now().Format(serviceDateLayout)
+	return a.withDB(func(db *sql.DB) error {
+		if strings.TrimSpace(serviceName) == "" {
+			acronym, entryText, err := a.lookupSongKey(db, songId)
+			if err != nil {
+				return err
+			}
+			err = db.QueryRow(`
+				SELECT COALESCE(NULLIF(s.title, ''), s.service_date)
+				FROM services s JOIN service_items i ON i.service_id = s.id
+				WHERE s.service_date = ? AND i.item_type = 'song' AND i.songbook_acronym = ? AND i.entry_text = ?
+				ORDER BY s.id LIMIT 1`, today, acronym, entryText).Scan(&serviceName)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+		}
+		return a.recordSongUsage(db, songId, today, serviceName, usageProjection)
+	})
+}
+
+// recordServiceUsage records every song of a service plan under the service
+// date. Plans of future services are not recorded yet, as they may still
+// change before the service.
+func (a *App) recordServiceUsage(svc dtoService, usageType string) error {
+	if svc.ServiceDate > time.Now().Format(serviceDateLayout) {
+		return nil
+	}
+	serviceName := svc.Title
+	if serviceName == "" {
+		serviceName = svc.ServiceDate
+	}
+	return a.withDB(func(db *sql.DB) error {
+		for _, item := range svc.Items {
+			if item.ItemType != SongItem || item.SongId == 0 {
+				continue
+			}
+			if err := a.recordSongUsage(db, item.SongId, svc.ServiceDate, serviceName, usageType); err != nil {
+				slog.Warn("Failed to record song usage", "songId", item.SongId, "error", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetSongLastUsed returns the usage summary of a single song
+func (a *App) GetSongLastUsed(songId int) (dtoSongUsage, error) {
+	var result dtoSongUsage
+	err := a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		result = dtoSongUsage{Id: songId, EntryText: entryText, SongbookAcronym: acronym}
+		return db.QueryRow(`
+			SELECT COUNT(*), COALESCE(MAX(used_on), '')
+			FROM song_usage
+			WHERE songbook_acronym = ? AND entry_text = ?`, acronym, entryText).
+			Scan(&result.UseCount, &result.LastUsed)
+	})
+	return result, err
+}
+
+// GetSongUsageStats returns songs used between from and to (inclusive, YYYY-MM-DD,
+// empty means unbounded), most used first. sourceFilter limits the songbook.
+func (a *App) GetSongUsageStats(from string, to string, sourceFilter string) ([]dtoSongUsage, error) {
+	conditions, args, err := usagePeriodConditions(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(sourceFilter) != "" {
+		conditions = append(conditions, "u.songbook_acronym = ?")
+		args = append(args, strings.TrimSpace(sourceFilter))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	result := []dtoSongUsage{}
+	err = a.withDB(func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT COALESCE(MIN(s.id), 0), COALESCE(MIN(s.entry), 0), u.entry_text, COALESCE(MIN(s.title), ''),
+			       u.songbook_acronym, COUNT(DISTINCT u.id), MAX(u.used_on)
+			FROM song_usage u
+			LEFT JOIN songs s ON COALESCE(s.songbook_acronym, '') = u.songbook_acronym
+			                 AND `+songEntryKeyExpr+` = u.entry_text
+			`+where+`
+			GROUP BY u.songbook_acronym, u.entry_text
+			ORDER BY COUNT(DISTINCT u.id) DESC, MAX(u.used_on) DESC`, args...)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error querying song usage: %s", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var u dtoSongUsage
+			if err := rows.Scan(&u.Id, &u.Entry, &u.EntryText, &u.Title, &u.SongbookAcronym, &u.UseCount, &u.LastUsed); err != nil {
+				slog.Error(fmt.Sprintf("Error scanning song usage row: %s", err))
+				return err
+			}
+			result = append(result, u)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
+
+// usagePeriodConditions validates an optional date range for song_usage queries
+func usagePeriodConditions(from string, to string) ([]string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	if strings.TrimSpace(from) != "" {
+		normalized, err := normalizeServiceDate(from)
+		if err != nil {
+			return nil, nil, err
+		}
+		conditions = append(conditions, "u.used_on >= ?")
+		args = append(args, normalized)
+	}
+	if strings.TrimSpace(to) != "" {
+		normalized, err := normalizeServiceDate(to)
+		if err != nil {
+			return nil, nil, err
+		}
+		conditions = append(conditions, "u.used_on <= ?")
+		args = append(args, normalized)
+	}
+	return conditions, args, nil
+}
+
+// GetNeverUsedSongs lists songs of a songbook ("" for all) that have no recorded use
+func (a *App) GetNeverUsedSongs(sourceFilter string) ([]dtoSongHeader, error) {
+	result := []dtoSongHeader{}
+	err := a.withDB(func(db *sql.DB) error {
+		query := `
+			SELECT s.id, s.entry, COALESCE(s.title, ''), COALESCE(s.title_d, ''), COALESCE(s.kytara_file, '')
+			FROM songs s
+			WHERE NOT EXISTS (
+				SELECT 1 FROM song_usage u
+				WHERE u.songbook_acronym = COALESCE(s.songbook_acronym, '')
+				  AND u.entry_text = ` + songEntryKeyExpr + `)`
+		var args []interface{}
+		if strings.TrimSpace(sourceFilter) != "" {
+			query += ` AND s.songbook_acronym = ?`
+			args = append(args, strings.TrimSpace(sourceFilter))
+		}
+		query += ` ORDER BY s.songbook_acronym, s.entry`
+
+		rows, err := db.Query(query, args...)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error querying unused songs: %s", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var h dtoSongHeader
+			if err := rows.Scan(&h.Id, &h.Entry, &h.Title, &h.TitleD, &h.KytaraFile); err != nil {
+				slog.Error(fmt.Sprintf("Error scanning row: %s", err))
+				return err
+			}
+			result = append(result, h)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
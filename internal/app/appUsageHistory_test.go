@@ -0,0 +1,255 @@
+package app
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+// insertUsageSongs inserts three EZ songs and one KK song and returns their ids
+func insertUsageSongs(t *testing.T, app *App) []int {
+	t.Helper()
+	var ids []int
+	err := app.withDB(func(db *sql.DB) error {
+		songs := []struct {
+			acronym string
+			entry   int
+			title   string
+		}{
+			{"EZ", 1, "Kdo se vzdává"},
+			{"EZ", 2, "Blaze tomu"},
+			{"EZ", 3, "Proč se bouří"},
+			{"KK", 1, "Ejhle oltář"},
+		}
+		for _, s := range songs {
+			r, err := db.Exec(`INSERT INTO songs (songbook_acronym, title, title_d, verse_order, entry, entry_text) VALUES (?, ?, ?, '', ?, ?)`,
+				s.acronym, s.title, removeDiacritics(s.title), s.entry, s.entry)
+			if err != nil {
+				return err
+			}
+			id, _ := r.LastInsertId()
+			if _, err := db.Exec(`INSERT INTO verses (song_id, name, lines, lines_d) VALUES (?, 'v1', 'text', 'text')`, id); err != nil {
+				return err
+			}
+			ids = append(ids, int(id))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	return ids
+}
+
+func recordUsage(t *testing.T, app *App, songID int, usedOn, serviceName string) {
+	t.Helper()
+	err := app.withDB(func(db *sql.DB) error {
+		return app.recordSongUsage(db, songID, usedOn, serviceName, usageProjection)
+	})
+	if err != nil {
+		t.Fatalf("recordSongUsage: %v", err)
+	}
+}
+
+func TestRecordSongUsage_DeduplicatesSameDay(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertUsageSongs(t, app)
+
+	recordUsage(t, app, ids[0], "2026-01-04", "Bohoslužba")
+	recordUsage(t, app, ids[0], "2026-01-04", "Bohoslužba")
+	recordUsage(t, app, ids[0], "2026-01-11", "Bohoslužba")
+
+	usage, err := app.GetSongLastUsed(ids[0])
+	if err != nil {
+		t.Fatalf("GetSongLastUsed: %v", err)
+	}
+	if usage.UseCount != 2 || usage.LastUsed != "2026-01-11" {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+
+	if err := app.RecordSongProjection(ids[1], ""); err != nil {
+		t.Fatalf("RecordSongProjection: %v", err)
+	}
+	usage, _ = app.GetSongLastUsed(ids[1])
+	if usage.LastUsed != time.Now().Format(serviceDateLayout) {
+		t.Errorf("expected today's date, got %+v", usage)
+	}
+}
+
+func TestGetSongUsageStats(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertUsageSongs(t, app)
+
+	recordUsage(t, app, ids[0], "2025-12-24", "Štědrý večer")
+	recordUsage(t, app, ids[1], "2026-01-04", "Bohoslužba")
+	recordUsage(t, app, ids[1], "2026-01-11", "Bohoslužba")
+	recordUsage(t, app, ids[3], "2026-01-11", "Mše")
+
+	tests := []struct {
+		name       string
+		from, to   string
+		source     string
+		wantTitles []string
+		wantErr    bool
+	}{
+		{name: "all time", wantTitles: []string{"Blaze tomu", "Ejhle oltář", "Kdo se vzdává"}},
+		{name: "period", from: "2026-01-01", to: "2026-01-31", wantTitles: []string{"Blaze tomu", "Ejhle oltář"}},
+		{name: "songbook filter", source: "EZ", wantTitles: []string{"Blaze tomu", "Kdo se vzdává"}},
+		{name: "invalid date", from: "1.1.2026", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := app.GetSongUsageStats(tt.from, tt.to, tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(stats) != len(tt.wantTitles) {
+				t.Fatalf("expected %d rows, got %+v", len(tt.wantTitles), stats)
+			}
+			for i, title := range tt.wantTitles {
+				if stats[i].Title != title {
+					t.Errorf("row %d = %q, want %q", i, stats[i].Title, title)
+				}
+			}
+		})
+	}
+
+	stats, _ := app.GetSongUsageStats("", "", "EZ")
+	if stats[0].UseCount != 2 || stats[0].LastUsed != "2026-01-11" || stats[0].Id != ids[1] {
+		t.Errorf("unexpected top row: %+v", stats[0])
+	}
+}
+
+func TestGetNeverUsedSongs(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertUsageSongs(t, app)
+
+	recordUsage(t, app, ids[1], "2026-01-04", "")
+
+	unused, err := app.GetNeverUsedSongs("EZ")
+	if err != nil {
+		t.Fatalf("GetNeverUsedSongs: %v", err)
+	}
+	if len(unused) != 2 || unused[0].Id != ids[0] || unused[1].Id != ids[2] {
+		t.Errorf("unexpected unused songs: %+v", unused)
+	}
+
+	all, _ := app.GetNeverUsedSongs("")
+	if len(all) != 3 {
+		t.Errorf("expected 3 unused songs across songbooks, got %d", len(all))
+	}
+}
+
+func TestGetSongs_LastUsedSorting(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertUsageSongs(t, app)
+
+	recordUsage(t, app, ids[2], "2026-01-04", "")
+	recordUsage(t, app, ids[0], "2026-01-11", "")
+
+	songs, err := app.GetSongs(string(LastUsed), "", "EZ")
+	if err != nil {
+		t.Fatalf("GetSongs: %v", err)
+	}
+	want := []string{"Kdo se vzdává", "Proč se bouří", "Blaze tomu"}
+	for i, title := range want {
+		if songs[i].Title != title {
+			t.Errorf("song %d = %q, want %q", i, songs[i].Title, title)
+		}
+	}
+	if songs[0].LastUsed != "2026-01-11" || songs[2].LastUsed != "" {
+		t.Errorf("unexpected LastUsed values: %q, %q", songs[0].LastUsed, songs[2].LastUsed)
+	}
+}
+
+func TestServiceUsageRecording(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertUsageSongs(t, app)
+
+	id, err := app.SaveService(dtoService{
+		ServiceDate: "2026-02-01",
+		Title:       "Bohoslužba",
+		Items: []dtoServiceItem{
+			{SongId: ids[0]},
+			{ItemType: ReadingItem, ScriptureRef: "Ž 1"},
+			{SongId: ids[1]},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SaveService: %v", err)
+	}
+
+	svc, _ := app.GetService(id)
+	if _, err := app.GetServiceItemProjection(svc.Items[0].Id); err != nil {
+		t.Fatalf("GetServiceItemProjection: %v", err)
+	}
+	usage, _ := app.GetSongLastUsed(ids[0])
+	if usage.UseCount != 0 {
+		t.Errorf("projection getter should not record usage, got %+v", usage)
+	}
+
+	if _, err := app.GetServiceOrderPdf(id); err != nil {
+		t.Fatalf("GetServiceOrderPdf: %v", err)
+	}
+	usage, _ = app.GetSongLastUsed(ids[1])
+	if usage.UseCount != 1 {
+		t.Errorf("export should record usage of all songs, got %+v", usage)
+	}
+}
+
+func TestProjectionUsageRecording(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	ids := insertUsageSongs(t, app)
+
+	// loading a song for the projection window is only a preview
+	if _, err := app.GetSongProjection(ids[0]); err != nil {
+		t.Fatalf("GetSongProjection: %v", err)
+	}
+	if usage, _ := app.GetSongLastUsed(ids[0]); usage.UseCount != 0 {
+		t.Errorf("projection getter should not record usage, got %+v", usage)
+	}
+
+	today := time.Now().Format(serviceDateLayout)
+	if _, err := app.SaveService(dtoService{ServiceDate: today, Title: "Nedělní bohoslužba", Items: []dtoServiceItem{{SongId: ids[0]}}}); err != nil {
+		t.Fatalf("SaveService: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := app.RecordSongProjection(ids[0], ""); err != nil {
+			t.Fatalf("RecordSongProjection: %v", err)
+		}
+	}
+	usage, _ := app.GetSongLastUsed(ids[0])
+	if usage.UseCount != 1 || usage.LastUsed != today {
+		t.Errorf("projection should be recorded once today, got %+v", usage)
+	}
+	var serviceName string
+	app.withDB(func(db *sql.DB) error {
+		return db.QueryRow(`SELECT service_name FROM song_usage`).Scan(&serviceName)
+	})
+	if serviceName != "Nedělní bohoslužba" {
+		t.Errorf("projection should be recorded under today's service, got %q", serviceName)
+	}
+
+	// plans printed ahead of the service are not used yet
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(serviceDateLayout)
+	id, err := app.SaveService(dtoService{ServiceDate: tomorrow, Title: "Bohoslužba", Items: []dtoServiceItem{{SongId: ids[1]}}})
+	if err != nil {
+		t.Fatalf("SaveService: %v", err)
+	}
+	if _, err := app.GetServiceOrderPdf(id); err != nil {
+		t.Fatalf("GetServiceOrderPdf: %v", err)
+	}
+	if usage, _ := app.GetSongLastUsed(ids[1]); usage.UseCount != 0 {
+		t.Errorf("future service should not be recorded, got %+v", usage)
+	}
+}
@@ -0,0 +1,250 @@
+package app
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// usageReportRow is one song of a copyright usage report
+type usageReportRow struct {
+	SongbookAcronym string
+	SongbookName    string
+	EntryText       string
+	Title           string
+	AuthorWords     string
+	AuthorMusic     string
+	Translators     string
+	Copyright       string
+	CcliNo          string
+	UseCount        int
+}
+
+// buildUsageReport collects songs used in the period together with their
+// authors, copyright and songbook metadata, ordered by songbook and entry.
+// Songs deleted since are described by their latest revision.
+func (a *App) buildUsageReport(from string, to string) ([]usageReportRow, error) {
+	conditions, args, err := usagePeriodConditions(from, to)
+	if err != nil {
+		return nil, err
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows := []usageReportRow{}
+	err = a.withDB(func(db *sql.DB) error {
+		result, err := db.Query(`
+			SELECT u.songbook_acronym,
+			       COALESCE((SELECT name FROM songbooks b WHERE b.songbook_acronym = u.songbook_acronym), u.songbook_acronym),
+			       u.entry_text,
+			       COALESCE(MIN(s.title), ''),
+			       COALESCE(MIN(s.id), 0),
+			       COUNT(DISTINCT u.id)
+			FROM song_usage u
+			LEFT JOIN songs s ON COALESCE(s.songbook_acronym, '') = u.songbook_acronym
+			                 AND `+songEntryKeyExpr+` = u.entry_text
+			`+where+`
+			GROUP BY u.songbook_acronym, u.entry_text
+			ORDER BY u.songbook_acronym, CAST(u.entry_text AS INTEGER), u.entry_text`, args...)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error querying usage report: %s", err))
+			return err
+		}
+
+		var songIDs []int
+		for result.Next() {
+			var row usageReportRow
+			var songID int
+			if err := result.Scan(&row.SongbookAcronym, &row.SongbookName, &row.EntryText, &row.Title, &songID, &row.UseCount); err != nil {
+				result.Close()
+				slog.Error(fmt.Sprintf("Error scanning usage report row: %s", err))
+				return err
+			}
+			rows = append(rows, row)
+			songIDs = append(songIDs, songID)
+		}
+		result.Close()
+		if err := result.Err(); err != nil {
+			return err
+		}
+
+		for i, songID := range songIDs {
+			if songID == 0 {
+				if err := fillUsageRowFromRevision(db, &rows[i]); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := db.QueryRow(`SELECT COALESCE(copyright, ''), COALESCE(ccli_no, '') FROM songs WHERE id = ?`, songID).
+				Scan(&rows[i].Copyright, &rows[i].CcliNo); err != nil {
+				return err
+			}
+			authors, err := loadSongAuthors(db, songID)
+			if err != nil {
+				return err
+			}
+			rows[i].setAuthors(authors)
+		}
+		return nil
+	})
+	return rows, err
+}
+
+// fillUsageRowFromRevision takes the title, authors and copyright of a
+// deleted song from its latest revision, if it has one
+func fillUsageRowFromRevision(db *sql.DB, row *usageReportRow) error {
+	var snapshot string
+	err := db.QueryRow(`SELECT snapshot FROM song_revisions WHERE songbook_acronym = ? AND entry_text = ? ORDER BY id DESC LIMIT 1`,
+		row.SongbookAcronym, row.EntryText).Scan(&snapshot)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var draft dtoSongDraft
+	if err := json.Unmarshal([]byte(snapshot), &draft); err != nil {
+		return err
+	}
+	row.Title = draft.Title
+	row.Copyright = draft.Copyright
+	row.setAuthors(draft.Authors)
+	return nil
+}
+
+// loadSongAuthors returns the authors of a song in their stored order
+func loadSongAuthors(db *sql.DB, songID int) ([]Author, error) {
+	rows, err := db.Query(`SELECT COALESCE(author_type, ''), COALESCE(author_value, '') FROM authors WHERE song_id = ? ORDER BY id`, songID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var authors []Author
+	for rows.Next() {
+		var author Author
+		if err := rows.Scan(&author.Type, &author.Value); err != nil {
+			return nil, err
+		}
+		authors = append(authors, author)
+	}
+	return authors, rows.Err()
+}
+
+// setAuthors fills the lyric, music and translation authors joined by "; "
+func (row *usageReportRow) setAuthors(authors []Author) {
+	var words, music, translators []string
+	for _, author := range authors {
+		switch author.Type {
+		case "music":
+			music = append(music, author.Value)
+		case "translation":
+			translators = append(translators, author.Value)
+		default:
+			words = append(words, author.Value)
+		}
+	}
+	row.AuthorWords = strings.Join(words, "; ")
+	row.AuthorMusic = strings.Join(music, "; ")
+	row.Translators = strings.Join(translators, "; ")
+}
+
+// usageReportCsv renders the report as UTF-8 CSV with a BOM so spreadsheet
+// applications detect the encoding of Czech names.
+func usageReportCsv(rows []usageReportRow) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(buf)
+	if err := w.Write([]string{"Songbook", "SongbookName", "Entry", "Title", "AuthorWords", "AuthorMusic", "Translators", "Copyright", "CCLI", "Uses"}); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		record := []string{row.SongbookAcronym, row.SongbookName, row.EntryText, row.Title, row.AuthorWords, row.AuthorMusic,
+			row.Translators, row.Copyright, row.CcliNo, strconv.Itoa(row.UseCount)}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// usageReportLines builds the printable lines of the usage report
+func usageReportLines(rows []usageReportRow, from string, to string) []pdfTextLine {
+	period := strings.TrimSpace(from + " – " + to)
+	if from == "" && to == "" {
+		period = "celé období"
+	}
+	lines := []pdfTextLine{
+		{Text: "Hlášení o užití písní", Bold: true, Size: 18},
+		{Text: "Období: " + period, Size: 12},
+	}
+	if len(rows) == 0 {
+		lines = append(lines, pdfTextLine{Text: "V tomto období nebyly použity žádné písně.", SpaceBefore: 8})
+		return lines
+	}
+
+	total := 0
+	for _, row := range rows {
+		total += row.UseCount
+		lines = append(lines, pdfTextLine{
+			Text:        fmt.Sprintf("%s %s – %s (%d×)", row.SongbookAcronym, row.EntryText, row.Title, row.UseCount),
+			Bold:        true,
+			SpaceBefore: 6,
+		})
+		var credits []string
+		if row.AuthorWords != "" {
+			credits = append(credits, "Text: "+row.AuthorWords)
+		}
+		if row.AuthorMusic != "" {
+			credits = append(credits, "Hudba: "+row.AuthorMusic)
+		}
+		if row.Translators != "" {
+			credits = append(credits, "Překlad: "+row.Translators)
+		}
+		if row.Copyright != "" {
+			credits = append(credits, "© "+row.Copyright)
+		}
+		if row.CcliNo != "" {
+			credits = append(credits, "CCLI: "+row.CcliNo)
+		}
+		credits = append(credits, "Zpěvník: "+row.SongbookName)
+		lines = append(lines, pdfTextLine{Text: strings.Join(credits, " | "), Size: 9, Indent: 15})
+	}
+	lines = append(lines, pdfTextLine{Text: fmt.Sprintf("Celkem písní: %d, celkem užití: %d", len(rows), total), Bold: true, SpaceBefore: 12})
+	return lines
+}
+
+// ExportUsageReportCsv returns the usage report for the period as a CSV data URL
+func (a *App) ExportUsageReportCsv(from string, to string) (string, error) {
+	rows, err := a.buildUsageReport(from, to)
+	if err != nil {
+		return "", err
+	}
+	data, err := usageReportCsv(rows)
+	if err != nil {
+		return "", err
+	}
+	return "data:text/csv;charset=utf-8;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// ExportUsageReportPdf returns the usage report for the period as a PDF data URL
+func (a *App) ExportUsageReportPdf(from string, to string) (string, error) {
+	rows, err := a.buildUsageReport(from, to)
+	if err != nil {
+		return "", err
+	}
+	c, err := renderTextPdf(usageReportLines(rows, from, to))
+	if err != nil {
+		return "", err
+	}
+	return a.encodeCreatorToPdf(c)
+}
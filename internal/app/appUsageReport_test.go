@@ -0,0 +1,159 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func setupUsageReport(t *testing.T) *App {
+	t.Helper()
+	app := setupTestDB(t)
+	ids := insertUsageSongs(t, app)
+
+	err := app.withDB(func(db *sql.DB) error {
+		if _, err := app.getOrCreateSongbook(db, "EZ", "Evangelický zpěvník 2021"); err != nil {
+			return err
+		}
+		if err := app.insertAuthors(db, int64(ids[0]), []Author{
+			{Type: "words", Value: "Miloslav Esterle"},
+			{Type: "music", Value: "Loys Bourgeois"},
+			{Type: "translation", Value: "Jan Blahoslav"},
+		}, "test"); err != nil {
+			return err
+		}
+		_, err := db.Exec(`UPDATE songs SET copyright = 'Kalich', ccli_no = '12345' WHERE id = ?`, ids[0])
+		return err
+	})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	recordUsage(t, app, ids[0], "2026-01-04", "Bohoslužba")
+	recordUsage(t, app, ids[0], "2026-01-11", "Bohoslužba")
+	recordUsage(t, app, ids[3], "2026-01-11", "Mše")
+	recordUsage(t, app, ids[2], "2025-06-01", "Bohoslužba")
+	return app
+}
+
+func TestBuildUsageReport(t *testing.T) {
+	app := setupUsageReport(t)
+	defer teardownTestDB(app)
+
+	rows, err := app.buildUsageReport("2026-01-01", "2026-03-31")
+	if err != nil {
+		t.Fatalf("buildUsageReport: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %+v", rows)
+	}
+
+	ez := rows[0]
+	if ez.SongbookAcronym != "EZ" || ez.SongbookName != "Evangelický zpěvník 2021" || ez.EntryText != "1" || ez.UseCount != 2 {
+		t.Errorf("unexpected EZ row: %+v", ez)
+	}
+	if ez.AuthorWords != "Miloslav Esterle" || ez.AuthorMusic != "Loys Bourgeois" || ez.Translators != "Jan Blahoslav" {
+		t.Errorf("unexpected authors: %+v", ez)
+	}
+	if ez.Copyright != "Kalich" || ez.CcliNo != "12345" {
+		t.Errorf("unexpected copyright: %+v", ez)
+	}
+	// KK has no songbooks row in this database, so the acronym is used as name
+	if rows[1].SongbookAcronym != "KK" || rows[1].SongbookName != "KK" {
+		t.Errorf("unexpected KK row: %+v", rows[1])
+	}
+
+	if _, err := app.buildUsageReport("bad", ""); err == nil {
+		t.Error("expected error for invalid date")
+	}
+}
+
+func TestExportUsageReportCsv(t *testing.T) {
+	app := setupUsageReport(t)
+	defer teardownTestDB(app)
+
+	dataURL, err := app.ExportUsageReportCsv("", "")
+	if err != nil {
+		t.Fatalf("ExportUsageReportCsv: %v", err)
+	}
+	const prefix = "data:text/csv;charset=utf-8;base64,"
+	if !strings.HasPrefix(dataURL, prefix) {
+		t.Fatalf("unexpected data URL prefix: %q", dataURL[:min(len(dataURL), 40)])
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(dataURL, prefix))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(raw), "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("expected header and 3 rows, got %d", len(records))
+	}
+	if records[0][0] != "Songbook" || records[1][3] != "Kdo se vzdává" || records[1][9] != "2" {
+		t.Errorf("unexpected CSV content: %v", records)
+	}
+	if records[1][6] != "Jan Blahoslav" || records[1][7] != "Kalich" || records[1][8] != "12345" {
+		t.Errorf("unexpected translator and copyright columns: %v", records[1])
+	}
+}
+
+func TestBuildUsageReport_DeletedSong(t *testing.T) {
+	app := setupUsageReport(t)
+	defer teardownTestDB(app)
+
+	// EZ 3 was sung, then deleted; its latest revision still describes it
+	err := app.withDB(func(db *sql.DB) error {
+		if _, err := db.Exec(`INSERT INTO song_revisions (songbook_acronym, entry_text, snapshot) VALUES ('EZ', '3', ?)`,
+			`{"Title":"Proč se bouří","Authors":[{"Type":"words","Value":"Jiří Třanovský"}],"Copyright":"Kalich"}`); err != nil {
+			return err
+		}
+		_, err := db.Exec(`DELETE FROM songs WHERE songbook_acronym = 'EZ' AND entry = 3`)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := app.buildUsageReport("", "")
+	if err != nil {
+		t.Fatalf("buildUsageReport: %v", err)
+	}
+	var deleted usageReportRow
+	for _, row := range rows {
+		if row.SongbookAcronym == "EZ" && row.EntryText == "3" {
+			deleted = row
+		}
+	}
+	if deleted.Title != "Proč se bouří" || deleted.AuthorWords != "Jiří Třanovský" || deleted.Copyright != "Kalich" {
+		t.Errorf("expected the deleted song from its revision, got %+v", deleted)
+	}
+}
+
+func TestExportUsageReportPdf(t *testing.T) {
+	app := setupUsageReport(t)
+	defer teardownTestDB(app)
+
+	lines := usageReportLines(nil, "2026-01-01", "2026-01-31")
+	if len(lines) != 3 || lines[1].Text != "Období: 2026-01-01 – 2026-01-31" {
+		t.Errorf("unexpected empty report lines: %+v", lines)
+	}
+
+	lines = usageReportLines([]usageReportRow{{SongbookAcronym: "EZ", EntryText: "1", SongbookName: "EZ", Title: "Kdo se vzdává",
+		Translators: "Jan Blahoslav", Copyright: "Kalich", CcliNo: "12345", UseCount: 1}}, "", "")
+	if credits := lines[3].Text; credits != "Překlad: Jan Blahoslav | © Kalich | CCLI: 12345 | Zpěvník: EZ" {
+		t.Errorf("unexpected credits line: %q", credits)
+	}
+
+	dataURL, err := app.ExportUsageReportPdf("2026-01-01", "2026-01-31")
+	if err != nil {
+		t.Fatalf("ExportUsageReportPdf: %v", err)
+	}
+	if !strings.HasPrefix(dataURL, "data:application/pdf;base64,") {
+		t.Errorf("expected PDF data URL")
+	}
+}
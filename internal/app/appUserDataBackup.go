@@ -0,0 +1,651 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// User data archive layout
+const (
+	backupFormat        = "lyyyra-user-data"
+	backupFormatVersion = 1
+	backupManifestName  = "manifest.json"
+	backupDataName      = "data.json"
+	backupStatusName    = "status.yaml"
+	backupSettingsName  = settingsFileName
+)
+
+// Import modes of a user data archive
+const (
+	backupMerge   = "merge"
+	backupReplace = "replace"
+)
+
+// backupTables lists the user data tables stored in an archive with their
+// columns. Rows with equal key columns are considered the same when merging.
+var backupTables = []struct {
+	name    string
+	columns []string
+	key     []string
+}{
+	{"song_arrangements", []string{"songbook_acronym", "entry_text", "verse_order", "updated_at"}, []string{"songbook_acronym", "entry_text"}},
+	{"song_usage", []string{"songbook_acronym", "entry_text", "used_on", "service_name", "usage_type", "created_at"}, []string{"songbook_acronym", "entry_text", "used_on", "service_name"}},
+	{"song_favorites", []string{"songbook_acronym", "entry_text", "created_at"}, []string{"songbook_acronym", "entry_text"}},
+	{"song_tags", []string{"songbook_acronym", "entry_text", "tag", "created_at"}, []string{"songbook_acronym", "entry_text", "tag"}},
+	{"song_scripture_refs", []string{"songbook_acronym", "entry_text", "book", "start_pos", "end_pos", "reference", "source", "created_at"}, []string{"songbook_acronym", "entry_text", "reference"}},
+	{"song_tunes", []string{"songbook_acronym", "entry_text", "tune_name", "meter", "updated_at"}, []string{"songbook_acronym", "entry_text"}},
+	{"song_overlays", []string{"songbook_acronym", "entry_text", "field", "verse_name", "value", "original", "updated_at"}, []string{"songbook_acronym", "entry_text", "field", "verse_name"}},
+	{"song_revisions", []string{"songbook_acronym", "entry_text", "edited_by", "snapshot", "created_at"}, []string{"songbook_acronym", "entry_text", "created_at", "snapshot"}},
+	{"liturgical_song_rules", []string{"occasion", "tag", "songbook_acronym", "entry_from", "entry_to", "created_at"}, []string{"occasion", "tag", "songbook_acronym", "entry_from", "entry_to"}},
+}
+
+// backupManifest identifies a user data archive and the schema it was made from
+type backupManifest struct {
+	Format        string `json:"format"`
+	Version       int    `json:"version"`
+	SchemaVersion int    `json:"schemaVersion"`
+	BuildVersion  string `json:"buildVersion"`
+	CreatedAt     string `json:"createdAt"`
+}
+
+// backupData is the content of data.json. Songs are referenced by songbook
+// acronym and entry text, as row ids differ between databases.
+type backupData struct {
+	Songbooks []backupSongbook           `json:"songbooks"`
+	Services  []backupService            `json:"services"`
+	Tables    map[string]backupTableRows `json:"tables"`
+}
+
+type backupSongbook struct {
+	Acronym string       `json:"acronym"`
+	Name    string       `json:"name"`
+	Songs   []backupSong `json:"songs"`
+}
+
+type backupSong struct {
+	EntryText string       `json:"entryText"`
+	Song      dtoSongDraft `json:"song"`
+}
+
+type backupService struct {
+	ServiceDate string              `json:"serviceDate"`
+	Title       string              `json:"title"`
+	Notes       string              `json:"notes"`
+	Items       []backupServiceItem `json:"items"`
+}
+
+type backupServiceItem struct {
+	ItemType        ServiceItemType `json:"itemType"`
+	SongbookAcronym string          `json:"songbookAcronym,omitempty"`
+	EntryText       string          `json:"entryText,omitempty"`
+	VerseOrder      string          `json:"verseOrder"`
+	Notes           string          `json:"notes"`
+	Title           string          `json:"title"`
+	Content         string          `json:"content"`
+	ScriptureRef    string          `json:"scriptureRef"`
+}
+
+// backupTableRows holds rows of a table as text, NULL values as nil
+type backupTableRows struct {
+	Columns []string    `json:"columns"`
+	Rows    [][]*string `json:"rows"`
+}
+
+// exportBackupSongbooks reads all user songbooks with their songs
+func (a *App) exportBackupSongbooks(db *sql.DB) ([]backupSongbook, error) {
+	rows, err := db.Query(`SELECT songbook_acronym, name FROM songbooks ORDER BY songbook_acronym`)
+	if err != nil {
+		return nil, err
+	}
+	var books []backupSongbook
+	for rows.Next() {
+		var book backupSongbook
+		if err := rows.Scan(&book.Acronym, &book.Name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if !isBuiltinSongbook(book.Acronym) {
+			books = append(books, book)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range books {
+		songRows, err := db.Query(`SELECT id, `+songEntryKeyExpr+` FROM songs s WHERE songbook_acronym = ? ORDER BY entry, entry_text`, books[i].Acronym)
+		if err != nil {
+			return nil, err
+		}
+		var songs []backupSong
+		var ids []int
+		for songRows.Next() {
+			var id int
+			var song backupSong
+			if err := songRows.Scan(&id, &song.EntryText); err != nil {
+				songRows.Close()
+				return nil, err
+			}
+			ids = append(ids, id)
+			songs = append(songs, song)
+		}
+		songRows.Close()
+		for j, id := range ids {
+			draft, err := a.loadSongDraft(db, id)
+			if err != nil {
+				return nil, err
+			}
+			draft.Id = 0
+			songs[j].Song = draft
+		}
+		books[i].Songs = songs
+	}
+	return books, nil
+}
+
+// exportBackupServices reads all service plans with song items referenced by key
+func exportBackupServices(db *sql.DB) ([]backupService, error) {
+	rows, err := db.Query(`SELECT id, service_date, title, notes FROM services ORDER BY service_date, id`)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	var services []backupService
+	for rows.Next() {
+		var id int
+		var svc backupService
+		if err := rows.Scan(&id, &svc.ServiceDate, &svc.Title, &svc.Notes); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+		services = append(services, svc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, id := range ids {
+		itemRows, err := db.Query(`
+			SELECT i.item_type, i.songbook_acronym, i.entry_text,
+			       i.verse_order, i.notes, i.title, i.content, i.scripture_ref
+			FROM service_items i
+			WHERE i.service_id = ?
+			ORDER BY i.position, i.id`, id)
+		if err != nil {
+			return nil, err
+		}
+		for itemRows.Next() {
+			var item backupServiceItem
+			if err := itemRows.Scan(&item.ItemType, &item.SongbookAcronym, &item.EntryText,
+				&item.VerseOrder, &item.Notes, &item.Title, &item.Content, &item.ScriptureRef); err != nil {
+				itemRows.Close()
+				return nil, err
+			}
+			services[i].Items = append(services[i].Items, item)
+		}
+		itemRows.Close()
+	}
+	return services, nil
+}
+
+// exportBackupTable reads the listed columns of a table as text. Casting
+// keeps timestamps in the format SQLite stores them in.
+func exportBackupTable(db *sql.DB, table string, columns []string) (backupTableRows, error) {
+	result := backupTableRows{Columns: columns, Rows: [][]*string{}}
+	selects := make([]string, len(columns))
+	for i, column := range columns {
+		selects[i] = "CAST(" + column + " AS TEXT)"
+	}
+	rows, err := db.Query(`SELECT ` + strings.Join(selects, ", ") + ` FROM ` + table + ` ORDER BY rowid`)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return result, err
+		}
+		row := make([]*string, len(columns))
+		for i, value := range values {
+			if value.Valid {
+				text := value.String
+				row[i] = &text
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, rows.Err()
+}
+
+// exportBackupData reads the user songbooks, service plans and user data
+// tables of the database
+func (a *App) exportBackupData() (backupData, error) {
+	data := backupData{Tables: map[string]backupTableRows{}}
+	err := a.withDB(func(db *sql.DB) error {
+		var err error
+		if data.Songbooks, err = a.exportBackupSongbooks(db); err != nil {
+			return err
+		}
+		if data.Services, err = exportBackupServices(db); err != nil {
+			return err
+		}
+		for _, table := range backupTables {
+			rows, err := exportBackupTable(db, table.name, table.columns)
+			if err != nil {
+				return fmt.Errorf("failed to export %s: %w", table.name, err)
+			}
+			data.Tables[table.name] = rows
+		}
+		return nil
+	})
+	return data, err
+}
+
+// ExportUserData returns all user-owned data as a zip archive data URL:
+// service plans, user songbooks, tags, favorites, local edits, revisions,
+// usage history, liturgical rules, the preferences from status.yaml and the
+// saved settings.yaml. Downloaded songbooks are not included, they are
+// downloaded again.
+func (a *App) ExportUserData() (string, error) {
+	manifest := backupManifest{
+		Format:        backupFormat,
+		Version:       backupFormatVersion,
+		SchemaVersion: CurrentDBVersion,
+		BuildVersion:  buildVersion,
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+	}
+	data, err := a.exportBackupData()
+	if err != nil {
+		slog.Error(fmt.Sprintf("User data export failed: %s", err))
+		return "", err
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	status, err := yaml.Marshal(&a.status)
+	if err != nil {
+		return "", err
+	}
+
+	entries := []struct {
+		name string
+		data []byte
+	}{
+		{backupManifestName, manifestJSON},
+		{backupDataName, dataJSON},
+		{backupStatusName, status},
+	}
+	if a.settingsPath != "" {
+		if settings, err := os.ReadFile(a.settingsPath); err == nil {
+			entries = append(entries, struct {
+				name string
+				data []byte
+			}{backupSettingsName, settings})
+		}
+	}
+
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		w, err := zipWriter.Create(entry.name)
+		if err != nil {
+			return "", err
+		}
+		if _, err := w.Write(entry.data); err != nil {
+			return "", err
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		return "", err
+	}
+	return "data:application/zip;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// readBackupArchive reads and validates a user data archive. It also returns
+// the content of status.yaml and settings.yaml by name.
+func readBackupArchive(path string) (backupManifest, backupData, map[string][]byte, error) {
+	var manifest backupManifest
+	var data backupData
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return manifest, data, nil, fmt.Errorf("not a user data archive: %w", err)
+	}
+	defer reader.Close()
+
+	files := map[string][]byte{}
+	for _, file := range reader.File {
+		switch file.Name {
+		case backupManifestName, backupDataName, backupStatusName, backupSettingsName:
+		default:
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return manifest, data, nil, err
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return manifest, data, nil, err
+		}
+		files[file.Name] = content
+	}
+
+	if err := json.Unmarshal(files[backupManifestName], &manifest); err != nil || manifest.Format != backupFormat {
+		return manifest, data, nil, fmt.Errorf("not a user data archive: missing or invalid %s", backupManifestName)
+	}
+	if manifest.Version > backupFormatVersion || manifest.SchemaVersion > CurrentDBVersion {
+		return manifest, data, nil, fmt.Errorf("archive was created by a newer version (%s, schema %d)", manifest.BuildVersion, manifest.SchemaVersion)
+	}
+	if err := json.Unmarshal(files[backupDataName], &data); err != nil {
+		return manifest, data, nil, fmt.Errorf("invalid %s: %w", backupDataName, err)
+	}
+	return manifest, data, files, nil
+}
+
+// importBackupSongbooks creates the user songbooks of an archive. Songs whose
+// number already exists in the songbook are skipped.
+func (a *App) importBackupSongbooks(tx *sql.Tx, books []backupSongbook, summary *dtoBackupSummary) error {
+	for _, book := range books {
+		if isBuiltinSongbook(book.Acronym) || !songbookAcronymPattern.MatchString(book.Acronym) {
+			summary.Skipped = append(summary.Skipped, fmt.Sprintf("%s: invalid songbook", book.Acronym))
+			continue
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO songbooks (songbook_acronym, name) VALUES (?, ?)`, book.Acronym, book.Name); err != nil {
+			return err
+		}
+		summary.Songbooks++
+		for _, entry := range book.Songs {
+			label := book.Acronym + " " + entry.EntryText
+			var existing int
+			if err := tx.QueryRow(`SELECT COUNT(*) FROM songs s WHERE s.songbook_acronym = ? AND `+songEntryKeyExpr+` = ?`,
+				book.Acronym, entry.EntryText).Scan(&existing); err != nil {
+				return err
+			}
+			if existing > 0 {
+				summary.Skipped = append(summary.Skipped, label+": song already exists")
+				continue
+			}
+			draft, err := normalizeSongDraft(entry.Song)
+			if err != nil {
+				summary.Skipped = append(summary.Skipped, fmt.Sprintf("%s: %s", label, err))
+				continue
+			}
+			song := draftToSong(draft)
+			song.Songbook.Entry = entry.EntryText
+			id, err := a.insertFullSong(tx, song, book.Acronym, editorSourceName)
+			if err != nil {
+				return fmt.Errorf("failed to import %s: %w", label, err)
+			}
+			// entry texts like "23a" do not convert to the numeric entry
+			if _, err := tx.Exec(`UPDATE songs SET entry = ? WHERE id = ?`, draft.Entry, id); err != nil {
+				return err
+			}
+			summary.Songs++
+		}
+	}
+	return nil
+}
+
+// importBackupServices creates the service plans of an archive. A service
+// with the same date and title as an existing one is skipped. Song items keep
+// their song key, songs missing in this database show as missing items.
+func importBackupServices(tx *sql.Tx, services []backupService, summary *dtoBackupSummary) error {
+	for _, svc := range services {
+		date, err := normalizeServiceDate(svc.ServiceDate)
+		if err != nil {
+			summary.Skipped = append(summary.Skipped, err.Error())
+			continue
+		}
+		var existing int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM services WHERE service_date = ? AND title = ?`, date, svc.Title).Scan(&existing); err != nil {
+			return err
+		}
+		if existing > 0 {
+			summary.Skipped = append(summary.Skipped, fmt.Sprintf("%s %s: service already exists", date, svc.Title))
+			continue
+		}
+
+		items := make([]dtoServiceItem, 0, len(svc.Items))
+		for _, item := range svc.Items {
+			items = append(items, dtoServiceItem{ItemType: item.ItemType, SongbookAcronym: item.SongbookAcronym, EntryText: item.EntryText,
+				VerseOrder: item.VerseOrder, Notes: item.Notes, Title: item.Title, Content: item.Content, ScriptureRef: item.ScriptureRef})
+		}
+
+		result, err := tx.Exec(`INSERT INTO services (service_date, title, notes) VALUES (?, ?, ?)`, date, svc.Title, svc.Notes)
+		if err != nil {
+			return err
+		}
+		serviceID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		if err := insertServiceItems(tx, int(serviceID), items); err != nil {
+			return err
+		}
+		summary.Services++
+	}
+	return nil
+}
+
+// importBackupTable inserts archived rows that have no equal row by key.
+// Only known columns are written.
+func importBackupTable(tx *sql.Tx, table string, columns, key []string, data backupTableRows) (int, error) {
+	known := map[string]bool{}
+	for _, column := range columns {
+		known[column] = true
+	}
+	positions := map[string]int{}
+	var used []string
+	for i, column := range data.Columns {
+		if known[column] {
+			positions[column] = i
+			used = append(used, column)
+		}
+	}
+	for _, column := range key {
+		if _, ok := positions[column]; !ok {
+			return 0, fmt.Errorf("table %s misses column %s", table, column)
+		}
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(used)), ", ")
+	conditions := make([]string, len(key))
+	for i, column := range key {
+		conditions[i] = column + " IS ?"
+	}
+	query := `INSERT INTO ` + table + ` (` + strings.Join(used, ", ") + `) SELECT ` + placeholders +
+		` WHERE NOT EXISTS (SELECT 1 FROM ` + table + ` WHERE ` + strings.Join(conditions, " AND ") + `)`
+
+	inserted := 0
+	for _, row := range data.Rows {
+		if len(row) != len(data.Columns) {
+			return inserted, fmt.Errorf("table %s has a row with %d values, expected %d", table, len(row), len(data.Columns))
+		}
+		args := make([]interface{}, 0, len(used)+len(key))
+		for _, column := range used {
+			args = append(args, row[positions[column]])
+		}
+		for _, column := range key {
+			args = append(args, row[positions[column]])
+		}
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			return inserted, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			inserted++
+		}
+	}
+	return inserted, nil
+}
+
+// clearUserData deletes service plans, user songbooks and all user data
+// tables before an archive replaces them
+func clearUserData(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT songbook_acronym FROM songbooks`)
+	if err != nil {
+		return err
+	}
+	var acronyms []string
+	for rows.Next() {
+		var acronym string
+		if err := rows.Scan(&acronym); err != nil {
+			rows.Close()
+			return err
+		}
+		if !isBuiltinSongbook(acronym) {
+			acronyms = append(acronyms, acronym)
+		}
+	}
+	rows.Close()
+
+	for _, query := range []string{`DELETE FROM service_items`, `DELETE FROM services`} {
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+	}
+	for _, acronym := range acronyms {
+		if err := deleteSongbookRows(tx, acronym); err != nil {
+			return err
+		}
+	}
+	for _, table := range backupTables {
+		if _, err := tx.Exec(`DELETE FROM ` + table.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importBackupData writes user data read by exportBackupData into the
+// database in one transaction, deleting the local user data first when
+// replace is set, and applies local edits of EZ and KK songs
+func (a *App) importBackupData(data backupData, replace bool, summary *dtoBackupSummary) error {
+	return a.withDB(func(db *sql.DB) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if replace {
+			if err := clearUserData(tx); err != nil {
+				return err
+			}
+		}
+		if err := a.importBackupSongbooks(tx, data.Songbooks, summary); err != nil {
+			return err
+		}
+		if err := importBackupServices(tx, data.Services, summary); err != nil {
+			return err
+		}
+		for _, table := range backupTables {
+			rows, ok := data.Tables[table.name]
+			if !ok {
+				// archives of older schemas miss newer tables
+				continue
+			}
+			inserted, err := importBackupTable(tx, table.name, table.columns, table.key, rows)
+			if err != nil {
+				return fmt.Errorf("failed to import %s: %w", table.name, err)
+			}
+			summary.Rows += inserted
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		// songs, tags and local edits all feed the similar songs
+		a.markSimilarSongsStale()
+		_, err = a.applySongOverlays(db)
+		return err
+	})
+}
+
+// restoreBackupSettings saves archived settings, keeping the local data
+// directory as the archive may come from another machine
+func (a *App) restoreBackupSettings(content []byte) {
+	if a.settingsPath == "" {
+		return
+	}
+	settings := defaultSettings()
+	if err := yaml.Unmarshal(content, &settings); err != nil {
+		slog.Warn("Ignoring invalid settings in user data archive", "error", err)
+		return
+	}
+	local, _ := readSettingsFile(a.settingsPath)
+	settings.DataDir = local.DataDir
+	if _, err := a.SaveSettings(settings); err != nil {
+		slog.Warn("Ignoring invalid settings in user data archive", "error", err)
+	}
+}
+
+// ImportUserData restores a user data archive created by ExportUserData.
+// Mode "merge" adds the archived data and keeps local data where both
+// differ; "replace" deletes the local service plans, user songbooks and user
+// data first and also restores the preferences and settings. Local edits of
+// EZ and KK songs are applied to the songs right away.
+func (a *App) ImportUserData(path string, mode string) (dtoBackupSummary, error) {
+	var summary dtoBackupSummary
+	if mode != backupMerge && mode != backupReplace {
+		return summary, fmt.Errorf("unknown import mode %q, expected %s or %s", mode, backupMerge, backupReplace)
+	}
+	manifest, data, files, err := readBackupArchive(path)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Reading user data archive failed: %s", err))
+		return summary, err
+	}
+	summary.CreatedAt = manifest.CreatedAt
+	summary.SchemaVersion = manifest.SchemaVersion
+	if mode == backupReplace {
+		if _, err := a.snapshotDatabase(snapshotImport); err != nil {
+			return summary, err
+		}
+	}
+
+	if err := a.importBackupData(data, mode == backupReplace, &summary); err != nil {
+		slog.Error(fmt.Sprintf("User data import failed: %s", err))
+		return summary, err
+	}
+
+	if mode == backupReplace && len(files[backupSettingsName]) > 0 {
+		a.restoreBackupSettings(files[backupSettingsName])
+	}
+	if status := files[backupStatusName]; mode == backupReplace && len(status) > 0 {
+		var stored AppStatus
+		if err := yaml.Unmarshal(status, &stored); err != nil {
+			slog.Warn("Ignoring invalid status in user data archive", "error", err)
+		} else {
+			a.status.Sorting = normalizeSortingOption(string(stored.Sorting))
+			a.status.CollapseDuplicates = stored.CollapseDuplicates
+			a.saveStatus()
+		}
+	}
+	slog.Info(fmt.Sprintf("User data imported from %s: %+v", path, summary))
+	return summary, nil
+}
@@ -0,0 +1,261 @@
+package app
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// exportUserDataFile writes the user data archive of app into a temp file
+func exportUserDataFile(t *testing.T, app *App) string {
+	t.Helper()
+	dataURL, err := app.ExportUserData()
+	if err != nil {
+		t.Fatalf("ExportUserData: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "zaloha.zip")
+	if err := os.WriteFile(path, decodeDataURL(t, dataURL, "data:application/zip;base64,"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeTestZip creates a zip archive with the given file contents
+func writeTestZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserDataBackup_RoundTrip(t *testing.T) {
+	source := setupTestDB(t)
+	defer teardownTestDB(source)
+	source.appDir = t.TempDir()
+	source.status.Sorting = LastUsed
+	ezID := importOpenLyricsSample(t, source)
+
+	if err := source.AddSongTag(ezID, "žalmy"); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.SetSongFavorite(ezID, true); err != nil {
+		t.Fatal(err)
+	}
+	draft, _ := source.GetSongDraft(ezID)
+	draft.Title = "Hospodin je můj pastýř"
+	if _, err := source.SaveSongLocalEdits(draft); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.CreateSongbook("SBOR", "Zpěvník sboru"); err != nil {
+		t.Fatal(err)
+	}
+	ownID, err := source.SaveSong(dtoSongDraft{SongbookAcronym: "SBOR", Title: "Ranní píseň", EditedBy: "Marie",
+		Verses: []dtoVerseDetail{{Name: "v1", Lines: "Ráno vstávám"}, {Name: "c", Chords: "[G]Haleluja"}}, VerseOrder: "v1 c v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.SaveService(dtoService{ServiceDate: "2026-11-29", Title: "1. neděle adventní", Items: []dtoServiceItem{
+		{ItemType: SongItem, SongId: ezID, VerseOrder: "v1 c"},
+		{ItemType: ReadingItem, Title: "Čtení", ScriptureRef: "Iz 40,1-11"},
+		{ItemType: SongItem, SongId: ownID},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	path := exportUserDataFile(t, source)
+
+	target := setupTestDB(t)
+	defer teardownTestDB(target)
+	target.appDir = t.TempDir()
+	importOpenLyricsSample(t, target)
+	// data replaced by the archive
+	if err := target.CreateSongbook("STARY", "Starý zpěvník"); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := target.ImportUserData(path, backupReplace)
+	if err != nil {
+		t.Fatalf("ImportUserData: %v", err)
+	}
+	if summary.SchemaVersion != CurrentDBVersion || summary.Songbooks != 1 || summary.Songs != 1 || summary.Services != 1 || len(summary.Skipped) != 0 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if target.status.Sorting != LastUsed {
+		t.Errorf("replace should restore preferences, sorting = %s", target.status.Sorting)
+	}
+
+	books, _ := target.GetSongbooks()
+	var acronyms []string
+	for _, book := range books {
+		acronyms = append(acronyms, book.Acronym)
+	}
+	if strings.Contains(strings.Join(acronyms, " "), "STARY") || !strings.Contains(strings.Join(acronyms, " "), "SBOR") {
+		t.Errorf("songbooks after replace = %v", acronyms)
+	}
+	ez := findSong(t, target, "EZ")
+	if !ez.LocallyModified || ez.Title != "Hospodin je můj pastýř" || !ez.IsFavorite {
+		t.Errorf("local edits and favorites should be restored, got %+v", ez)
+	}
+	if tags, _ := target.GetSongTags(ez.Id); !reflect.DeepEqual(tags, []string{"žalmy"}) {
+		t.Errorf("tags = %v", tags)
+	}
+	own := findSong(t, target, "SBOR")
+	restored := mustLoadExportSong(t, target, own.Id)
+	if restored.Title != "Ranní píseň" || restored.VerseOrder != "v1 c v1" || len(restored.Verses) != 2 {
+		t.Errorf("unexpected restored song %+v", restored)
+	}
+	if revisions, _ := target.GetSongRevisions(own.Id); len(revisions) != 1 || revisions[0].EditedBy != "Marie" {
+		t.Errorf("revisions should be restored, got %+v", revisions)
+	}
+
+	services, _ := target.GetServices()
+	if len(services) != 1 {
+		t.Fatalf("expected one service, got %+v", services)
+	}
+	svc, _ := target.GetService(services[0].Id)
+	if len(svc.Items) != 3 || svc.Items[0].SongId != ez.Id || svc.Items[0].VerseOrder != "v1 c" || svc.Items[2].SongId != own.Id {
+		t.Errorf("service items should reference the songs of this database, got %+v", svc.Items)
+	}
+
+	// merging the same archive again adds nothing
+	summary, err = target.ImportUserData(path, backupMerge)
+	if err != nil {
+		t.Fatalf("ImportUserData merge: %v", err)
+	}
+	if summary.Songs != 0 || summary.Services != 0 || summary.Rows != 0 || len(summary.Skipped) != 2 {
+		t.Errorf("merge of known data should skip everything, got %+v", summary)
+	}
+}
+
+func TestImportUserData_Invalid(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	app.appDir = t.TempDir()
+
+	writeArchive := func(files map[string]string) string {
+		path := filepath.Join(t.TempDir(), "archiv.zip")
+		writeTestZip(t, path, files)
+		return path
+	}
+	notZip := filepath.Join(t.TempDir(), "text.zip")
+	os.WriteFile(notZip, []byte("není zip"), 0644)
+
+	tests := []struct {
+		name string
+		path string
+		mode string
+		want string
+	}{
+		{"not a zip", notZip, backupMerge, "not a user data archive"},
+		{"missing manifest", writeArchive(map[string]string{backupDataName: "{}"}), backupMerge, "not a user data archive"},
+		{"newer schema", writeArchive(map[string]string{
+			backupManifestName: `{"format":"lyyyra-user-data","version":1,"schemaVersion":999}`,
+			backupDataName:     "{}",
+		}), backupMerge, "newer version"},
+		{"unknown mode", notZip, "append", "unknown import mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := app.ImportUserData(tt.path, tt.mode)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResetData_KeepsUserData(t *testing.T) {
+	app := setupBundleApp(t)
+	if err := app.CreateSongbook("SBOR", "Zpěvník sboru"); err != nil {
+		t.Fatal(err)
+	}
+	ownID, err := app.SaveSong(dtoSongDraft{SongbookAcronym: "SBOR", Title: "Ranní píseň", EditedBy: "Marie",
+		Verses: []dtoVerseDetail{{Name: "v1", Lines: "Ráno vstávám"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.AddSongTag(ownID, "ranní"); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.SetSongFavorite(ownID, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.SaveService(dtoService{ServiceDate: "2026-11-29", Title: "Ranní modlitba",
+		Items: []dtoServiceItem{{ItemType: SongItem, SongId: ownID}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.ResetData(); err != nil {
+		t.Fatalf("ResetData: %v", err)
+	}
+	own := findSong(t, app, "SBOR")
+	if !own.IsFavorite {
+		t.Errorf("favorite should survive the reset, got %+v", own)
+	}
+	if tags, _ := app.GetSongTags(own.Id); !reflect.DeepEqual(tags, []string{"ranní"}) {
+		t.Errorf("tags = %v", tags)
+	}
+	if revisions, _ := app.GetSongRevisions(own.Id); len(revisions) != 1 {
+		t.Errorf("revisions should survive the reset, got %+v", revisions)
+	}
+	services, _ := app.GetServices()
+	if len(services) != 1 {
+		t.Fatalf("expected the service to survive, got %+v", services)
+	}
+	if svc, _ := app.GetService(services[0].Id); len(svc.Items) != 1 || svc.Items[0].SongId != own.Id {
+		t.Errorf("service items = %+v", svc.Items)
+	}
+}
+
+func TestUserDataBackup_Settings(t *testing.T) {
+	source := setupTestDB(t)
+	defer teardownTestDB(source)
+	source.appDir = t.TempDir()
+	source.settingsPath = filepath.Join(t.TempDir(), settingsFileName)
+	settings := defaultSettings()
+	settings.Mirrors = []string{"https://zrcadlo.example.org"}
+	settings.DataDir = filepath.Join(t.TempDir(), "zdroj")
+	if _, err := source.SaveSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	path := exportUserDataFile(t, source)
+
+	target := setupTestDB(t)
+	defer teardownTestDB(target)
+	target.appDir = t.TempDir()
+	target.settingsPath = filepath.Join(t.TempDir(), settingsFileName)
+	local := defaultSettings()
+	local.DataDir = filepath.Join(t.TempDir(), "cil")
+	if _, err := target.SaveSettings(local); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := target.ImportUserData(path, backupMerge); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := target.GetSettings(); len(saved.Saved.Mirrors) != 0 {
+		t.Errorf("merge should keep local settings, got %+v", saved.Saved)
+	}
+	if _, err := target.ImportUserData(path, backupReplace); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := target.GetSettings()
+	if !reflect.DeepEqual(saved.Saved.Mirrors, settings.Mirrors) || saved.Saved.DataDir != local.DataDir {
+		t.Errorf("replace should restore settings except the data directory, got %+v", saved.Saved)
+	}
+}
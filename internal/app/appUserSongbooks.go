@@ -0,0 +1,507 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// songbookAcronymPattern limits user songbook acronyms to letters and digits
+var songbookAcronymPattern = regexp.MustCompile(`^[\p{L}0-9]{1,10}$`)
+
+// verseNamePattern matches verse names like "v1", "c" or "b2"
+var verseNamePattern = regexp.MustCompile(`^[a-z]+[0-9]*$`)
+
+// editorSourceName is logged as the source file of songs written by the editor
+const editorSourceName = "editor"
+
+// songUserDataTables hold user data keyed by songbook acronym and entry_text.
+// The key outlives the song row: the data survives a refill of EZ and KK, is
+// carried across ResetData and follows a user song when it is renumbered. It
+// is removed with its song or songbook.
+var songUserDataTables = []string{
+	"song_arrangements", "song_favorites", "song_tags", "song_scripture_refs", "song_tunes", "song_overlays",
+}
+
+// songRevisionsTable is keyed and carried like songUserDataTables, but it is
+// kept when a song is deleted so the song can be restored from its history.
+// It is removed with its songbook.
+const songRevisionsTable = "song_revisions"
+
+// songUsageTable is keyed and carried like songUserDataTables, but it is never
+// removed with a song or songbook: the usage report must still list songs
+// that were sung and deleted later.
+const songUsageTable = "song_usage"
+
+// isBuiltinSongbook reports whether a songbook is filled from the downloads
+func isBuiltinSongbook(acronym string) bool {
+	return strings.EqualFold(acronym, Acronym_EZ) || strings.EqualFold(acronym, Acronym_KK)
+}
+
+// GetSongbooks lists all songbooks with their song counts
+func (a *App) GetSongbooks() ([]dtoSongbook, error) {
+	result := []dtoSongbook{}
+	err := a.withDB(func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT b.songbook_acronym, b.name,
+			       (SELECT COUNT(*) FROM songs s WHERE s.songbook_acronym = b.songbook_acronym)
+			FROM songbooks b
+			ORDER BY b.songbook_acronym`)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error querying songbooks: %s", err))
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var book dtoSongbook
+			if err := rows.Scan(&book.Acronym, &book.Name, &book.SongCount); err != nil {
+				return err
+			}
+			book.Editable = !isBuiltinSongbook(book.Acronym)
+			result = append(result, book)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
+
+// CreateSongbook creates an empty user songbook
+func (a *App) CreateSongbook(acronym string, name string) error {
+	acronym, name = strings.TrimSpace(acronym), strings.TrimSpace(name)
+	if !songbookAcronymPattern.MatchString(acronym) {
+		return fmt.Errorf("songbook acronym must be 1 to 10 letters or digits")
+	}
+	if isBuiltinSongbook(acronym) {
+		return fmt.Errorf("songbook acronym %s is reserved", acronym)
+	}
+	if name == "" {
+		return fmt.Errorf("songbook name is required")
+	}
+	return a.withDB(func(db *sql.DB) error {
+		var count int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM songbooks WHERE songbook_acronym = ?`, acronym).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("songbook %s already exists", acronym)
+		}
+		_, err := db.Exec(`INSERT INTO songbooks (songbook_acronym, name) VALUES (?, ?)`, acronym, name)
+		return err
+	})
+}
+
+// requireEditableSongbook checks that a songbook exists and may be edited
+func requireEditableSongbook(db *sql.DB, acronym string) error {
+	if isBuiltinSongbook(acronym) {
+		return fmt.Errorf("songbook %s is read-only", acronym)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM songbooks WHERE songbook_acronym = ?`, acronym).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("songbook %s not found", acronym)
+	}
+	return nil
+}
+
+// checkSongsNotInServices refuses to delete songs referenced by service plans
+func checkSongsNotInServices(db *sql.DB, where string, args ...interface{}) error {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(DISTINCT i.service_id) FROM service_items i
+		JOIN songs s ON i.item_type = 'song' AND COALESCE(s.songbook_acronym, '') = i.songbook_acronym
+		            AND `+songEntryKeyExpr+` = i.entry_text
+		WHERE `+where, args...).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("song is used in %d service plans", count)
+	}
+	return nil
+}
+
+// deleteSongChildren removes the verses, authors, titles and themes of a song
+func deleteSongChildren(tx *sql.Tx, songID int) error {
+	for _, table := range []string{"verses", "authors", "song_titles", "song_themes"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE song_id = ?`, songID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteSongbookRows removes a songbook with its songs, chapters and the
+// user data of its songs
+func deleteSongbookRows(tx *sql.Tx, acronym string) error {
+	for _, table := range []string{"verses", "authors", "song_titles", "song_themes"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE song_id IN (SELECT id FROM songs WHERE songbook_acronym = ?)`, acronym); err != nil {
+			return err
+		}
+	}
+	for _, table := range append(songUserDataTables, songRevisionsTable) {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE songbook_acronym = ?`, acronym); err != nil {
+			return err
+		}
+	}
+	for _, query := range []string{
+		`DELETE FROM song_chapter_ranges WHERE chapter_id IN (SELECT id FROM song_chapters WHERE songbook_acronym = ?)`,
+		`DELETE FROM song_chapters WHERE songbook_acronym = ?`,
+		`DELETE FROM song_neighbors WHERE songbook_acronym = ?1 OR neighbor_acronym = ?1`,
+		`DELETE FROM song_concordance WHERE songbook_acronym_a = ?1 OR songbook_acronym_b = ?1`,
+		`DELETE FROM songs WHERE songbook_acronym = ?`,
+		`DELETE FROM songbooks WHERE songbook_acronym = ?`,
+	} {
+		if _, err := tx.Exec(query, acronym); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteSongbook deletes a user songbook with all its songs and their user data
+func (a *App) DeleteSongbook(acronym string) error {
+	err := a.withDB(func(db *sql.DB) error {
+		if err := requireEditableSongbook(db, acronym); err != nil {
+			return err
+		}
+		if err := checkSongsNotInServices(db, `s.songbook_acronym = ?`, acronym); err != nil {
+			return err
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := deleteSongbookRows(tx, acronym); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Deleting songbook %s failed: %s", acronym, err))
+		return err
+	}
+	a.markSimilarSongsStale()
+	return nil
+}
+
+// normalizeSongDraft trims and validates a song from the editor. Verse names
+// are lower-cased, verses given only with inline chords get their plain lines
+// and the verse order must reference existing verses.
+func normalizeSongDraft(draft dtoSongDraft) (dtoSongDraft, error) {
+	draft.SongbookAcronym = strings.TrimSpace(draft.SongbookAcronym)
+	draft.Title = strings.TrimSpace(draft.Title)
+	draft.Copyright = strings.TrimSpace(draft.Copyright)
+	if draft.Title == "" {
+		return draft, fmt.Errorf("song title is required")
+	}
+	if draft.Entry < 0 {
+		return draft, fmt.Errorf("invalid song number %d", draft.Entry)
+	}
+
+	authors := []Author{}
+	for _, author := range draft.Authors {
+		author.Value = strings.TrimSpace(author.Value)
+		if author.Value == "" {
+			continue
+		}
+		switch author.Type {
+		case "":
+			author.Type = "words"
+		case "words", "music", "translation":
+		default:
+			return draft, fmt.Errorf("unknown author type %q", author.Type)
+		}
+		authors = append(authors, author)
+	}
+	draft.Authors = authors
+
+	if len(draft.Verses) == 0 {
+		return draft, fmt.Errorf("song has no verses")
+	}
+	draft.Verses = slices.Clone(draft.Verses)
+	seen := map[string]bool{}
+	verses := make([]songVerse, 0, len(draft.Verses))
+	for i, verse := range draft.Verses {
+		verse.Name = strings.ToLower(strings.TrimSpace(verse.Name))
+		if !verseNamePattern.MatchString(verse.Name) {
+			return draft, fmt.Errorf("invalid verse name %q", verse.Name)
+		}
+		if seen[verse.Name] {
+			return draft, fmt.Errorf("duplicate verse name %s", verse.Name)
+		}
+		seen[verse.Name] = true
+		verse.Chords = trimVerseLines(verse.Chords)
+		if strings.TrimSpace(verse.Lines) == "" && verse.Chords != "" {
+			verse.Lines = chordProChord.ReplaceAllString(verse.Chords, "")
+		}
+		verse.Lines = trimVerseLines(verse.Lines)
+		if verse.Lines == "" {
+			return draft, fmt.Errorf("verse %s is empty", verse.Name)
+		}
+		verse.Comment = strings.TrimSpace(verse.Comment)
+		draft.Verses[i] = verse
+		verses = append(verses, songVerse{Name: verse.Name, Lines: verse.Lines})
+	}
+
+	order, err := validateVerseOrder(draft.VerseOrder, verses)
+	if err != nil {
+		return draft, err
+	}
+	draft.VerseOrder = order
+	return draft, nil
+}
+
+// draftToSong converts an editor song to the structure written by insertFullSong
+func draftToSong(draft dtoSongDraft) *Song {
+	song := &Song{
+		Title:      draft.Title,
+		Titles:     []SongTitle{{Value: draft.Title}},
+		Songbook:   Songbook{Entry: strconv.Itoa(draft.Entry)},
+		VerseOrder: draft.VerseOrder,
+		Authors:    draft.Authors,
+		Copyright:  draft.Copyright,
+	}
+	for _, v := range draft.Verses {
+		song.Lyrics.Verses = append(song.Lyrics.Verses, Verse{
+			Name: v.Name, Lang: v.Lang, Translit: v.Translit, Lines: v.Lines, Chords: v.Chords, Comment: v.Comment,
+		})
+	}
+	return song
+}
+
+// GetSongDraft loads a song in the form edited by the song editor
+func (a *App) GetSongDraft(songId int) (dtoSongDraft, error) {
+	var draft dtoSongDraft
+	err := a.withDB(func(db *sql.DB) error {
+		var err error
+		draft, err = a.loadSongDraft(db, songId)
+		return err
+	})
+	return draft, err
+}
+
+// loadSongDraft reads a song with its imported verse order in editor form
+func (a *App) loadSongDraft(db *sql.DB, songID int) (dtoSongDraft, error) {
+	var draft dtoSongDraft
+	song, err := a.loadExportSong(db, songID)
+	if err != nil {
+		return draft, err
+	}
+	if err := db.QueryRow(`SELECT COALESCE(entry, 0), COALESCE(verse_order, '') FROM songs WHERE id = ?`, songID).
+		Scan(&draft.Entry, &draft.VerseOrder); err != nil {
+		return draft, err
+	}
+	draft.Id = songID
+	draft.SongbookAcronym = song.SongbookAcronym
+	draft.Title = song.Title
+	draft.Authors = song.Authors
+	if draft.Authors == nil {
+		draft.Authors = []Author{}
+	}
+	draft.Verses = song.Verses
+	draft.Copyright = song.Copyright
+	return draft, nil
+}
+
+// SaveSong creates (Id == 0) or replaces a song of a user songbook and
+// returns its ID. Changing the number of a song keeps its tags, favorites and
+// other user data.
+func (a *App) SaveSong(song dtoSongDraft) (int, error) {
+	return a.saveSong(song, "")
+}
+
+// saveSong saves a song like SaveSong. A new song is stored under newEntryText
+// when it is given, so restored songs keep numbers like "23a".
+func (a *App) saveSong(song dtoSongDraft, newEntryText string) (int, error) {
+	draft, err := normalizeSongDraft(song)
+	if err != nil {
+		return 0, err
+	}
+
+	songID := draft.Id
+	err = a.withDB(func(db *sql.DB) error {
+		var oldEntry int
+		var oldEntryText string
+		if draft.Id != 0 {
+			var acronym string
+			err := db.QueryRow(`SELECT COALESCE(songbook_acronym, ''), COALESCE(entry, 0), `+songEntryKeyExpr+` FROM songs s WHERE id = ?`, draft.Id).
+				Scan(&acronym, &oldEntry, &oldEntryText)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("song %d not found", draft.Id)
+			}
+			if err != nil {
+				return err
+			}
+			if draft.SongbookAcronym == "" {
+				draft.SongbookAcronym = acronym
+			}
+			if draft.SongbookAcronym != acronym {
+				return fmt.Errorf("moving songs between songbooks is not supported")
+			}
+			if draft.Entry == 0 {
+				draft.Entry = oldEntry
+			}
+		}
+		if err := requireEditableSongbook(db, draft.SongbookAcronym); err != nil {
+			return err
+		}
+
+		if draft.Id != 0 {
+			newEntryText = ""
+		}
+		if draft.Entry == 0 && newEntryText == "" {
+			if err := db.QueryRow(`SELECT COALESCE(MAX(entry), 0) + 1 FROM songs WHERE songbook_acronym = ?`, draft.SongbookAcronym).
+				Scan(&draft.Entry); err != nil {
+				return err
+			}
+		}
+		entryText := strconv.Itoa(draft.Entry)
+		if newEntryText != "" {
+			entryText = newEntryText
+		}
+		if draft.Id != 0 && draft.Entry == oldEntry {
+			// updateSong keeps entry texts like "23a"
+			entryText = oldEntryText
+		}
+		var taken int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM songs s WHERE s.songbook_acronym = ? AND `+songEntryKeyExpr+` = ? AND s.id <> ?`,
+			draft.SongbookAcronym, entryText, draft.Id).Scan(&taken); err != nil {
+			return err
+		}
+		if taken > 0 {
+			return fmt.Errorf("song number %s already exists in songbook %s", entryText, draft.SongbookAcronym)
+		}
+
+		if draft.Id != 0 {
+			if err := a.ensureSongBaseline(db, draft.Id); err != nil {
+				return err
+			}
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		newSong := draftToSong(draft)
+		if draft.Id == 0 {
+			newSong.Songbook.Entry = entryText
+			id, err := a.insertFullSong(tx, newSong, draft.SongbookAcronym, editorSourceName)
+			if err != nil {
+				return err
+			}
+			songID = int(id)
+			if newEntryText != "" {
+				// insertSong reads the number from the entry text
+				if _, err := tx.Exec(`UPDATE songs SET entry = ? WHERE id = ?`, draft.Entry, songID); err != nil {
+					return err
+				}
+			}
+		} else if err := a.updateSong(tx, draft, newSong, oldEntry, oldEntryText); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		a.markSimilarSongsStale()
+		return a.recordSongRevision(db, songID, draft.EditedBy)
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Saving song failed: %s", err))
+		return 0, err
+	}
+	return songID, nil
+}
+
+// DeleteSong deletes a song of a user songbook together with its user data.
+// Songs used in service plans cannot be deleted.
+func (a *App) DeleteSong(songId int) error {
+	err := a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+		if err := requireEditableSongbook(db, acronym); err != nil {
+			return err
+		}
+		if err := checkSongsNotInServices(db, `s.id = ?`, songId); err != nil {
+			return err
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := deleteSongChildren(tx, songId); err != nil {
+			return err
+		}
+		for _, table := range songUserDataTables {
+			if _, err := tx.Exec(`DELETE FROM `+table+` WHERE songbook_acronym = ? AND entry_text = ?`, acronym, entryText); err != nil {
+				return err
+			}
+		}
+		for _, query := range []string{
+			`DELETE FROM song_neighbors WHERE (songbook_acronym = ?1 AND entry_text = ?2) OR (neighbor_acronym = ?1 AND neighbor_entry_text = ?2)`,
+			`DELETE FROM song_concordance WHERE (songbook_acronym_a = ?1 AND entry_text_a = ?2) OR (songbook_acronym_b = ?1 AND entry_text_b = ?2)`,
+		} {
+			if _, err := tx.Exec(query, acronym, entryText); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(`DELETE FROM songs WHERE id = ?`, songId); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Deleting song %d failed: %s", songId, err))
+		return err
+	}
+	a.markSimilarSongsStale()
+	return nil
+}
+
+// updateSong replaces a song of a user songbook in place, keeping its ID.
+// User data follows the song when its number changes.
+func (a *App) updateSong(tx *sql.Tx, draft dtoSongDraft, song *Song, oldEntry int, oldEntryText string) error {
+	entryText := strconv.Itoa(draft.Entry)
+	if draft.Entry == oldEntry {
+		// keeps entry texts like "23a" of imported songs
+		entryText = oldEntryText
+	}
+	if _, err := tx.Exec(`UPDATE songs SET title = ?, title_d = ?, entry = ?, entry_text = ?, verse_order = ?, copyright = ? WHERE id = ?`,
+		draft.Title, removeDiacritics(draft.Title), draft.Entry, entryText, draft.VerseOrder, draft.Copyright, draft.Id); err != nil {
+		return err
+	}
+	if err := deleteSongChildren(tx, draft.Id); err != nil {
+		return err
+	}
+	id := int64(draft.Id)
+	if err := a.insertAuthors(tx, id, song.Authors, editorSourceName); err != nil {
+		return err
+	}
+	if err := a.insertVerses(tx, id, song.Lyrics.Verses, editorSourceName); err != nil {
+		return err
+	}
+	if err := a.insertSongMetadata(tx, id, song, editorSourceName); err != nil {
+		return err
+	}
+	if entryText != oldEntryText {
+		for _, table := range append(songUserDataTables, songRevisionsTable, songUsageTable, "service_items") {
+			if _, err := tx.Exec(`UPDATE OR REPLACE `+table+` SET entry_text = ? WHERE songbook_acronym = ? AND entry_text = ?`,
+				entryText, draft.SongbookAcronym, oldEntryText); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
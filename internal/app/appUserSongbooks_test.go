@@ -0,0 +1,329 @@
+package app
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSongDraft(t *testing.T) {
+	valid := func() dtoSongDraft {
+		return dtoSongDraft{
+			Title:   " Naše píseň ",
+			Authors: []Author{{Value: " Jan Novák "}, {Type: "music", Value: ""}},
+			Verses: []dtoVerseDetail{
+				{Name: "V1", Lines: " první sloka \n druhý řádek "},
+				{Name: "c", Chords: "[G]Refrén [D]zní"},
+			},
+			VerseOrder: "V1 C v1",
+		}
+	}
+
+	got, err := normalizeSongDraft(valid())
+	if err != nil {
+		t.Fatalf("normalizeSongDraft: %v", err)
+	}
+	if got.Title != "Naše píseň" || got.VerseOrder != "v1 c v1" {
+		t.Errorf("unexpected draft %+v", got)
+	}
+	if want := []Author{{Type: "words", Value: "Jan Novák"}}; !reflect.DeepEqual(got.Authors, want) {
+		t.Errorf("authors = %+v, want %+v", got.Authors, want)
+	}
+	if got.Verses[0].Name != "v1" || got.Verses[0].Lines != "první sloka\ndruhý řádek" {
+		t.Errorf("first verse = %+v", got.Verses[0])
+	}
+	if got.Verses[1].Lines != "Refrén zní" {
+		t.Errorf("lines should be derived from chords, got %q", got.Verses[1].Lines)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*dtoSongDraft)
+	}{
+		{"missing title", func(d *dtoSongDraft) { d.Title = " " }},
+		{"negative entry", func(d *dtoSongDraft) { d.Entry = -1 }},
+		{"unknown author type", func(d *dtoSongDraft) { d.Authors = []Author{{Type: "arranger", Value: "X"}} }},
+		{"no verses", func(d *dtoSongDraft) { d.Verses = nil }},
+		{"invalid verse name", func(d *dtoSongDraft) { d.Verses[0].Name = "sloka 1" }},
+		{"duplicate verse name", func(d *dtoSongDraft) { d.Verses[1].Name = "v1" }},
+		{"empty verse", func(d *dtoSongDraft) { d.Verses[0].Lines = "  " }},
+		{"unknown verse in order", func(d *dtoSongDraft) { d.VerseOrder = "v1 b" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			draft := valid()
+			tt.modify(&draft)
+			if _, err := normalizeSongDraft(draft); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestCreateSongbook(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+
+	if err := app.CreateSongbook("SBOR", "Zpěvník sboru"); err != nil {
+		t.Fatalf("CreateSongbook: %v", err)
+	}
+	for _, tt := range []struct{ acronym, name string }{
+		{"SBOR", "Znovu"},
+		{"ez", "Rezervováno"},
+		{"", "Bez zkratky"},
+		{"PRILIS_DLOUHA", "Dlouhá"},
+		{"NOVY", " "},
+	} {
+		if err := app.CreateSongbook(tt.acronym, tt.name); err == nil {
+			t.Errorf("CreateSongbook(%q, %q) should fail", tt.acronym, tt.name)
+		}
+	}
+
+	books, err := app.GetSongbooks()
+	if err != nil {
+		t.Fatalf("GetSongbooks: %v", err)
+	}
+	var found bool
+	for _, book := range books {
+		if book.Acronym == "SBOR" {
+			found = true
+			if book.Name != "Zpěvník sboru" || !book.Editable || book.SongCount != 0 {
+				t.Errorf("unexpected songbook %+v", book)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("SBOR missing in %+v", books)
+	}
+}
+
+func TestSaveSong_CreateUpdateDelete(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	if err := app.CreateSongbook("SBOR", "Zpěvník sboru"); err != nil {
+		t.Fatal(err)
+	}
+
+	draft := dtoSongDraft{
+		SongbookAcronym: "SBOR",
+		Title:           "Ranní píseň",
+		Authors:         []Author{{Type: "words", Value: "Marie Svobodová"}},
+		Verses:          []dtoVerseDetail{{Name: "v1", Lines: "Ráno vstávám"}, {Name: "c", Lines: "Haleluja"}},
+		VerseOrder:      "v1 c",
+	}
+	firstID, err := app.SaveSong(draft)
+	if err != nil {
+		t.Fatalf("SaveSong: %v", err)
+	}
+	draft.Title = "Večerní píseň"
+	secondID, err := app.SaveSong(draft)
+	if err != nil {
+		t.Fatalf("SaveSong second: %v", err)
+	}
+
+	songs, err := app.GetSongs("entry", "", "SBOR")
+	if err != nil || len(songs) != 2 {
+		t.Fatalf("GetSongs: %v %+v", err, songs)
+	}
+	if songs[0].Entry != 1 || songs[1].Entry != 2 {
+		t.Errorf("songs should be numbered 1 and 2, got %d and %d", songs[0].Entry, songs[1].Entry)
+	}
+
+	// renumbering keeps tags
+	if err := app.AddSongTag(firstID, "ráno"); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := app.GetSongDraft(firstID)
+	if err != nil {
+		t.Fatalf("GetSongDraft: %v", err)
+	}
+	if loaded.Entry != 1 || loaded.VerseOrder != "v1 c" || len(loaded.Verses) != 2 {
+		t.Errorf("unexpected draft %+v", loaded)
+	}
+	loaded.Entry = 10
+	loaded.Verses = append(loaded.Verses, dtoVerseDetail{Name: "v2", Lines: "Den začíná"})
+	loaded.VerseOrder = "v1 c v2 c"
+	loaded.Authors = append(loaded.Authors, Author{Type: "music", Value: "Petr Dvořák"})
+	if _, err := app.SaveSong(loaded); err != nil {
+		t.Fatalf("SaveSong update: %v", err)
+	}
+	song := mustLoadExportSong(t, app, firstID)
+	if song.EntryText != "10" || song.VerseOrder != "v1 c v2 c" || len(song.Verses) != 3 || len(song.Authors) != 2 {
+		t.Errorf("unexpected updated song %+v", song)
+	}
+	if tags, _ := app.GetSongTags(firstID); !reflect.DeepEqual(tags, []string{"ráno"}) {
+		t.Errorf("tags should follow the renumbered song, got %v", tags)
+	}
+
+	loaded.Entry = 2
+	if _, err := app.SaveSong(loaded); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("duplicate number should fail, got %v", err)
+	}
+
+	if _, err := app.SaveService(dtoService{ServiceDate: "2026-11-29", Items: []dtoServiceItem{{SongId: secondID}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.DeleteSong(secondID); err == nil {
+		t.Error("songs used in service plans must not be deleted")
+	}
+	if err := app.RecordSongProjection(firstID, "Ranní chvály"); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.DeleteSong(firstID); err != nil {
+		t.Fatalf("DeleteSong: %v", err)
+	}
+	if tags, _ := app.GetAllTags(); len(tags) != 0 {
+		t.Errorf("deleting a song should drop its tags, got %+v", tags)
+	}
+	// the usage of a deleted song stays in the copyright report
+	if report, _ := app.buildUsageReport("", ""); len(report) != 1 || report[0].EntryText != "10" || report[0].UseCount != 1 {
+		t.Errorf("usage of the deleted song should be kept, got %+v", report)
+	}
+	songs, _ = app.GetSongs("entry", "", "SBOR")
+	if len(songs) != 1 {
+		t.Errorf("expected one remaining song, got %d", len(songs))
+	}
+	if err := app.DeleteSongbook("SBOR"); err == nil {
+		t.Error("songbook with songs in service plans must not be deleted")
+	}
+}
+
+func TestSaveSong_ReadOnlySongbooks(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := importOpenLyricsSample(t, app)
+
+	draft, err := app.GetSongDraft(songID)
+	if err != nil {
+		t.Fatalf("GetSongDraft: %v", err)
+	}
+	if _, err := app.SaveSong(draft); err == nil {
+		t.Error("EZ songs must be read-only")
+	}
+	if err := app.DeleteSong(songID); err == nil {
+		t.Error("EZ songs must not be deleted")
+	}
+	if err := app.DeleteSongbook("EZ"); err == nil {
+		t.Error("EZ songbook must not be deleted")
+	}
+
+	draft.Id = 0
+	draft.SongbookAcronym = "NONE"
+	if _, err := app.SaveSong(draft); err == nil {
+		t.Error("saving into a missing songbook should fail")
+	}
+}
+
+func TestDeleteSongbook(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	if err := app.CreateSongbook("SBOR", "Zpěvník sboru"); err != nil {
+		t.Fatal(err)
+	}
+	id, err := app.SaveSong(dtoSongDraft{SongbookAcronym: "SBOR", Title: "Píseň", Verses: []dtoVerseDetail{{Name: "v1", Lines: "Text"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.SetSongFavorite(id, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.RecordSongProjection(id, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.DeleteSongbook("SBOR"); err != nil {
+		t.Fatalf("DeleteSongbook: %v", err)
+	}
+	books, _ := app.GetSongbooks()
+	for _, book := range books {
+		if book.Acronym == "SBOR" {
+			t.Error("songbook should be deleted")
+		}
+	}
+	if favorites, _ := app.GetFavoriteSongs(); len(favorites) != 0 {
+		t.Errorf("favorites of deleted songs should be removed, got %+v", favorites)
+	}
+	if report, _ := app.buildUsageReport("", ""); len(report) != 1 || report[0].SongbookAcronym != "SBOR" {
+		t.Errorf("usage of the deleted songbook should be kept, got %+v", report)
+	}
+}
+
+func TestSaveSong_EntryTextCollisions(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	if err := app.CreateSongbook("MOJE", "Můj zpěvník"); err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, entryText := range []string{"5a", "5b"} {
+		id, err := app.SaveSong(dtoSongDraft{SongbookAcronym: "MOJE", Title: "Píseň " + entryText,
+			Verses: []dtoVerseDetail{{Name: "v1", Lines: "Sloka"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// imported songs may share the number and differ by a letter
+		if err := app.withDB(func(db *sql.DB) error {
+			_, err := db.Exec(`UPDATE songs SET entry = 5, entry_text = ? WHERE id = ?`, entryText, id)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	draft, _ := app.GetSongDraft(ids[0])
+	draft.Title = "Upravená píseň"
+	if _, err := app.SaveSong(draft); err != nil {
+		t.Fatalf("songs 5a and 5b should not collide: %v", err)
+	}
+	if _, err := app.SaveSong(dtoSongDraft{SongbookAcronym: "MOJE", Entry: 5, Title: "Pátá",
+		Verses: []dtoVerseDetail{{Name: "v1", Lines: "Sloka"}}}); err != nil {
+		t.Errorf("number 5 is free next to 5a and 5b: %v", err)
+	}
+
+	if err := app.withDB(func(db *sql.DB) error {
+		_, err := db.Exec(`INSERT INTO song_neighbors (songbook_acronym, entry_text, neighbor_acronym, neighbor_entry_text, score)
+			VALUES ('MOJE', '5b', 'MOJE', '5a', 0.9), ('MOJE', '5a', 'MOJE', '5b', 0.9)`)
+		if err == nil {
+			_, err = db.Exec(`INSERT INTO song_concordance (songbook_acronym_a, entry_text_a, songbook_acronym_b, entry_text_b, confidence)
+				VALUES ('EZ', '1', 'MOJE', '5a', 0.9)`)
+		}
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.DeleteSong(ids[0]); err != nil {
+		t.Fatalf("DeleteSong: %v", err)
+	}
+	var neighbors, concordance int
+	app.withDB(func(db *sql.DB) error {
+		db.QueryRow(`SELECT COUNT(*) FROM song_neighbors`).Scan(&neighbors)
+		return db.QueryRow(`SELECT COUNT(*) FROM song_concordance`).Scan(&concordance)
+	})
+	if neighbors != 0 || concordance != 0 {
+		t.Errorf("rows of the deleted song should be removed, got %d neighbors and %d concordance rows", neighbors, concordance)
+	}
+}
+
+func TestSaveSong_FailedVerseInsertRollsBack(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	if err := app.CreateSongbook("SBOR", "Zpěvník sboru"); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.withDB(func(db *sql.DB) error {
+		_, err := db.Exec(`CREATE TRIGGER reject_verses BEFORE INSERT ON verses BEGIN SELECT RAISE(ABORT, 'verse rejected'); END`)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := app.SaveSong(dtoSongDraft{SongbookAcronym: "SBOR", Title: "Píseň", Verses: []dtoVerseDetail{{Name: "v1", Lines: "Text"}}})
+	if err == nil || !strings.Contains(err.Error(), "verse rejected") {
+		t.Errorf("failed verse insert should fail the save, got %v", err)
+	}
+	if songs, _ := app.GetSongs("entry", "", "SBOR"); len(songs) != 0 {
+		t.Errorf("no song without verses should be saved, got %+v", songs)
+	}
+}
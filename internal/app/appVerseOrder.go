@@ -0,0 +1,179 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// songVerse is a single named verse of a song as stored in the verses table
+type songVerse struct {
+	Name  string `json:"name"`
+	Lines string `json:"lines"`
+}
+
+// parseVerseOrder splits a verse order like "v1 c v2" into lower-cased verse names
+func parseVerseOrder(order string) []string {
+	fields := strings.Fields(order)
+	for i, f := range fields {
+		fields[i] = strings.ToLower(f)
+	}
+	return fields
+}
+
+// validateVerseOrder checks that every verse referenced by order exists in verses
+// and returns the normalized order string.
+func validateVerseOrder(order string, verses []songVerse) (string, error) {
+	known := make(map[string]bool, len(verses))
+	for _, v := range verses {
+		known[strings.ToLower(v.Name)] = true
+	}
+
+	names := parseVerseOrder(order)
+	var unknown []string
+	for _, name := range names {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("unknown verse names in verse order: %s", strings.Join(unknown, ", "))
+	}
+	return strings.Join(names, " "), nil
+}
+
+// arrangeVerses returns verses in the sequence given by order, repeating verses
+// as needed. An empty order keeps the stored sequence.
+func arrangeVerses(verses []songVerse, order string) []songVerse {
+	names := parseVerseOrder(order)
+	if len(names) == 0 {
+		return verses
+	}
+
+	byName := make(map[string]songVerse, len(verses))
+	for _, v := range verses {
+		byName[strings.ToLower(v.Name)] = v
+	}
+
+	arranged := make([]songVerse, 0, len(names))
+	for _, name := range names {
+		if v, ok := byName[name]; ok {
+			arranged = append(arranged, v)
+		}
+	}
+	return arranged
+}
+
+// loadSongVerses reads all verses of a song in their stored sequence
+func (a *App) loadSongVerses(db *sql.DB, songID int) ([]songVerse, error) {
+	rows, err := db.Query(`SELECT name, lines FROM verses WHERE song_id = ? ORDER BY id`, songID)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error querying verses: %s", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	verses := []songVerse{}
+	for rows.Next() {
+		var name, lines sql.NullString
+		if err := rows.Scan(&name, &lines); err != nil {
+			slog.Error(fmt.Sprintf("Error scanning verse row: %s", err))
+			return nil, err
+		}
+		verses = append(verses, songVerse{Name: name.String, Lines: lines.String})
+	}
+	return verses, rows.Err()
+}
+
+// loadSongVerseOrders returns the imported verse order of a song and the
+// user-defined arrangement, if any.
+func (a *App) loadSongVerseOrders(db *sql.DB, songID int) (string, string, error) {
+	var original sql.NullString
+	err := db.QueryRow(`SELECT verse_order FROM songs WHERE id = ?`, songID).Scan(&original)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error reading verse_order: %s", err))
+		return "", "", err
+	}
+
+	acronym, entryText, err := a.lookupSongKey(db, songID)
+	if err != nil {
+		return "", "", err
+	}
+
+	var custom string
+	err = db.QueryRow(`
+		SELECT verse_order FROM song_arrangements
+		WHERE songbook_acronym = ? AND entry_text = ?`, acronym, entryText).Scan(&custom)
+	if err != nil && err != sql.ErrNoRows {
+		slog.Error(fmt.Sprintf("Error reading song arrangement: %s", err))
+		return "", "", err
+	}
+	return original.String, custom, nil
+}
+
+// GetSongVerseOrder returns the imported and custom verse orders of a song
+// together with the verse names available for building an arrangement.
+func (a *App) GetSongVerseOrder(songId int) (dtoVerseOrder, error) {
+	var result dtoVerseOrder
+	err := a.withDB(func(db *sql.DB) error {
+		original, custom, err := a.loadSongVerseOrders(db, songId)
+		if err != nil {
+			return err
+		}
+		verses, err := a.loadSongVerses(db, songId)
+		if err != nil {
+			return err
+		}
+
+		result.Original = original
+		result.Custom = custom
+		result.Effective = original
+		if custom != "" {
+			result.Effective = custom
+		}
+		result.VerseNames = []string{}
+		for _, v := range verses {
+			result.VerseNames = append(result.VerseNames, v.Name)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// SaveSongVerseOrder stores a custom arrangement (e.g. "v1 c v3 c") for a song.
+// An empty verseOrder removes the arrangement and restores the imported order.
+func (a *App) SaveSongVerseOrder(songId int, verseOrder string) error {
+	return a.withDB(func(db *sql.DB) error {
+		acronym, entryText, err := a.lookupSongKey(db, songId)
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(verseOrder) == "" {
+			_, err = db.Exec(`DELETE FROM song_arrangements WHERE songbook_acronym = ? AND entry_text = ?`,
+				acronym, entryText)
+			return err
+		}
+
+		verses, err := a.loadSongVerses(db, songId)
+		if err != nil {
+			return err
+		}
+		normalized, err := validateVerseOrder(verseOrder, verses)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			INSERT INTO song_arrangements (songbook_acronym, entry_text, verse_order, updated_at)
+			VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(songbook_acronym, entry_text)
+			DO UPDATE SET verse_order = excluded.verse_order, updated_at = excluded.updated_at`,
+			acronym, entryText, normalized)
+		return err
+	})
+}
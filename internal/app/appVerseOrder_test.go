@@ -0,0 +1,214 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// insertArrangementSong inserts a song with verses v1, c, v2 and returns its id
+func insertArrangementSong(t *testing.T, app *App) int {
+	t.Helper()
+	var songID int64
+	err := app.withDB(func(db *sql.DB) error {
+		r, err := db.Exec(
+			`INSERT INTO songs (songbook_acronym, title, title_d, verse_order, entry, entry_text) VALUES (?, ?, ?, ?, ?, ?)`,
+			"EZ", "Arranged", "Arranged", "v1 c v2 c", 700, "700",
+		)
+		if err != nil {
+			return err
+		}
+		songID, err = r.LastInsertId()
+		if err != nil {
+			return err
+		}
+		_, err = db.Exec(`INSERT INTO verses (song_id, name, lines, lines_d) VALUES
+			(?, 'v1', 'first', 'first'),
+			(?, 'c', 'chorus', 'chorus'),
+			(?, 'v2', 'second', 'second')`, songID, songID, songID)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	return int(songID)
+}
+
+func TestValidateVerseOrder(t *testing.T) {
+	verses := []songVerse{{Name: "v1"}, {Name: "c"}, {Name: "V2"}}
+
+	tests := []struct {
+		name    string
+		order   string
+		want    string
+		wantErr bool
+	}{
+		{name: "valid order", order: "v1 c v2 c", want: "v1 c v2 c"},
+		{name: "normalizes case and spaces", order: "  V1   C ", want: "v1 c"},
+		{name: "skipping verses is allowed", order: "v2", want: "v2"},
+		{name: "empty order", order: "", want: ""},
+		{name: "unknown verse", order: "v1 v3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateVerseOrder(tt.order, verses)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateVerseOrder(%q) error = %v, wantErr %v", tt.order, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("validateVerseOrder(%q) = %q, want %q", tt.order, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArrangeVerses(t *testing.T) {
+	verses := []songVerse{{Name: "v1", Lines: "a"}, {Name: "c", Lines: "b"}, {Name: "v2", Lines: "c"}}
+
+	tests := []struct {
+		name  string
+		order string
+		want  []string
+	}{
+		{name: "empty order keeps stored sequence", order: "", want: []string{"v1", "c", "v2"}},
+		{name: "repeats chorus", order: "v1 c v2 c", want: []string{"v1", "c", "v2", "c"}},
+		{name: "skips missing names", order: "v1 x v2", want: []string{"v1", "v2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := arrangeVerses(verses, tt.order)
+			var names []string
+			for _, v := range got {
+				names = append(names, v.Name)
+			}
+			if strings.Join(names, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("arrangeVerses(%q) = %v, want %v", tt.order, names, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveSongVerseOrder_RoundTrip(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := insertArrangementSong(t, app)
+
+	if err := app.SaveSongVerseOrder(songID, "v1 c"); err != nil {
+		t.Fatalf("SaveSongVerseOrder: %v", err)
+	}
+
+	order, err := app.GetSongVerseOrder(songID)
+	if err != nil {
+		t.Fatalf("GetSongVerseOrder: %v", err)
+	}
+	if order.Original != "v1 c v2 c" || order.Custom != "v1 c" || order.Effective != "v1 c" {
+		t.Errorf("unexpected verse order: %+v", order)
+	}
+	if len(order.VerseNames) != 3 {
+		t.Errorf("expected 3 verse names, got %v", order.VerseNames)
+	}
+
+	verses, err := app.GetSongVerses(songID)
+	if err != nil {
+		t.Fatalf("GetSongVerses: %v", err)
+	}
+	if verses != "first===chorus" {
+		t.Errorf("GetSongVerses should follow arrangement, got %q", verses)
+	}
+
+	// Empty order reverts to the imported order
+	if err := app.SaveSongVerseOrder(songID, ""); err != nil {
+		t.Fatalf("SaveSongVerseOrder (clear): %v", err)
+	}
+	order, _ = app.GetSongVerseOrder(songID)
+	if order.Custom != "" || order.Effective != "v1 c v2 c" {
+		t.Errorf("expected arrangement to be cleared, got %+v", order)
+	}
+}
+
+func TestSaveSongVerseOrder_RejectsUnknownVerse(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := insertArrangementSong(t, app)
+
+	if err := app.SaveSongVerseOrder(songID, "v1 v3"); err == nil {
+		t.Error("expected error for unknown verse v3")
+	}
+	if err := app.SaveSongVerseOrder(99999, "v1"); err == nil {
+		t.Error("expected error for non-existent song")
+	}
+}
+
+func TestSaveSongVerseOrder_SurvivesRefill(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := insertArrangementSong(t, app)
+
+	if err := app.SaveSongVerseOrder(songID, "v2 c"); err != nil {
+		t.Fatalf("SaveSongVerseOrder: %v", err)
+	}
+
+	// Simulate a refill: drop the song and import it again under a new id
+	_ = app.withDB(func(db *sql.DB) error {
+		_, err := db.Exec(`DELETE FROM verses; DELETE FROM songs;`)
+		return err
+	})
+	newID := insertArrangementSong(t, app)
+
+	order, err := app.GetSongVerseOrder(newID)
+	if err != nil {
+		t.Fatalf("GetSongVerseOrder: %v", err)
+	}
+	if order.Custom != "v2 c" {
+		t.Errorf("expected arrangement to survive refill, got %+v", order)
+	}
+}
+
+func TestGetSongProjectionWithOrder(t *testing.T) {
+	app := setupTestDB(t)
+	defer teardownTestDB(app)
+	songID := insertArrangementSong(t, app)
+
+	if err := app.SaveSongVerseOrder(songID, "v1 v2"); err != nil {
+		t.Fatalf("SaveSongVerseOrder: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		override string
+		want     string
+		wantErr  bool
+	}{
+		{name: "stored arrangement", override: "", want: "v1 v2"},
+		{name: "service override", override: "c v2", want: "c v2"},
+		{name: "invalid override", override: "v9", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := app.GetSongProjectionWithOrder(songID, tt.override)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var payload struct {
+				VerseOrder         string `json:"verse_order"`
+				OriginalVerseOrder string `json:"original_verse_order"`
+			}
+			if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+				t.Fatalf("bad JSON: %v", err)
+			}
+			if payload.VerseOrder != tt.want {
+				t.Errorf("verse_order = %q, want %q", payload.VerseOrder, tt.want)
+			}
+			if payload.OriginalVerseOrder != "v1 c v2 c" {
+				t.Errorf("original_verse_order = %q", payload.OriginalVerseOrder)
+			}
+		})
+	}
+}
@@ -1,5 +1,6 @@
 package app
 
+// Source URLs are the defaults of settings.yaml, see defaultSettings
 const (
 	// EZ (Evangelický zpěvník)
 	XMLUrl_EZ            = "https://www.evangelickyzpevnik.cz.www.e-cirkev.cz/res/archive/001/000243.zip?download"
@@ -17,11 +18,13 @@ const (
 )
 
 type SupplementalPDF struct {
-	URL      string
-	FileName string
+	URL      string `yaml:"url"`
+	FileName string `yaml:"fileName"`
 }
 
-var SupplementalPDFs = []SupplementalPDF{
+// defaultSupplementalPDFs lists the PDFs downloaded with the chapters unless
+// settings name others
+var defaultSupplementalPDFs = []SupplementalPDF{
 	{
 		URL:      "https://www.evangelickyzpevnik.cz.www.e-cirkev.cz/res/archive/001/000234.pdf",
 		FileName: "kytara.pdf",
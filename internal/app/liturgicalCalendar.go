@@ -0,0 +1,172 @@
+package app
+
+import (
+	"fmt"
+	"time"
+)
+
+// Liturgical seasons of the church year
+const (
+	SeasonAdvent    = "advent"
+	SeasonChristmas = "christmas"
+	SeasonEpiphany  = "epiphany"
+	SeasonLent      = "lent"
+	SeasonEaster    = "easter"
+	SeasonPentecost = "pentecost"
+)
+
+// Feasts that override the season when suggesting songs
+const (
+	FeastChristmas      = "christmas_day"
+	FeastNewYear        = "new_year"
+	FeastEpiphany       = "epiphany_day"
+	FeastAshWednesday   = "ash_wednesday"
+	FeastPalmSunday     = "palm_sunday"
+	FeastMaundyThursday = "maundy_thursday"
+	FeastGoodFriday     = "good_friday"
+	FeastEaster         = "easter_day"
+	FeastAscension      = "ascension"
+	FeastPentecost      = "pentecost_day"
+	FeastTrinity        = "trinity"
+)
+
+// liturgicalNames are the Czech display names of seasons and feasts. They
+// double as the default tag linking songs to an occasion.
+var liturgicalNames = map[string]string{
+	SeasonAdvent:        "Advent",
+	SeasonChristmas:     "Vánoce",
+	SeasonEpiphany:      "Doba po Zjevení Páně",
+	SeasonLent:          "Postní doba",
+	SeasonEaster:        "Velikonoční doba",
+	SeasonPentecost:     "Doba svatodušní",
+	FeastChristmas:      "Narození Páně",
+	FeastNewYear:        "Nový rok",
+	FeastEpiphany:       "Zjevení Páně",
+	FeastAshWednesday:   "Popeleční středa",
+	FeastPalmSunday:     "Květná neděle",
+	FeastMaundyThursday: "Zelený čtvrtek",
+	FeastGoodFriday:     "Velký pátek",
+	FeastEaster:         "Velikonoce",
+	FeastAscension:      "Nanebevstoupení Páně",
+	FeastPentecost:      "Letnice",
+	FeastTrinity:        "Svátek Trojice",
+}
+
+// liturgicalDay describes where a date falls in the church year
+type liturgicalDay struct {
+	Date   time.Time
+	Season string
+	Feast  string // empty on ordinary days
+	Name   string // e.g. "2. neděle adventní"
+}
+
+// easterSunday computes the Gregorian Easter date (anonymous Gregorian algorithm)
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// firstAdventSunday returns the fourth Sunday before Christmas Day
+func firstAdventSunday(year int) time.Time {
+	christmas := time.Date(year, time.December, 25, 0, 0, 0, 0, time.UTC)
+	offset := int(christmas.Weekday())
+	if offset == 0 {
+		offset = 7
+	}
+	return christmas.AddDate(0, 0, -offset-21)
+}
+
+// daysBetween returns the number of whole days from a to b
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// newLiturgicalDay classifies a date into its season and feast
+func newLiturgicalDay(date time.Time) liturgicalDay {
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	year := date.Year()
+	easter := easterSunday(year)
+	fromEaster := daysBetween(easter, date)
+	advent := firstAdventSunday(year)
+
+	day := liturgicalDay{Date: date}
+
+	switch {
+	case date.Month() == time.December && date.Day() >= 24:
+		day.Season = SeasonChristmas
+	case !date.Before(advent):
+		day.Season = SeasonAdvent
+	case date.Month() == time.January && date.Day() < 6:
+		day.Season = SeasonChristmas
+	case fromEaster < -46:
+		day.Season = SeasonEpiphany
+	case fromEaster < 0:
+		day.Season = SeasonLent
+	case fromEaster < 49:
+		day.Season = SeasonEaster
+	default:
+		day.Season = SeasonPentecost
+	}
+
+	switch {
+	case date.Month() == time.December && date.Day() >= 25 && date.Day() <= 26:
+		day.Feast = FeastChristmas
+	case date.Month() == time.January && date.Day() == 1:
+		day.Feast = FeastNewYear
+	case date.Month() == time.January && date.Day() == 6:
+		day.Feast = FeastEpiphany
+	case fromEaster == -46:
+		day.Feast = FeastAshWednesday
+	case fromEaster == -7:
+		day.Feast = FeastPalmSunday
+	case fromEaster == -3:
+		day.Feast = FeastMaundyThursday
+	case fromEaster == -2:
+		day.Feast = FeastGoodFriday
+	case fromEaster == 0 || fromEaster == 1:
+		day.Feast = FeastEaster
+	case fromEaster == 39:
+		day.Feast = FeastAscension
+	case fromEaster == 49 || fromEaster == 50:
+		day.Feast = FeastPentecost
+	case fromEaster == 56:
+		day.Feast = FeastTrinity
+	}
+
+	switch {
+	case day.Feast != "":
+		day.Name = liturgicalNames[day.Feast]
+	case day.Season == SeasonAdvent && date.Weekday() == time.Sunday:
+		day.Name = fmt.Sprintf("%d. neděle adventní", daysBetween(advent, date)/7+1)
+	case day.Season == SeasonLent && date.Weekday() == time.Sunday:
+		day.Name = fmt.Sprintf("%d. neděle postní", (fromEaster+46)/7+1)
+	case day.Season == SeasonEaster && date.Weekday() == time.Sunday:
+		day.Name = fmt.Sprintf("%d. neděle velikonoční", fromEaster/7+1)
+	case day.Season == SeasonPentecost && date.Weekday() == time.Sunday:
+		day.Name = fmt.Sprintf("%d. neděle po Trojici", (fromEaster-56)/7)
+	default:
+		day.Name = liturgicalNames[day.Season]
+	}
+	return day
+}
+
+// occasions returns the feast (if any) followed by the season
+func (d liturgicalDay) occasions() []string {
+	if d.Feast != "" {
+		return []string{d.Feast, d.Season}
+	}
+	return []string{d.Season}
+}
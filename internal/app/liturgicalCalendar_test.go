@@ -0,0 +1,79 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEasterSunday(t *testing.T) {
+	tests := []struct {
+		year int
+		want string
+	}{
+		{2000, "2000-04-23"},
+		{2019, "2019-04-21"},
+		{2024, "2024-03-31"},
+		{2025, "2025-04-20"},
+		{2026, "2026-04-05"},
+		{2038, "2038-04-25"},
+	}
+	for _, tt := range tests {
+		if got := easterSunday(tt.year).Format(serviceDateLayout); got != tt.want {
+			t.Errorf("easterSunday(%d) = %s, want %s", tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestFirstAdventSunday(t *testing.T) {
+	tests := []struct {
+		year int
+		want string
+	}{
+		{2023, "2023-12-03"},
+		{2024, "2024-12-01"},
+		{2025, "2025-11-30"},
+		{2026, "2026-11-29"},
+	}
+	for _, tt := range tests {
+		if got := firstAdventSunday(tt.year).Format(serviceDateLayout); got != tt.want {
+			t.Errorf("firstAdventSunday(%d) = %s, want %s", tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestNewLiturgicalDay(t *testing.T) {
+	tests := []struct {
+		date       string
+		wantSeason string
+		wantFeast  string
+		wantName   string
+	}{
+		{"2026-01-01", SeasonChristmas, FeastNewYear, "Nový rok"},
+		{"2026-01-06", SeasonEpiphany, FeastEpiphany, "Zjevení Páně"},
+		{"2026-02-01", SeasonEpiphany, "", "Doba po Zjevení Páně"},
+		{"2026-02-18", SeasonLent, FeastAshWednesday, "Popeleční středa"},
+		{"2026-02-22", SeasonLent, "", "1. neděle postní"},
+		{"2026-03-29", SeasonLent, FeastPalmSunday, "Květná neděle"},
+		{"2026-04-03", SeasonLent, FeastGoodFriday, "Velký pátek"},
+		{"2026-04-05", SeasonEaster, FeastEaster, "Velikonoce"},
+		{"2026-04-12", SeasonEaster, "", "2. neděle velikonoční"},
+		{"2026-05-14", SeasonEaster, FeastAscension, "Nanebevstoupení Páně"},
+		{"2026-05-24", SeasonPentecost, FeastPentecost, "Letnice"},
+		{"2026-05-31", SeasonPentecost, FeastTrinity, "Svátek Trojice"},
+		{"2026-06-07", SeasonPentecost, "", "1. neděle po Trojici"},
+		{"2026-11-29", SeasonAdvent, "", "1. neděle adventní"},
+		{"2026-12-20", SeasonAdvent, "", "4. neděle adventní"},
+		{"2026-12-24", SeasonChristmas, "", "Vánoce"},
+		{"2026-12-25", SeasonChristmas, FeastChristmas, "Narození Páně"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			date, _ := time.Parse(serviceDateLayout, tt.date)
+			day := newLiturgicalDay(date)
+			if day.Season != tt.wantSeason || day.Feast != tt.wantFeast || day.Name != tt.wantName {
+				t.Errorf("got season=%q feast=%q name=%q, want %q %q %q",
+					day.Season, day.Feast, day.Name, tt.wantSeason, tt.wantFeast, tt.wantName)
+			}
+		})
+	}
+}
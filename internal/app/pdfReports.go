@@ -0,0 +1,61 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oliverpool/unipdf/v3/creator"
+	"github.com/oliverpool/unipdf/v3/model"
+)
+
+// pdfTextLine is a paragraph of a generated text document
+type pdfTextLine struct {
+	Text        string
+	Bold        bool
+	Size        float64
+	Indent      float64
+	SpaceBefore float64
+}
+
+// renderTextPdf lays out the given paragraphs on A4 pages.
+// Standard Helvetica is used with a custom encoding built from the document
+// alphabet so Czech diacritics render without embedding a font file.
+func renderTextPdf(lines []pdfTextLine) (*creator.Creator, error) {
+	var all strings.Builder
+	for _, line := range lines {
+		all.WriteString(line.Text)
+	}
+	alphabet := model.GetAlphabet(all.String())
+
+	regular, _, err := model.NewStandard14FontWithEncoding(model.HelveticaName, alphabet)
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare font: %w", err)
+	}
+	bold, _, err := model.NewStandard14FontWithEncoding(model.HelveticaBoldName, alphabet)
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare bold font: %w", err)
+	}
+
+	c := creator.New()
+	c.SetPageSize(creator.PageSizeA4)
+	c.SetPageMargins(50, 50, 50, 50)
+
+	for _, line := range lines {
+		p := c.NewParagraph(line.Text)
+		p.SetFont(regular)
+		if line.Bold {
+			p.SetFont(bold)
+		}
+		size := line.Size
+		if size == 0 {
+			size = 11
+		}
+		p.SetFontSize(size)
+		p.SetLineHeight(1.2)
+		p.SetMargins(line.Indent, 0, line.SpaceBefore, 4)
+		if err := c.Draw(p); err != nil {
+			return nil, fmt.Errorf("unable to draw paragraph: %w", err)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/oliverpool/unipdf/v3/extractor"
+	"github.com/oliverpool/unipdf/v3/model"
+)
+
+func TestRenderTextPdf_CzechText(t *testing.T) {
+	c, err := renderTextPdf([]pdfTextLine{
+		{Text: "Pořad bohoslužby", Bold: true, Size: 18},
+		{Text: "Příliš žluťoučký kůň úpěl ďábelské ódy", Indent: 15},
+	})
+	if err != nil {
+		t.Fatalf("renderTextPdf: %v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := c.Write(buf); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	reader, err := model.NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewPdfReader: %v", err)
+	}
+	page, err := reader.GetPage(1)
+	if err != nil {
+		t.Fatalf("GetPage: %v", err)
+	}
+	ex, err := extractor.New(page)
+	if err != nil {
+		t.Fatalf("extractor: %v", err)
+	}
+	text, err := ex.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if !strings.Contains(text, "Pořad bohoslužby") || !strings.Contains(text, "žluťoučký") {
+		t.Errorf("expected Czech text in PDF, got %q", text)
+	}
+}
@@ -0,0 +1,255 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Slides are 16:9; OOXML sizes are in EMU, ODF sizes in centimeters
+const (
+	pptxSlideWidth  = 12192000
+	pptxSlideHeight = 6858000
+	pptxMargin      = 457200
+	odpPageWidth    = 28.0
+	odpPageHeight   = 15.75
+	odpMargin       = 1.0
+)
+
+// zipEntry is a file written to an OOXML or ODF package
+type zipEntry struct {
+	Name    string
+	Content string
+}
+
+// writeZipEntries writes the entries to a zip archive. ODF requires its
+// "mimetype" entry to be stored first and uncompressed, which the caller
+// ensures by passing it as the first entry.
+func writeZipEntries(entries []zipEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.Name, Method: zip.Deflate}
+		if entry.Name == "mimetype" {
+			header.Method = zip.Store
+		}
+		w, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(entry.Content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// escapeXML escapes text for use in XML content and attribute values
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// paragraphSize returns the font size of a paragraph in points
+func paragraphSize(p presentationParagraph, options dtoPresentationOptions) float64 {
+	return math.Round(float64(options.FontSize)*p.Scale*10) / 10
+}
+
+// ============ PPTX (Office Open XML) ============
+
+const pptxNamespaces = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+	`xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"`
+
+const pptxRelationships = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+const pptxEmptyTree = `<p:nvGrpSpPr><p:cNvPr id="1" name=""/><p:cNvGrpSpPr/><p:nvPr/></p:nvGrpSpPr><p:grpSpPr/>`
+
+const pptxTheme = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<a:theme xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" name="Lyyyra">
+<a:themeElements>
+<a:clrScheme name="Lyyyra">
+<a:dk1><a:srgbClr val="000000"/></a:dk1><a:lt1><a:srgbClr val="FFFFFF"/></a:lt1>
+<a:dk2><a:srgbClr val="1F1F1F"/></a:dk2><a:lt2><a:srgbClr val="EEEEEE"/></a:lt2>
+<a:accent1><a:srgbClr val="4472C4"/></a:accent1><a:accent2><a:srgbClr val="ED7D31"/></a:accent2>
+<a:accent3><a:srgbClr val="A5A5A5"/></a:accent3><a:accent4><a:srgbClr val="FFC000"/></a:accent4>
+<a:accent5><a:srgbClr val="5B9BD5"/></a:accent5><a:accent6><a:srgbClr val="70AD47"/></a:accent6>
+<a:hlink><a:srgbClr val="0563C1"/></a:hlink><a:folHlink><a:srgbClr val="954F72"/></a:folHlink>
+</a:clrScheme>
+<a:fontScheme name="Lyyyra">
+<a:majorFont><a:latin typeface="Arial"/><a:ea typeface=""/><a:cs typeface=""/></a:majorFont>
+<a:minorFont><a:latin typeface="Arial"/><a:ea typeface=""/><a:cs typeface=""/></a:minorFont>
+</a:fontScheme>
+<a:fmtScheme name="Lyyyra">
+<a:fillStyleLst><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:fillStyleLst>
+<a:lnStyleLst><a:ln w="6350"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln><a:ln w="12700"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln><a:ln w="19050"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln></a:lnStyleLst>
+<a:effectStyleLst><a:effectStyle><a:effectLst/></a:effectStyle><a:effectStyle><a:effectLst/></a:effectStyle><a:effectStyle><a:effectLst/></a:effectStyle></a:effectStyleLst>
+<a:bgFillStyleLst><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:bgFillStyleLst>
+</a:fmtScheme>
+</a:themeElements>
+</a:theme>`
+
+// pptxSlide renders one slide with a centered text box on a solid background
+func pptxSlide(slide presentationSlide, options dtoPresentationOptions) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<p:sld ` + pptxNamespaces + `><p:cSld>`)
+	fmt.Fprintf(&b, `<p:bg><p:bgPr><a:solidFill><a:srgbClr val="%s"/></a:solidFill><a:effectLst/></p:bgPr></p:bg>`, options.BackgroundColor)
+	b.WriteString(`<p:spTree>` + pptxEmptyTree)
+	b.WriteString(`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Text"/><p:cNvSpPr txBox="1"/><p:nvPr/></p:nvSpPr>`)
+	fmt.Fprintf(&b, `<p:spPr><a:xfrm><a:off x="%d" y="%d"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></p:spPr>`,
+		pptxMargin, pptxMargin, pptxSlideWidth-2*pptxMargin, pptxSlideHeight-2*pptxMargin)
+	b.WriteString(`<p:txBody><a:bodyPr wrap="square" anchor="ctr"><a:normAutofit/></a:bodyPr><a:lstStyle/>`)
+	for _, p := range slide.Paragraphs {
+		bold := 0
+		if p.Bold {
+			bold = 1
+		}
+		fmt.Fprintf(&b, `<a:p><a:pPr algn="ctr"/><a:r><a:rPr lang="cs-CZ" sz="%d" b="%d"><a:solidFill><a:srgbClr val="%s"/></a:solidFill><a:latin typeface="%s"/><a:cs typeface="%s"/></a:rPr><a:t>%s</a:t></a:r></a:p>`,
+			int(paragraphSize(p, options)*100), bold, options.TextColor,
+			escapeXML(options.FontFamily), escapeXML(options.FontFamily), escapeXML(p.Text))
+	}
+	b.WriteString(`</p:txBody></p:sp></p:spTree></p:cSld><p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:sld>`)
+	return b.String()
+}
+
+// writePptx builds a PowerPoint package with one slide master, one blank
+// layout and the given slides
+func writePptx(slides []presentationSlide, options dtoPresentationOptions) ([]byte, error) {
+	const header = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+	const pml = "application/vnd.openxmlformats-officedocument.presentationml."
+
+	var types, slideIDs, presentationRels strings.Builder
+	types.WriteString(header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	types.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	types.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	types.WriteString(`<Override PartName="/ppt/presentation.xml" ContentType="` + pml + `presentation.main+xml"/>`)
+	types.WriteString(`<Override PartName="/ppt/slideMasters/slideMaster1.xml" ContentType="` + pml + `slideMaster+xml"/>`)
+	types.WriteString(`<Override PartName="/ppt/slideLayouts/slideLayout1.xml" ContentType="` + pml + `slideLayout+xml"/>`)
+	types.WriteString(`<Override PartName="/ppt/theme/theme1.xml" ContentType="application/vnd.openxmlformats-officedocument.theme+xml"/>`)
+
+	presentationRels.WriteString(header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	presentationRels.WriteString(`<Relationship Id="rId1" Type="` + pptxRelationships + `/slideMaster" Target="slideMasters/slideMaster1.xml"/>`)
+	presentationRels.WriteString(`<Relationship Id="rId2" Type="` + pptxRelationships + `/theme" Target="theme/theme1.xml"/>`)
+
+	slideRels := header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="` + pptxRelationships + `/slideLayout" Target="../slideLayouts/slideLayout1.xml"/></Relationships>`
+
+	var slideEntries []zipEntry
+	for i, slide := range slides {
+		n := i + 1
+		fmt.Fprintf(&types, `<Override PartName="/ppt/slides/slide%d.xml" ContentType="%sslide+xml"/>`, n, pml)
+		fmt.Fprintf(&presentationRels, `<Relationship Id="rId%d" Type="%s/slide" Target="slides/slide%d.xml"/>`, n+2, pptxRelationships, n)
+		fmt.Fprintf(&slideIDs, `<p:sldId id="%d" r:id="rId%d"/>`, 255+n, n+2)
+		slideEntries = append(slideEntries,
+			zipEntry{fmt.Sprintf("ppt/slides/slide%d.xml", n), pptxSlide(slide, options)},
+			zipEntry{fmt.Sprintf("ppt/slides/_rels/slide%d.xml.rels", n), slideRels})
+	}
+	types.WriteString(`</Types>`)
+	presentationRels.WriteString(`</Relationships>`)
+
+	entries := []zipEntry{
+		{"[Content_Types].xml", types.String()},
+		{"_rels/.rels", header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="` + pptxRelationships + `/officeDocument" Target="ppt/presentation.xml"/></Relationships>`},
+		{"ppt/presentation.xml", header + `<p:presentation ` + pptxNamespaces + ` saveSubsetFonts="1">` +
+			`<p:sldMasterIdLst><p:sldMasterId id="2147483648" r:id="rId1"/></p:sldMasterIdLst>` +
+			`<p:sldIdLst>` + slideIDs.String() + `</p:sldIdLst>` +
+			fmt.Sprintf(`<p:sldSz cx="%d" cy="%d"/><p:notesSz cx="%d" cy="%d"/>`, pptxSlideWidth, pptxSlideHeight, pptxSlideHeight, pptxSlideWidth) +
+			`</p:presentation>`},
+		{"ppt/_rels/presentation.xml.rels", presentationRels.String()},
+		{"ppt/slideMasters/slideMaster1.xml", header + `<p:sldMaster ` + pptxNamespaces + `>` +
+			`<p:cSld><p:spTree>` + pptxEmptyTree + `</p:spTree></p:cSld>` +
+			`<p:clrMap bg1="lt1" tx1="dk1" bg2="lt2" tx2="dk2" accent1="accent1" accent2="accent2" accent3="accent3" ` +
+			`accent4="accent4" accent5="accent5" accent6="accent6" hlink="hlink" folHlink="folHlink"/>` +
+			`<p:sldLayoutIdLst><p:sldLayoutId id="2147483649" r:id="rId1"/></p:sldLayoutIdLst></p:sldMaster>`},
+		{"ppt/slideMasters/_rels/slideMaster1.xml.rels", header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="` + pptxRelationships + `/slideLayout" Target="../slideLayouts/slideLayout1.xml"/>` +
+			`<Relationship Id="rId2" Type="` + pptxRelationships + `/theme" Target="../theme/theme1.xml"/></Relationships>`},
+		{"ppt/slideLayouts/slideLayout1.xml", header + `<p:sldLayout ` + pptxNamespaces + ` type="blank" preserve="1">` +
+			`<p:cSld name="Blank"><p:spTree>` + pptxEmptyTree + `</p:spTree></p:cSld>` +
+			`<p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:sldLayout>`},
+		{"ppt/slideLayouts/_rels/slideLayout1.xml.rels", header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="` + pptxRelationships + `/slideMaster" Target="../slideMasters/slideMaster1.xml"/></Relationships>`},
+		{"ppt/theme/theme1.xml", pptxTheme},
+	}
+	return writeZipEntries(append(entries, slideEntries...))
+}
+
+// ============ ODP (OpenDocument Presentation) ============
+
+const odpNamespaces = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+	`xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0" ` +
+	`xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" ` +
+	`xmlns:svg="urn:oasis:names:tc:opendocument:xmlns:svg-compatible:1.0" ` +
+	`xmlns:presentation="urn:oasis:names:tc:opendocument:xmlns:presentation:1.0" office:version="1.2"`
+
+// writeOdp builds an OpenDocument presentation with one page per slide
+func writeOdp(slides []presentationSlide, options dtoPresentationOptions) ([]byte, error) {
+	const header = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+	font := escapeXML(options.FontFamily)
+
+	// one paragraph style per distinct size and weight
+	styles := map[presentationParagraph]string{}
+	var automatic, pages strings.Builder
+	fmt.Fprintf(&automatic, `<style:style style:name="dp1" style:family="drawing-page">`+
+		`<style:drawing-page-properties draw:fill="solid" draw:fill-color="#%s" presentation:background-visible="true"/></style:style>`,
+		options.BackgroundColor)
+	automatic.WriteString(`<style:style style:name="gr1" style:family="graphic"><style:graphic-properties draw:stroke="none" draw:fill="none" ` +
+		`draw:textarea-horizontal-align="center" draw:textarea-vertical-align="middle" draw:auto-grow-height="false"/></style:style>`)
+
+	for i, slide := range slides {
+		fmt.Fprintf(&pages, `<draw:page draw:name="page%d" draw:style-name="dp1" draw:master-page-name="Default">`, i+1)
+		fmt.Fprintf(&pages, `<draw:frame draw:style-name="gr1" svg:x="%gcm" svg:y="%gcm" svg:width="%gcm" svg:height="%gcm"><draw:text-box>`,
+			odpMargin, odpMargin, odpPageWidth-2*odpMargin, odpPageHeight-2*odpMargin)
+		for _, p := range slide.Paragraphs {
+			key := presentationParagraph{Scale: p.Scale, Bold: p.Bold}
+			name, ok := styles[key]
+			if !ok {
+				name = fmt.Sprintf("P%d", len(styles)+1)
+				styles[key] = name
+				weight := "normal"
+				if p.Bold {
+					weight = "bold"
+				}
+				fmt.Fprintf(&automatic, `<style:style style:name="%s" style:family="paragraph">`+
+					`<style:paragraph-properties fo:text-align="center"/>`+
+					`<style:text-properties fo:font-family="%s" fo:font-size="%gpt" fo:font-weight="%s" fo:color="#%s"/></style:style>`,
+					name, font, paragraphSize(p, options), weight, options.TextColor)
+			}
+			fmt.Fprintf(&pages, `<text:p text:style-name="%s">%s</text:p>`, name, escapeXML(p.Text))
+		}
+		pages.WriteString(`</draw:text-box></draw:frame></draw:page>`)
+	}
+
+	mimeType := presentationMimeTypes[presentationFormatOdp]
+	entries := []zipEntry{
+		{"mimetype", mimeType},
+		{"META-INF/manifest.xml", header + `<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">` +
+			`<manifest:file-entry manifest:full-path="/" manifest:media-type="` + mimeType + `"/>` +
+			`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>` +
+			`<manifest:file-entry manifest:full-path="styles.xml" manifest:media-type="text/xml"/>` +
+			`</manifest:manifest>`},
+		{"styles.xml", header + `<office:document-styles ` + odpNamespaces + `><office:styles/>` +
+			`<office:automatic-styles><style:page-layout style:name="PM1">` +
+			fmt.Sprintf(`<style:page-layout-properties fo:margin-top="0cm" fo:margin-bottom="0cm" fo:margin-left="0cm" fo:margin-right="0cm" fo:page-width="%gcm" fo:page-height="%gcm" style:print-orientation="landscape"/>`,
+				odpPageWidth, odpPageHeight) +
+			`</style:page-layout></office:automatic-styles>` +
+			`<office:master-styles><style:master-page style:name="Default" style:page-layout-name="PM1"/></office:master-styles>` +
+			`</office:document-styles>`},
+		{"content.xml", header + `<office:document-content ` + odpNamespaces + `>` +
+			`<office:automatic-styles>` + automatic.String() + `</office:automatic-styles>` +
+			`<office:body><office:presentation>` + pages.String() + `</office:presentation></office:body>` +
+			`</office:document-content>`},
+	}
+	return writeZipEntries(entries)
+}
@@ -0,0 +1,258 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// bibleBook is a book of the Bible with its Czech (ČEP) abbreviation
+type bibleBook struct {
+	Abbrev  string
+	Name    string
+	Aliases []string
+}
+
+// bibleBooks lists the books in canonical order. Abbreviations follow the
+// Český ekumenický překlad, aliases cover the common full and short names.
+var bibleBooks = []bibleBook{
+	{"Gn", "Genesis", []string{"Gen", "1M", "1Mojž", "1. Mojžíšova"}},
+	{"Ex", "Exodus", []string{"Exod", "2M", "2Mojž", "2. Mojžíšova"}},
+	{"Lv", "Leviticus", []string{"Lev", "3M", "3Mojž", "3. Mojžíšova"}},
+	{"Nu", "Numeri", []string{"Num", "4M", "4Mojž", "4. Mojžíšova"}},
+	{"Dt", "Deuteronomium", []string{"Deut", "5M", "5Mojž", "5. Mojžíšova"}},
+	{"Joz", "Jozue", nil},
+	{"Sd", "Soudců", nil},
+	{"Rt", "Rút", nil},
+	{"1S", "1. Samuelova", []string{"1Sam"}},
+	{"2S", "2. Samuelova", []string{"2Sam"}},
+	{"1Kr", "1. Královská", nil},
+	{"2Kr", "2. Královská", nil},
+	{"1Pa", "1. Paralipomenon", []string{"1Par", "1. Letopisů"}},
+	{"2Pa", "2. Paralipomenon", []string{"2Par", "2. Letopisů"}},
+	{"Ezd", "Ezdráš", nil},
+	{"Neh", "Nehemjáš", nil},
+	{"Est", "Ester", nil},
+	{"Jb", "Jób", nil},
+	{"Ž", "Žalmy", []string{"Žalm", "Ps", "Žl"}},
+	{"Př", "Přísloví", nil},
+	{"Kaz", "Kazatel", nil},
+	{"Pís", "Píseň písní", nil},
+	{"Iz", "Izajáš", []string{"Is"}},
+	{"Jr", "Jeremjáš", []string{"Jer"}},
+	{"Pl", "Pláč", nil},
+	{"Ez", "Ezechiel", nil},
+	{"Da", "Daniel", []string{"Dan"}},
+	{"Oz", "Ozeáš", nil},
+	{"Jl", "Jóel", nil},
+	{"Am", "Ámos", nil},
+	{"Abd", "Abdijáš", nil},
+	{"Jon", "Jonáš", nil},
+	{"Mi", "Micheáš", nil},
+	{"Na", "Nahum", nil},
+	{"Abk", "Abakuk", nil},
+	{"Sf", "Sofonjáš", nil},
+	{"Ag", "Ageus", nil},
+	{"Za", "Zacharjáš", nil},
+	{"Mal", "Malachiáš", nil},
+	{"Mt", "Matouš", []string{"Mat"}},
+	{"Mk", "Marek", []string{"Mar"}},
+	{"L", "Lukáš", []string{"Lk"}},
+	{"J", "Jan", []string{"Jn"}},
+	{"Sk", "Skutky", nil},
+	{"Ř", "Římanům", []string{"Řím"}},
+	{"1K", "1. Korintským", []string{"1Kor"}},
+	{"2K", "2. Korintským", []string{"2Kor"}},
+	{"Ga", "Galatským", []string{"Gal"}},
+	{"Ef", "Efezským", nil},
+	{"Fp", "Filipským", []string{"Flp"}},
+	{"Ko", "Koloským", []string{"Kol"}},
+	{"1Te", "1. Tesalonickým", []string{"1Tes"}},
+	{"2Te", "2. Tesalonickým", []string{"2Tes"}},
+	{"1Tm", "1. Timoteovi", []string{"1Tim"}},
+	{"2Tm", "2. Timoteovi", []string{"2Tim"}},
+	{"Tt", "Titovi", []string{"Tit"}},
+	{"Fm", "Filemonovi", []string{"Flm"}},
+	{"Žd", "Židům", []string{"Žid"}},
+	{"Jk", "Jakubův", []string{"Jak"}},
+	{"1Pt", "1. Petrův", []string{"1Petr"}},
+	{"2Pt", "2. Petrův", []string{"2Petr"}},
+	{"1J", "1. Janův", []string{"1Jan"}},
+	{"2J", "2. Janův", []string{"2Jan"}},
+	{"3J", "3. Janův", []string{"3Jan"}},
+	{"Ju", "Judův", []string{"Jud"}},
+	{"Zj", "Zjevení", []string{"Zjev"}},
+}
+
+// bibleBookKey normalizes a book name or abbreviation for lookup:
+// lowercase, without diacritics, dots and spaces
+func bibleBookKey(name string) string {
+	key := strings.ToLower(removeDiacritics(name))
+	return strings.NewReplacer(".", "", " ", "").Replace(key)
+}
+
+var bibleBookIndex = func() map[string]int {
+	index := map[string]int{}
+	for i, book := range bibleBooks {
+		for _, name := range append([]string{book.Abbrev, book.Name}, book.Aliases...) {
+			index[bibleBookKey(name)] = i
+		}
+	}
+	return index
+}()
+
+// lookupBibleBook finds a book by abbreviation or name
+func lookupBibleBook(name string) (bibleBook, bool) {
+	i, ok := bibleBookIndex[bibleBookKey(name)]
+	if !ok {
+		return bibleBook{}, false
+	}
+	return bibleBooks[i], true
+}
+
+// Verse positions are stored as chapter*1000+verse; a whole chapter spans
+// verses 0 to wholeChapterEnd.
+const (
+	versesPerChapter = 1000
+	wholeChapterEnd  = 999
+)
+
+// scriptureRef is one continuous passage, e.g. J 3,16-21
+type scriptureRef struct {
+	Book        string // ČEP abbreviation
+	ChapterFrom int
+	VerseFrom   int // 0 for the whole chapter
+	ChapterTo   int
+	VerseTo     int // 0 for the whole chapter
+}
+
+// startPos is the first verse position; VerseFrom 0 means the chapter start
+func (r scriptureRef) startPos() int {
+	return r.ChapterFrom*versesPerChapter + r.VerseFrom
+}
+
+// endPos is the last verse position; VerseTo 0 means the chapter end
+func (r scriptureRef) endPos() int {
+	if r.VerseTo == 0 {
+		return r.ChapterTo*versesPerChapter + wholeChapterEnd
+	}
+	return r.ChapterTo*versesPerChapter + r.VerseTo
+}
+
+// String formats the passage in Czech notation ("Mk 16,1-8", "Ž 23-24")
+func (r scriptureRef) String() string {
+	var b strings.Builder
+	b.WriteString(r.Book)
+	b.WriteString(" ")
+	b.WriteString(strconv.Itoa(r.ChapterFrom))
+	if r.VerseFrom > 0 {
+		b.WriteString("," + strconv.Itoa(r.VerseFrom))
+	}
+	switch {
+	case r.ChapterTo != r.ChapterFrom && r.VerseTo > 0:
+		b.WriteString(fmt.Sprintf("-%d,%d", r.ChapterTo, r.VerseTo))
+	case r.ChapterTo != r.ChapterFrom:
+		b.WriteString(fmt.Sprintf("-%d", r.ChapterTo))
+	case r.VerseTo != r.VerseFrom:
+		b.WriteString(fmt.Sprintf("-%d", r.VerseTo))
+	}
+	return b.String()
+}
+
+var (
+	reScriptureBook = regexp.MustCompile(`^([1-3]?\.?\p{L}+)\.?(.*)$`)
+	reChapterVerse  = regexp.MustCompile(`^(\d+)(?:,(\d+))?(?:-(\d+)(?:,(\d+))?)?$`)
+	reVerseRange    = regexp.MustCompile(`^(\d+)(?:-(\d+))?$`)
+)
+
+// parseScriptureRefs parses Czech references such as "Ž 23", "J 3,16",
+// "Mk 16,1-8", "J 3,16-4,2", "Ř 8,28.31-39" and lists separated by ";".
+// A part without a book name continues the previous book ("J 3,16; 4,1").
+func parseScriptureRefs(text string) ([]scriptureRef, error) {
+	var refs []scriptureRef
+	book := ""
+	for _, part := range strings.Split(text, ";") {
+		// spaces are insignificant: "1. Korintským 13" and "1K13" are the same
+		part = strings.Join(strings.Fields(part), "")
+		part = strings.NewReplacer("–", "-", "—", "-").Replace(part)
+		if part == "" {
+			continue
+		}
+
+		rest := part
+		if m := reScriptureBook.FindStringSubmatch(part); m != nil {
+			found, ok := lookupBibleBook(m[1])
+			if !ok {
+				return nil, fmt.Errorf("unknown bible book in %q", part)
+			}
+			book = found.Abbrev
+			rest = m[2]
+		}
+		if book == "" {
+			return nil, fmt.Errorf("missing bible book in %q", part)
+		}
+
+		partRefs, err := parseChapterVerses(book, rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reference %q: %w", part, err)
+		}
+		refs = append(refs, partRefs...)
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("empty scripture reference")
+	}
+	return refs, nil
+}
+
+// parseChapterVerses parses the part after the book name; "." separates
+// further verses of the same chapter ("3,16.18-20")
+func parseChapterVerses(book string, text string) ([]scriptureRef, error) {
+	if text == "" {
+		return nil, fmt.Errorf("missing chapter")
+	}
+	items := strings.Split(text, ".")
+	m := reChapterVerse.FindStringSubmatch(items[0])
+	if m == nil {
+		return nil, fmt.Errorf("cannot parse %q", items[0])
+	}
+
+	ref := scriptureRef{Book: book}
+	ref.ChapterFrom, _ = strconv.Atoi(m[1])
+	ref.VerseFrom, _ = strconv.Atoi(m[2])
+	ref.ChapterTo, ref.VerseTo = ref.ChapterFrom, ref.VerseFrom
+	switch {
+	case m[3] != "" && m[4] != "":
+		// J 3,16-4,2
+		ref.ChapterTo, _ = strconv.Atoi(m[3])
+		ref.VerseTo, _ = strconv.Atoi(m[4])
+	case m[3] != "" && m[2] != "":
+		// Mk 16,1-8
+		ref.VerseTo, _ = strconv.Atoi(m[3])
+	case m[3] != "":
+		// Ž 23-24
+		ref.ChapterTo, _ = strconv.Atoi(m[3])
+	}
+	if ref.ChapterFrom == 0 || ref.startPos() > ref.endPos() {
+		return nil, fmt.Errorf("invalid range %q", items[0])
+	}
+	refs := []scriptureRef{ref}
+
+	for _, item := range items[1:] {
+		vm := reVerseRange.FindStringSubmatch(item)
+		if vm == nil || ref.VerseFrom == 0 {
+			return nil, fmt.Errorf("cannot parse verses %q", item)
+		}
+		next := scriptureRef{Book: book, ChapterFrom: ref.ChapterTo, ChapterTo: ref.ChapterTo}
+		next.VerseFrom, _ = strconv.Atoi(vm[1])
+		next.VerseTo = next.VerseFrom
+		if vm[2] != "" {
+			next.VerseTo, _ = strconv.Atoi(vm[2])
+		}
+		if next.VerseFrom == 0 || next.VerseTo < next.VerseFrom {
+			return nil, fmt.Errorf("invalid verses %q", item)
+		}
+		refs = append(refs, next)
+	}
+	return refs, nil
+}
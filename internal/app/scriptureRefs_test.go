@@ -0,0 +1,75 @@
+package app
+
+import (
+	"testing"
+)
+
+func TestBibleBookIndex_NoCollisions(t *testing.T) {
+	seen := map[string]string{}
+	for _, book := range bibleBooks {
+		for _, name := range append([]string{book.Abbrev, book.Name}, book.Aliases...) {
+			key := bibleBookKey(name)
+			if other, ok := seen[key]; ok && other != book.Abbrev {
+				t.Errorf("%q of %s collides with %s", name, book.Abbrev, other)
+			}
+			seen[key] = book.Abbrev
+		}
+	}
+	if len(bibleBooks) != 66 {
+		t.Errorf("expected 66 books, got %d", len(bibleBooks))
+	}
+}
+
+func TestParseScriptureRefs(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    []string
+		wantErr bool
+	}{
+		{text: "Ž 23", want: []string{"Ž 23"}},
+		{text: "Žalm 23", want: []string{"Ž 23"}},
+		{text: "ž23", want: []string{"Ž 23"}},
+		{text: "J 3,16", want: []string{"J 3,16"}},
+		{text: "Mk 16,1–8", want: []string{"Mk 16,1-8"}},
+		{text: "J 3,16-4,2", want: []string{"J 3,16-4,2"}},
+		{text: "Ž 23-24", want: []string{"Ž 23-24"}},
+		{text: "1. Korintským 13", want: []string{"1K 13"}},
+		{text: "1K 13,1-7", want: []string{"1K 13,1-7"}},
+		{text: "Ř 8,28.31-39", want: []string{"Ř 8,28", "Ř 8,31-39"}},
+		{text: "Iz 40,1-11; 52,7; L 2", want: []string{"Iz 40,1-11", "Iz 52,7", "L 2"}},
+		{text: "Píseň písní 8,6", want: []string{"Pís 8,6"}},
+		{text: "Xy 1", wantErr: true},
+		{text: "3,16", wantErr: true},
+		{text: "J", wantErr: true},
+		{text: "J 3,20-10", wantErr: true},
+		{text: "J 3.16", wantErr: true},
+		{text: " ; ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			refs, err := parseScriptureRefs(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(refs) != len(tt.want) {
+				t.Fatalf("got %v, want %v", refs, tt.want)
+			}
+			for i, ref := range refs {
+				if ref.String() != tt.want[i] {
+					t.Errorf("ref %d = %q, want %q", i, ref.String(), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestScriptureRefPositions(t *testing.T) {
+	refs, _ := parseScriptureRefs("Ž 23")
+	if refs[0].startPos() != 23000 || refs[0].endPos() != 23999 {
+		t.Errorf("whole chapter positions = %d-%d", refs[0].startPos(), refs[0].endPos())
+	}
+	refs, _ = parseScriptureRefs("J 3,16-4,2")
+	if refs[0].startPos() != 3016 || refs[0].endPos() != 4002 {
+		t.Errorf("cross-chapter positions = %d-%d", refs[0].startPos(), refs[0].endPos())
+	}
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const czechVowels = "aeiouyáéíóúůýě"
+
+func isCzechVowel(r rune) bool {
+	return strings.ContainsRune(czechVowels, r)
+}
+
+// countCzechSyllables counts syllables of a lyric line. Every vowel (with
+// "ou" as a diphthong) forms a syllable, as does a syllabic r or l standing
+// between consonants or after a consonant at the end of a word ("vlk", "vítr").
+func countCzechSyllables(line string) int {
+	count := 0
+	for _, word := range strings.FieldsFunc(strings.ToLower(line), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		letters := []rune(word)
+		for i := 0; i < len(letters); i++ {
+			r := letters[i]
+			switch {
+			case isCzechVowel(r):
+				count++
+				if r == 'o' && i+1 < len(letters) && letters[i+1] == 'u' {
+					i++
+				}
+			case r == 'r' || r == 'l':
+				afterConsonant := i > 0 && !isCzechVowel(letters[i-1])
+				beforeConsonant := i+1 == len(letters) || !isCzechVowel(letters[i+1])
+				if afterConsonant && beforeConsonant {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+// computeMeter returns the metrical pattern of a verse in hymnal notation,
+// syllables per line joined by dots ("8.7.8.7")
+func computeMeter(lines string) string {
+	var counts []string
+	for _, line := range strings.Split(lines, "\n") {
+		if n := countCzechSyllables(line); n > 0 {
+			counts = append(counts, strconv.Itoa(n))
+		}
+	}
+	return strings.Join(counts, ".")
+}
+
+var reMeter = regexp.MustCompile(`^\d+(\.\d+)*$`)
+
+// normalizeMeter accepts "8.7.8.7", "8 7 8 7" or "8,7,8,7" and returns the dotted form
+func normalizeMeter(meter string) (string, bool) {
+	fields := strings.FieldsFunc(meter, func(r rune) bool { return r == '.' || r == ',' || unicode.IsSpace(r) })
+	normalized := strings.Join(fields, ".")
+	if normalized == "" {
+		return "", true
+	}
+	return normalized, reMeter.MatchString(normalized)
+}
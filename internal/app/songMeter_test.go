@@ -0,0 +1,52 @@
+package app
+
+import "testing"
+
+func TestCountCzechSyllables(t *testing.T) {
+	tests := []struct {
+		line string
+		want int
+	}{
+		{"Chvaliž Hospodina, slávy vždy Krále mocného,", 14},
+		{"ó duše má, neboť tužba to srdce je mého.", 14},
+		{"Shromažďte se,", 4},
+		{"vlk a krk", 3},
+		{"vítr a bratr", 5},
+		{"Kristus vstal z mrtvých", 5},
+		{"touha mou", 3},
+		{"", 0},
+		{"123 ...", 0},
+	}
+	for _, tt := range tests {
+		if got := countCzechSyllables(tt.line); got != tt.want {
+			t.Errorf("countCzechSyllables(%q) = %d, want %d", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestComputeMeter(t *testing.T) {
+	verse := "Chvaliž Hospodina, slávy vždy Krále mocného,\nó duše má, neboť tužba to srdce je mého.\n\nShromažďte se,"
+	if got := computeMeter(verse); got != "14.14.4" {
+		t.Errorf("computeMeter = %q, want 14.14.4", got)
+	}
+}
+
+func TestNormalizeMeter(t *testing.T) {
+	tests := []struct {
+		raw    string
+		want   string
+		wantOK bool
+	}{
+		{"8.7.8.7", "8.7.8.7", true},
+		{"8 7 8 7", "8.7.8.7", true},
+		{"8,7, 8,7", "8.7.8.7", true},
+		{"", "", true},
+		{"8.7 D", "8.7.D", false},
+	}
+	for _, tt := range tests {
+		got, ok := normalizeMeter(tt.raw)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("normalizeMeter(%q) = %q %v, want %q %v", tt.raw, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
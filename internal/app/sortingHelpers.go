@@ -6,7 +6,7 @@ func normalizeSortingOption(raw string) SortingOption {
 	trimmed := strings.TrimSpace(raw)
 	option := SortingOption(trimmed)
 	switch option {
-	case Entry, Title, AuthorMusic, AuthorLyric:
+	case Entry, Title, AuthorMusic, AuthorLyric, LastUsed, Chapter:
 		return option
 	default:
 		return Entry
@@ -28,6 +28,10 @@ func orderColumnForSongs(option SortingOption) string {
 		return "authorMusic"
 	case AuthorLyric:
 		return "authorLyric"
+	case LastUsed:
+		return "lastUsed DESC, entry"
+	case Chapter:
+		return "chapterPosition, entry"
 	default:
 		return "entry"
 	}
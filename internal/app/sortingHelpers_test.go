@@ -131,6 +131,16 @@ func TestOrderColumnForSongs(t *testing.T) {
 			option:   AuthorLyric,
 			expected: "authorLyric",
 		},
+		{
+			name:     "lastUsed option",
+			option:   LastUsed,
+			expected: "lastUsed DESC, entry",
+		},
+		{
+			name:     "chapter option",
+			option:   Chapter,
+			expected: "chapterPosition, entry",
+		},
 		{
 			name:     "invalid option defaults to entry",
 			option:   SortingOption("invalid"),